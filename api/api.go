@@ -0,0 +1,7 @@
+// Package api embeds the OpenAPI description of the HTTP API.
+package api
+
+import _ "embed"
+
+//go:embed api.yaml
+var Spec []byte
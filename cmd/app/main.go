@@ -1,7 +1,12 @@
 package main
 
-import "banner-service/internal/app"
+import (
+	"banner-service/internal/app"
+	"log"
+)
 
 func main() {
-	app.Start()
+	if err := app.Start(); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,90 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// pngAsset returns distinct content sniffed as image/png.
+func pngAsset(seed byte) []byte {
+	return append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{seed}, 56)...)
+}
+
+func TestAssetReferences(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	defer pool.Close()
+	assets := repository.NewAssetRepository(pool)
+
+	resp, err := client.SignUp(models.User{Username: "designer", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	upload := func(t *testing.T, content []byte, status int) models.Asset {
+		t.Helper()
+
+		resp, err := client.UploadAsset(content, token)
+		require.NoError(t, err)
+		require.Equal(t, status, resp.StatusCode(), resp.String())
+		var uploaded models.Asset
+		require.NoError(t, json.Unmarshal(resp.Body(), &uploaded))
+		return uploaded
+	}
+	referenced := upload(t, pngAsset(1), http.StatusCreated)
+	unreferenced := upload(t, pngAsset(2), http.StatusCreated)
+	unknown := strings.Repeat("f", 64)
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    testTagIDs[:1],
+		Content: json.RawMessage(fmt.Sprintf(`{"image": %q, "cdn": "https://cdn.example.com/assets/%s"}`,
+			referenced.URL, unknown)),
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), "a path on another host is not a reference: %s", resp.String())
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    testTagIDs[1:2],
+		Content:   json.RawMessage(fmt.Sprintf(`{"image": "/assets/%s"}`, unknown)),
+	}, token)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), resp.String())
+
+	var removed []string
+	deleted, err := assets.DeleteUnreferencedAssets(ctx, 0, func(hash string) error {
+		removed = append(removed, hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{unreferenced.Hash}, deleted, "only the unreferenced asset is collected")
+	assert.Equal(t, deleted, removed, "the file goes after the record")
+
+	resp, err = client.resty.R().
+		SetHeader("If-None-Match", fmt.Sprintf("%q", unreferenced.Hash)).
+		Get(addr + unreferenced.URL)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "a collected asset is not reported as unchanged")
+
+	upload(t, pngAsset(2), http.StatusCreated)
+	again := upload(t, pngAsset(1), http.StatusOK)
+	assert.Equal(t, referenced.Hash, again.Hash)
+}
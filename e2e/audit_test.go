@@ -0,0 +1,81 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "auditor", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	started := time.Now()
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    testTagIDs,
+		Content:   json.RawMessage(testContent),
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+	require.NoError(t, err)
+
+	resp, err = client.PatchBannerRaw(bannerId, "application/json", `{"content": {"hello": "new world"}}`, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode())
+
+	resp, err = client.DeleteBanner(bannerId, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+	actions := func(t *testing.T, query url.Values) []models.AuditAction {
+		t.Helper()
+
+		query.Set("banner_id", fmt.Sprint(bannerId))
+		resp, err := client.GetAuditEvents(query, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+		var page models.AuditPage
+		require.NoError(t, json.Unmarshal(resp.Body(), &page))
+		var actions []models.AuditAction
+		for _, event := range page.Events {
+			assert.Equal(t, "auditor", event.Actor)
+			actions = append(actions, event.Action)
+		}
+		return actions
+	}
+
+	t.Run("newest first", func(t *testing.T) {
+		assert.Equal(t, []models.AuditAction{models.AuditDelete, models.AuditUpdate, models.AuditCreate}, actions(t, url.Values{}))
+	})
+
+	t.Run("action filter", func(t *testing.T) {
+		assert.Equal(t, []models.AuditAction{models.AuditUpdate}, actions(t, url.Values{"action": {"update"}}))
+	})
+
+	t.Run("time filters in another zone", func(t *testing.T) {
+		zone := time.FixedZone("UTC+14", 14*60*60)
+		from := started.Add(-time.Minute).In(zone).Format(time.RFC3339)
+		to := time.Now().Add(time.Minute).In(zone).Format(time.RFC3339)
+
+		assert.Len(t, actions(t, url.Values{"from": {from}, "to": {to}}), 3)
+		assert.Empty(t, actions(t, url.Values{"to": {from}}))
+	})
+}
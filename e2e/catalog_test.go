@@ -0,0 +1,126 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestConcurrentFeaturesGetDistinctIds(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	const count = 10
+	ids := make([]uint64, count)
+	var g errgroup.Group
+	for i := range ids {
+		i := i
+		g.Go(func() error {
+			resp, err := client.CreateFeature(controller.FeatureDTO{
+				CatalogEntryDTO: controller.CatalogEntryDTO{Name: fmt.Sprintf("feature %d", i)},
+			}, token)
+			if err != nil {
+				return err
+			} else if resp.StatusCode() != http.StatusCreated {
+				return fmt.Errorf("feature %d: %d %s", i, resp.StatusCode(), resp.String())
+			}
+
+			var feature models.Feature
+			if err = json.Unmarshal(resp.Body(), &feature); err != nil {
+				return err
+			}
+			ids[i] = feature.FeatureId
+			return nil
+		})
+	}
+	require.NoError(t, g.Wait(), "features without an id never conflict")
+
+	seen := make(map[uint64]bool, count)
+	for _, id := range ids {
+		assert.False(t, seen[id], "id %d is given out once", id)
+		seen[id] = true
+	}
+}
+
+func TestImportOverFeatureDefaultKeepsIt(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	resp, err = client.CreateFeature(controller.FeatureDTO{
+		CatalogEntryDTO: controller.CatalogEntryDTO{Name: "with default"},
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+	var feature models.Feature
+	require.NoError(t, json.Unmarshal(resp.Body(), &feature))
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: feature.FeatureId,
+		TagIds:    testTagIDs[:1],
+		Content:   json.RawMessage(`{"title": "default"}`),
+		IsActive:  true,
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+	bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+	require.NoError(t, err)
+
+	resp, err = client.resty.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetHeader("Content-Type", "application/json").
+		SetBody(fmt.Sprintf(`{"default_banner_id": %d}`, bannerId)).
+		Patch(fmt.Sprintf("%s/feature/%d", addr, feature.FeatureId))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+	resp, err = client.resty.R().SetHeader("Authorization", "Bearer "+token).Get(addr + "/banner/export")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+	exported := strings.NewReplacer(`"title":"default"`, `"title":"imported"`, `"title": "default"`, `"title": "imported"`).
+		Replace(resp.String())
+	require.Contains(t, exported, "imported")
+
+	resp, err = client.resty.R().
+		SetHeader("Authorization", "Bearer "+token).
+		SetHeader("Content-Type", "application/x-ndjson").
+		SetQueryParam("mode", "upsert").
+		SetBody(exported).
+		Post(addr + "/banner/import")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+	resp, err = client.resty.R().
+		SetHeader("Authorization", "Bearer "+token).
+		Get(fmt.Sprintf("%s/feature/%d", addr, feature.FeatureId))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+	require.NoError(t, json.Unmarshal(resp.Body(), &feature))
+	require.NotNil(t, feature.DefaultBannerId, "an upsert updates the banner in place")
+	assert.Equal(t, bannerId, *feature.DefaultBannerId)
+
+	resp, err = client.GetBanner(testTagIDs[1], feature.FeatureId, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+	assert.JSONEq(t, `{"title": "imported"}`, resp.String(), "the default serves the imported content")
+}
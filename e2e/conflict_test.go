@@ -0,0 +1,65 @@
+package e2e
+
+import (
+	"banner-service/internal/apierror"
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestPairConflict(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	banner := controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    []uint64{testTagIDs[0]},
+		Content:   json.RawMessage(testContent),
+		IsActive:  true,
+	}
+	resp, err = client.CreateBanner(banner, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+
+	assertConflict := func(t *testing.T, resp *resty.Response) {
+		t.Helper()
+
+		require.Equal(t, http.StatusConflict, resp.StatusCode(), resp.String())
+		var body apierror.Response
+		require.NoError(t, json.Unmarshal(resp.Body(), &body))
+		assert.Equal(t, apierror.CodeAlreadyExists, body.Code)
+	}
+
+	t.Run("create", func(t *testing.T) {
+		banner.TagIds = []uint64{testTagIDs[1], testTagIDs[0]}
+		resp, err := client.CreateBanner(banner, token)
+		require.NoError(t, err)
+		assertConflict(t, resp)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		banner.TagIds = []uint64{testTagIDs[1]}
+		resp, err := client.CreateBanner(banner, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+		bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+		require.NoError(t, err)
+
+		resp, err = client.PatchBannerRaw(bannerId, "application/json",
+			`{"feature_id": `+strconv.FormatUint(testFeatureID, 10)+`, "tag_ids": [`+strconv.FormatUint(testTagIDs[0], 10)+`], "is_active": true}`, token)
+		require.NoError(t, err)
+		assertConflict(t, resp)
+	})
+}
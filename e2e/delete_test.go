@@ -0,0 +1,134 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	create := func(t *testing.T, featureId uint64, tagIds []uint64, content string) uint64 {
+		t.Helper()
+
+		resp, err := client.CreateBanner(controller.CreateDTO{
+			FeatureId: featureId,
+			TagIds:    tagIds,
+			Content:   json.RawMessage(content),
+			IsActive:  true,
+		}, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+		bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+		require.NoError(t, err)
+		return bannerId
+	}
+
+	deleted := create(t, testFeatureID, []uint64{testTagIDs[0]}, testContent)
+	kept := create(t, testFeatureID, []uint64{testTagIDs[1]}, testContent)
+	create(t, newTestFeatureID, []uint64{testTagIDs[0]}, testContent)
+
+	t.Run("feature and tag are both matched", func(t *testing.T) {
+		resp, err := client.MarkBannersAsDeleted(url.Values{
+			"feature_id": {fmt.Sprint(testFeatureID)},
+			"tag_id":     {fmt.Sprint(testTagIDs[0])},
+		}, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode(), resp.String())
+
+		resp, err = client.GetBanner(testTagIDs[0], testFeatureID, token)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode(), "the deleted banner is no longer served")
+
+		resp, err = client.GetBanner(testTagIDs[1], testFeatureID, token)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode(), "a banner of the feature with another tag is kept")
+
+		resp, err = client.GetBanner(testTagIDs[0], newTestFeatureID, token)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode(), "a banner of the tag with another feature is kept")
+	})
+
+	t.Run("filter is required", func(t *testing.T) {
+		resp, err := client.MarkBannersAsDeleted(url.Values{}, token)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+	})
+
+	var replacement uint64
+	t.Run("pair of a deleted banner is reused", func(t *testing.T) {
+		replacement = create(t, testFeatureID, []uint64{testTagIDs[0]}, newTestContent)
+
+		resp, err := client.GetBanner(testTagIDs[0], testFeatureID, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode())
+		assert.JSONEq(t, newTestContent, resp.String())
+	})
+
+	t.Run("restore of a reused pair conflicts", func(t *testing.T) {
+		resp, err := client.RestoreBanner(deleted, token)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode(), resp.String())
+	})
+
+	t.Run("restore by feature and tag", func(t *testing.T) {
+		resp, err := client.DeleteBanner(replacement, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+		resp, err = client.MarkBannersAsDeleted(url.Values{"tag_id": {fmt.Sprint(testTagIDs[1])}}, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+		resp, err = client.RestoreBanners(url.Values{
+			"feature_id": {fmt.Sprint(testFeatureID)},
+			"tag_id":     {fmt.Sprint(testTagIDs[0])},
+		}, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+		var restored controller.RestoredDTO
+		require.NoError(t, json.Unmarshal(resp.Body(), &restored))
+		assert.Equal(t, []uint64{deleted}, restored.BannerIds, "banner %d has another tag", kept)
+
+		resp, err = client.GetBanner(testTagIDs[0], testFeatureID, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), "the restored banner is served at once")
+		assert.JSONEq(t, testContent, resp.String())
+	})
+
+}
+
+func TestMarkAndRestoreBannersRequireFilter(t *testing.T) {
+	Setup()
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	defer pool.Close()
+	banners := repository.NewBannerRepository(pool)
+
+	_, err = banners.MarkBannersAsDeleted(ctx, nil, nil)
+	assert.ErrorIs(t, err, repository.ErrInvalidFilter, "no filter does not mark every banner")
+	_, _, err = banners.RestoreBanners(ctx, nil, nil)
+	assert.ErrorIs(t, err, repository.ErrInvalidFilter, "no filter does not restore every banner")
+}
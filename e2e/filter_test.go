@@ -0,0 +1,83 @@
+package e2e
+
+import (
+	"banner-service/internal/apierror"
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func TestContentFilters(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	create := func(tagId uint64, content string) uint64 {
+		resp, err := client.CreateBanner(controller.CreateDTO{
+			FeatureId: testFeatureID,
+			TagIds:    []uint64{tagId},
+			Content:   json.RawMessage(content),
+			IsActive:  true,
+		}, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+		bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+		require.NoError(t, err)
+		return bannerId
+	}
+	world := create(testTagIDs[0], testContent)
+	create(testTagIDs[1], newTestContent)
+
+	// The old content of a patched banner no longer matches.
+	patched := create(testTagIDs[2], testContent)
+	resp, err = client.PatchBannerRaw(patched, "application/json", fmt.Sprintf(`{"content": %s, "is_active": true}`, newTestContent), token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+	list := func(query url.Values) *resty.Response {
+		resp, err := client.resty.R().SetQueryParamsFromValues(query).
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+			Get(addr + "/banner")
+		require.NoError(t, err)
+		return resp
+	}
+	bannerIds := func(resp *resty.Response) []uint64 {
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+		var banners []models.Banner
+		require.NoError(t, json.Unmarshal(resp.Body(), &banners))
+		ids := make([]uint64, 0, len(banners))
+		for _, banner := range banners {
+			ids = append(ids, banner.BannerId)
+		}
+		return ids
+	}
+
+	t.Run("containment", func(t *testing.T) {
+		assert.Equal(t, []uint64{world}, bannerIds(list(url.Values{"content": {testContent}})))
+	})
+
+	t.Run("path", func(t *testing.T) {
+		assert.Equal(t, []uint64{world}, bannerIds(list(url.Values{"content_path": {`$.hello ? (@ == "world")`}})))
+	})
+
+	t.Run("malformed path", func(t *testing.T) {
+		resp := list(url.Values{"content_path": {`$.hello ? (`}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode(), resp.String())
+		var body apierror.Response
+		require.NoError(t, json.Unmarshal(resp.Body(), &body))
+		assert.Equal(t, "invalid filter: invalid content path", body.Error, "the driver message stays in the log")
+	})
+}
@@ -0,0 +1,57 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestLocaleNegotiatedAgainstBannerLocales(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "translator", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId:     testFeatureID,
+		TagIds:        testTagIDs[:1],
+		Content:       json.RawMessage(`{"title": "hello"}`),
+		Localized:     map[string]json.RawMessage{"RU": json.RawMessage(`{"title": "привет"}`)},
+		DefaultLocale: "EN",
+		IsActive:      true,
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+
+	for _, tt := range []struct {
+		languages string
+		locale    string
+		content   string
+	}{
+		{"kk, ru;q=0.5", "ru", `{"title": "привет"}`},
+		{"kk, en;q=0.5, ru;q=0.1", "en", `{"title": "hello"}`},
+		{"kk", "en", `{"title": "hello"}`},
+	} {
+		resp, err = client.resty.R().SetQueryParams(map[string]string{
+			"tag_id":     fmt.Sprint(testTagIDs[0]),
+			"feature_id": fmt.Sprint(testFeatureID),
+		}).
+			SetHeader("Accept-Language", tt.languages).
+			SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+			Get(addr + "/user_banner")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+		assert.Equal(t, tt.locale, resp.Header().Get("Content-Language"), tt.languages)
+		assert.JSONEq(t, tt.content, resp.String(), tt.languages)
+	}
+}
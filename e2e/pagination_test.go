@@ -0,0 +1,68 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCursorFollowsSortColumn(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	var created []uint64
+	for _, tagId := range testTagIDs[:3] {
+		resp, err := client.CreateBanner(controller.CreateDTO{
+			FeatureId: testFeatureID,
+			TagIds:    []uint64{tagId},
+			Content:   json.RawMessage(testContent),
+			IsActive:  true,
+		}, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+		bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+		require.NoError(t, err)
+		created = append(created, bannerId)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Deactivating the oldest banner makes it the last one updated.
+	resp, err = client.BulkUpdate(fmt.Sprintf(`{"operations": [{"action": "deactivate", "banner_ids": [%d]}]}`, created[0]), token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+	var seen []uint64
+	filter := models.FilterBanner{Limit: 1, SortBy: models.SortByUpdatedAt, Order: models.OrderAsc}
+	for i := 0; i <= len(created); i++ {
+		resp, err := client.GetFilteredBanners(filter, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+		var banners []models.Banner
+		require.NoError(t, json.Unmarshal(resp.Body(), &banners))
+		for _, banner := range banners {
+			seen = append(seen, banner.BannerId)
+		}
+
+		filter.Cursor = resp.Header().Get(controller.NextCursorHeader)
+		if filter.Cursor == "" {
+			break
+		}
+	}
+
+	assert.Equal(t, []uint64{created[1], created[2], created[0]}, seen, "every banner is listed once in update order")
+}
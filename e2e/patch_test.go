@@ -0,0 +1,79 @@
+package e2e
+
+import (
+	"banner-service/internal/apierror"
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestPatchContent(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	resp, err = client.SignUp(models.User{Username: "reader", Password: "12345678", Role: "user"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	userToken := string(resp.Body())
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    []uint64{testTagIDs[0]},
+		Content:   json.RawMessage(`{"title": "hello", "text": "world"}`),
+		IsActive:  true,
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+	bannerId, err := strconv.ParseUint(string(resp.Body()), 10, 64)
+	require.NoError(t, err)
+
+	assertContent := func(t *testing.T, expected string) {
+		t.Helper()
+
+		resp, err := client.GetBanner(testTagIDs[0], testFeatureID, userToken)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), "the banner stays active: %s", resp.String())
+		assert.JSONEq(t, expected, resp.String())
+	}
+
+	t.Run("merge patch", func(t *testing.T) {
+		resp, err := client.PatchBannerRaw(bannerId, "application/merge-patch+json", `{"text": null, "url": "/x"}`, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+		assertContent(t, `{"title": "hello", "url": "/x"}`)
+	})
+
+	t.Run("json patch", func(t *testing.T) {
+		resp, err := client.PatchBannerRaw(bannerId, "application/json-patch+json",
+			`[{"op": "test", "path": "/title", "value": "hello"}, {"op": "replace", "path": "/title", "value": "hi"}]`, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+		assertContent(t, `{"title": "hi", "url": "/x"}`)
+	})
+
+	t.Run("failed test op", func(t *testing.T) {
+		resp, err := client.PatchBannerRaw(bannerId, "application/json-patch+json",
+			`[{"op": "test", "path": "/title", "value": "hello"}, {"op": "remove", "path": "/url"}]`, token)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusConflict, resp.StatusCode(), resp.String())
+		var body apierror.Response
+		require.NoError(t, json.Unmarshal(resp.Body(), &body))
+		assert.Equal(t, apierror.CodePatchTestFailed, body.Code)
+
+		assertContent(t, `{"title": "hi", "url": "/x"}`)
+	})
+}
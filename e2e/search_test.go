@@ -0,0 +1,76 @@
+package e2e
+
+import (
+	controller "banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+)
+
+func TestSearchHighlightIsEscaped(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    []uint64{testTagIDs[0]},
+		Content:   json.RawMessage(`{"title": "<script>alert(1)</script> summer sale"}`),
+		IsActive:  true,
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+
+	resp, err = client.resty.R().SetQueryParam("q", "sale").
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		Get(addr + "/banner/search")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+	var results []models.BannerSearchResult
+	require.NoError(t, json.Unmarshal(resp.Body(), &results))
+	require.Len(t, results, 1)
+	assert.JSONEq(t, `{"title": "&lt;script&gt;alert(1)&lt;/script&gt; summer <mark>sale</mark>"}`, string(results[0].Highlight))
+}
+
+func TestSearchFindsLocalizedContent(t *testing.T) {
+	Setup()
+
+	client := testClient{resty.New()}
+
+	resp, err := client.SignUp(models.User{Username: "qwerty", Password: "12345678", Role: "admin"})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode())
+	token := string(resp.Body())
+
+	resp, err = client.CreateBanner(controller.CreateDTO{
+		FeatureId: testFeatureID,
+		TagIds:    []uint64{testTagIDs[0]},
+		Content:   json.RawMessage(`{"title": "summer sale"}`),
+		Localized: map[string]json.RawMessage{"ru": json.RawMessage(`{"title": "летняя распродажа"}`)},
+		IsActive:  true,
+	}, token)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode(), resp.String())
+
+	resp, err = client.resty.R().SetQueryParam("q", "распродажа").
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		Get(addr + "/banner/search")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode(), resp.String())
+
+	var results []models.BannerSearchResult
+	require.NoError(t, json.Unmarshal(resp.Body(), &results))
+	require.Len(t, results, 1)
+	assert.JSONEq(t, `{"title": "summer sale"}`, string(results[0].Highlight), "the highlight is of the default content")
+}
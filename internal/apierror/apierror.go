@@ -0,0 +1,224 @@
+package apierror
+
+import (
+	"banner-service/internal/asset"
+	"banner-service/internal/auth"
+	"banner-service/internal/cursor"
+	"banner-service/internal/patch"
+	"banner-service/internal/repository"
+	"banner-service/internal/reqctx"
+	"banner-service/internal/schema"
+	"banner-service/internal/template"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeBadRequest         Code = "bad_request"
+	CodeInvalidParameter   Code = "invalid_parameter"
+	CodeInvalidBody        Code = "invalid_body"
+	CodeInvalidCursor      Code = "invalid_cursor"
+	CodeInvalidFilter      Code = "invalid_filter"
+	CodeInvalidImport      Code = "invalid_import"
+	CodeInvalidBulk        Code = "invalid_bulk"
+	CodeInvalidReference   Code = "invalid_reference"
+	CodeInvalidSchema      Code = "invalid_schema"
+	CodeInvalidContent     Code = "invalid_content"
+	CodeMissingVariable    Code = "missing_variable"
+	CodeInvalidLocale      Code = "invalid_locale"
+	CodeInvalidPatch       Code = "invalid_patch"
+	CodeAssetTooLarge      Code = "asset_too_large"
+	CodeUnsupportedAsset   Code = "unsupported_asset_type"
+	CodeInvalidEvent       Code = "invalid_event"
+	CodeInvalidCap         Code = "invalid_frequency_cap"
+	CodeUnauthorized       Code = "unauthorized"
+	CodeInvalidCredentials Code = "invalid_credentials"
+	CodeForbidden          Code = "forbidden"
+	CodeBannerInactive     Code = "banner_inactive"
+	CodeNotFound           Code = "not_found"
+	CodeAlreadyExists      Code = "already_exists"
+	CodeInUse              Code = "in_use"
+	CodePatchTestFailed    Code = "patch_test_failed"
+	CodeValidationFailed   Code = "validation_failed"
+	CodeMethodNotAllowed   Code = "method_not_allowed"
+	CodeInternal           Code = "internal"
+)
+
+// Error is an error that is safe to show to the client. Cause, if any, is
+// only logged.
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	Details []FieldError
+	Cause   error
+}
+
+// FieldError describes a single violation: a parameter name or a JSON Pointer
+// into the request body, and what is wrong with it.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// WithCause attaches the underlying error for the logs.
+func (e *Error) WithCause(err error) *Error {
+	e.Cause = err
+	return e
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func InvalidParameter(name string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("invalid %s", name))
+}
+
+func InvalidBody(err error) *Error {
+	return New(http.StatusBadRequest, CodeInvalidBody, "invalid request body").WithCause(err)
+}
+
+func Validation(details []FieldError) *Error {
+	e := New(http.StatusBadRequest, CodeValidationFailed, "request validation failed")
+	e.Details = details
+	return e
+}
+
+func Unauthorized() *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, "authorization required")
+}
+
+func Forbidden() *Error {
+	return New(http.StatusForbidden, CodeForbidden, "access denied")
+}
+
+func Internal(err error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "internal server error").WithCause(err)
+}
+
+// Response is the body of every error response.
+type Response struct {
+	Error     string       `json:"error"`
+	Code      Code         `json:"code"`
+	RequestId string       `json:"request_id,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
+}
+
+// From maps err to the error shown to the client. Domain errors get their own
+// status, everything unknown becomes an internal error.
+func From(err error) *Error {
+	var (
+		apiErr       *Error
+		violationErr *schema.ViolationError
+	)
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr
+	case errors.As(err, &violationErr):
+		return contentError(violationErr)
+	case errors.Is(err, repository.ErrNotFound):
+		return New(http.StatusNotFound, CodeNotFound, "not found").WithCause(err)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return New(http.StatusConflict, CodeAlreadyExists, "already exists").WithCause(err)
+	case errors.Is(err, repository.ErrBannerInactive):
+		return New(http.StatusForbidden, CodeBannerInactive, "banner is inactive").WithCause(err)
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return New(http.StatusUnauthorized, CodeInvalidCredentials, "invalid login or password").WithCause(err)
+	case errors.Is(err, cursor.ErrInvalidCursor):
+		return New(http.StatusBadRequest, CodeInvalidCursor, "invalid cursor").WithCause(err)
+	case errors.Is(err, repository.ErrInvalidFilter):
+		return New(http.StatusBadRequest, CodeInvalidFilter, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInvalidImport):
+		return New(http.StatusBadRequest, CodeInvalidImport, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInvalidBulk):
+		return New(http.StatusBadRequest, CodeInvalidBulk, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInvalidReference):
+		return New(http.StatusBadRequest, CodeInvalidReference, err.Error()).WithCause(err)
+	case errors.Is(err, template.ErrMissingVariable):
+		return New(http.StatusBadRequest, CodeMissingVariable, err.Error()).WithCause(err)
+	case errors.Is(err, schema.ErrInvalidSchema):
+		return New(http.StatusBadRequest, CodeInvalidSchema, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInvalidLocale):
+		return New(http.StatusBadRequest, CodeInvalidLocale, err.Error()).WithCause(err)
+	case errors.Is(err, patch.ErrInvalidPatch):
+		return New(http.StatusBadRequest, CodeInvalidPatch, err.Error()).WithCause(err)
+	case errors.Is(err, patch.ErrTestFailed):
+		return New(http.StatusConflict, CodePatchTestFailed, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInvalidEvent):
+		return New(http.StatusBadRequest, CodeInvalidEvent, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInvalidFrequencyCap):
+		return New(http.StatusBadRequest, CodeInvalidCap, err.Error()).WithCause(err)
+	case errors.Is(err, asset.ErrTooLarge):
+		return New(http.StatusRequestEntityTooLarge, CodeAssetTooLarge, err.Error()).WithCause(err)
+	case errors.Is(err, asset.ErrUnsupportedType):
+		return New(http.StatusUnsupportedMediaType, CodeUnsupportedAsset, err.Error()).WithCause(err)
+	case errors.Is(err, repository.ErrInUse):
+		return New(http.StatusConflict, CodeInUse, err.Error()).WithCause(err)
+	default:
+		return Internal(err)
+	}
+}
+
+// contentError lists the schema violations as pointers into the content, or
+// the localized content, of the request body.
+func contentError(err *schema.ViolationError) *Error {
+	field := "/content"
+	if err.Locale != "" {
+		field = "/localized/" + err.Locale
+	}
+
+	e := New(http.StatusBadRequest, CodeInvalidContent, err.Error()).WithCause(err)
+	e.Details = make([]FieldError, 0, len(err.Violations))
+	for _, violation := range err.Violations {
+		e.Details = append(e.Details, FieldError{Field: field + violation.Path, Message: violation.Message})
+	}
+	return e
+}
+
+// Write sends err as a JSON error response and logs server errors together
+// with their cause.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := From(err)
+	requestId := reqctx.GetRequestId(r.Context())
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Printf("request %s: %s %s: %v", requestId, r.Method, r.URL.Path, apiErr)
+	}
+
+	body, marshalErr := json.Marshal(Response{
+		Error:     apiErr.Message,
+		Code:      apiErr.Code,
+		RequestId: requestId,
+		Details:   apiErr.Details,
+	})
+	if marshalErr != nil {
+		http.Error(w, apiErr.Message, apiErr.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	_, _ = w.Write(body)
+}
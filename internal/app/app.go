@@ -1,63 +1,220 @@
 package app
 
 import (
+	"banner-service/api"
+	"banner-service/internal/asset"
 	AuthProvider "banner-service/internal/auth"
 	"banner-service/internal/config"
+	controllergrpc "banner-service/internal/controller/grpc"
 	controllerhttp "banner-service/internal/controller/http"
+	"banner-service/internal/frequency"
 	"banner-service/internal/middleware"
 	"banner-service/internal/models"
+	"banner-service/internal/notifier"
 	"banner-service/internal/repository"
+	"banner-service/internal/reqctx"
+	AssetService "banner-service/internal/service/asset"
 	BannerService "banner-service/internal/service/banner"
+	CatalogService "banner-service/internal/service/catalog"
+	EventService "banner-service/internal/service/event"
+	WebhookService "banner-service/internal/service/webhook"
+	"banner-service/internal/webhook"
 	"banner-service/internal/worker"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jellydator/ttlcache/v3"
 	"github.com/rs/cors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
-func Start() {
-	ctx := context.Background()
+// runner is a background worker; it returns once ctx is done.
+type runner interface {
+	Start(ctx context.Context)
+}
+
+// App is the service: the HTTP and gRPC servers and the background workers
+// sharing one database pool.
+type App struct {
+	cfg        *config.Config
+	pool       *pgxpool.Pool
+	closeSinks func()
+	workers    []runner
+	httpServer *http.Server
+	grpcServer *http.Server
+	grpcCalls  requestTracker
+	// shutdown is closed when the servers start shutting down, see
+	// reqctx.GetShutdown.
+	shutdown chan struct{}
+}
+
+// Start serves until SIGINT or SIGTERM and returns the error that stopped
+// the service, if any.
+func Start() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// A second signal kills the process instead of waiting for the drain.
+		<-ctx.Done()
+		stop()
+	}()
+
 	cfg := config.NewConfig()
+	a, err := New(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("unable to start: %w", err)
+	}
+	if err = a.Run(ctx); err != nil {
+		return fmt.Errorf("unable to serve: %w", err)
+	}
+	log.Println("shutdown complete")
+	return nil
+}
+
+// New wires the service. Nothing runs until Run or Serve.
+func New(ctx context.Context, cfg *config.Config) (*App, error) {
 	pool, err := pgxpool.New(ctx, cfg.PgDSN)
 	if err != nil {
-		log.Fatalf("Unable to connect to database: %v\n", err)
+		return nil, fmt.Errorf("unable to connect to database: %w", err)
 	}
-	defer pool.Close()
 	if err := pool.Ping(ctx); err != nil {
-		fmt.Print(err)
+		log.Printf("database is unavailable: %v", err)
+	}
+
+	a := &App{
+		cfg:      cfg,
+		pool:     pool,
+		shutdown: make(chan struct{}),
 	}
+	if err = a.wire(); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *App) wire() error {
+	cfg, pool := a.cfg, a.pool
 
 	authRepo := repository.NewAuthRepository(pool)
 	tokenCache := ttlcache.New[string, models.UserResources](ttlcache.
 		WithTTL[string, models.UserResources](cfg.TokenTTL),
 		ttlcache.WithCapacity[string, models.UserResources](cfg.TokenCacheCapacity),
 	)
-	bannerCache := ttlcache.New[models.FeatureTag, models.BannerContent](ttlcache.
-		WithTTL[models.FeatureTag, models.BannerContent](cfg.BannerTTL),
-		ttlcache.WithCapacity[models.FeatureTag, models.BannerContent](cfg.TokenCacheCapacity),
+	bannerCache := ttlcache.New[models.BannerCacheKey, models.BannerContent](ttlcache.
+		WithTTL[models.BannerCacheKey, models.BannerContent](cfg.BannerTTL),
+		ttlcache.WithCapacity[models.BannerCacheKey, models.BannerContent](cfg.TokenCacheCapacity),
 	)
 
 	tokenProvider := AuthProvider.NewTokenProvider(tokenCache, cfg.PrivateKey, cfg.PublicKey, cfg.TokenTTL)
 
 	bannerRepo := repository.NewBannerRepository(pool)
+	webhookRepo := repository.NewWebhookRepository(pool)
+	catalogRepo := repository.NewCatalogRepository(pool)
+	assetRepo := repository.NewAssetRepository(pool)
+	eventRepo := repository.NewEventRepository(pool)
+
+	assetStorage, err := asset.NewFileStorage(cfg.AssetDir)
+	if err != nil {
+		return fmt.Errorf("unable to create asset storage: %w", err)
+	}
 
 	authService := AuthProvider.NewAuthProvider(AuthProvider.Deps{
 		AuthRepo:      authRepo,
 		TokenProvider: tokenProvider,
 	})
 
-	bannerService := BannerService.NewService(BannerService.Deps{BannerRepo: bannerRepo, Cache: bannerCache})
-	bannerTicker := worker.NewBannerCollector(bannerRepo)
-	go bannerTicker.Start(ctx)
+	bannerHub := notifier.NewHub()
+	bannerListener := notifier.NewListener(cfg.PgDSN, bannerRepo, bannerHub)
+	capStore := frequency.NewMemoryStore()
+
+	bannerService := BannerService.NewService(BannerService.Deps{
+		BannerRepo:        bannerRepo,
+		Cache:             bannerCache,
+		Notifier:          bannerHub,
+		Catalog:           catalogRepo,
+		Schemas:           catalogRepo,
+		Assets:            assetRepo,
+		Caps:              capStore,
+		DeleteGracePeriod: cfg.DeleteGracePeriod,
+		SnapshotWait:      cfg.SnapshotWait,
+		StrictReferences:  cfg.StrictReferences,
+		StrictTemplates:   cfg.StrictTemplates,
+		Locales:           cfg.Locales,
+	})
+	bannerTicker := worker.NewBannerCollector(bannerRepo, cfg.DeleteGracePeriod)
+	assetCollector := worker.NewAssetCollector(assetRepo, assetStorage, cfg.AssetGracePeriod)
+	eventBuffer := worker.NewEventBuffer(eventRepo, cfg.EventFlushInterval, cfg.EventBatchSize, cfg.EventBufferCapacity)
+
+	sinks, closeSinks, err := newOutboxSinks(cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create outbox sinks: %w", err)
+	}
+	a.closeSinks = closeSinks
+	sinks = append(sinks, webhook.NewSubscriptionSink(webhookRepo))
+	outboxRelay := worker.NewOutboxRelay(bannerRepo, sinks, cfg.OutboxPollInterval, cfg.OutboxMaxAttempts, cfg.OutboxRetryBackoff,
+		cfg.OutboxRetention)
+
+	webhookClient := webhook.NewClient(&http.Client{Timeout: cfg.WebhookTimeout})
+	webhookDispatcher := worker.NewWebhookDispatcher(webhookRepo, webhookClient, cfg.WebhookPollInterval, cfg.WebhookMaxAttempts, cfg.WebhookRetryBackoff,
+		cfg.WebhookTimeout)
+
+	a.workers = []runner{bannerListener, capStore, bannerTicker, assetCollector, eventBuffer, outboxRelay, webhookDispatcher}
+
+	webhookService := WebhookService.NewService(WebhookService.Deps{WebhookRepo: webhookRepo})
+	catalogService := CatalogService.NewService(CatalogService.Deps{CatalogRepo: catalogRepo, BannerCache: bannerService})
+	eventService := EventService.NewService(EventService.Deps{EventRepo: eventRepo, Buffer: eventBuffer})
+	assetService := AssetService.NewService(AssetService.Deps{
+		AssetRepo: assetRepo,
+		Storage:   assetStorage,
+		MaxSize:   cfg.AssetMaxSize,
+		MimeTypes: cfg.AssetMimeTypes,
+	})
+
+	requestValidator, err := middleware.NewRequestValidator(api.Spec)
+	if err != nil {
+		closeSinks()
+		return fmt.Errorf("unable to create request validator: %w", err)
+	}
 
 	ctr := controllerhttp.NewController(
 		controllerhttp.AuthProvider{AuthManagement: authService, TokenProvider: tokenProvider},
 		controllerhttp.BannerService{BannerManagement: bannerService},
+		controllerhttp.WebhookService{WebhookManagement: webhookService},
+		controllerhttp.CatalogService{CatalogManagement: catalogService},
+		controllerhttp.AssetService{AssetManagement: assetService},
+		controllerhttp.EventService{EventManagement: eventService},
+		requestValidator,
 	)
 
+	grpcCtr := controllergrpc.NewController(bannerService, tokenProvider)
+	// gRPC clients speak HTTP/2 without TLS, which needs h2c. Its connections
+	// carry long-lived streams, so only the header and idle timeouts apply.
+	h2s := &http2.Server{IdleTimeout: cfg.IdleTimeout}
+	grpcHandler := h2c.NewHandler(a.grpcCalls.Middleware(grpcCtr.NewHandler()), h2s)
+	a.grpcServer = &http.Server{
+		Handler:           middleware.RequestId(middleware.PanicRecovery(middleware.LogRequest(grpcHandler))),
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		BaseContext:       a.baseContext,
+	}
+	// Shutdown then sends GOAWAY on the h2c connections, which it does not
+	// track otherwise.
+	if err = http2.ConfigureServer(a.grpcServer, h2s); err != nil {
+		closeSinks()
+		return fmt.Errorf("unable to configure gRPC server: %w", err)
+	}
+
 	router := ctr.NewRouter()
 
 	c := cors.New(cors.Options{
@@ -65,9 +222,133 @@ func Start() {
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
 	})
 
-	err = http.ListenAndServe(cfg.Port, middleware.PanicRecovery(middleware.LogRequest(c.Handler(router))))
+	// Streams and long polls lift the read and write timeouts for themselves.
+	a.httpServer = &http.Server{
+		Handler:      middleware.RequestId(middleware.PanicRecovery(middleware.LogRequest(c.Handler(router)))),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		BaseContext:  a.baseContext,
+	}
+
+	return nil
+}
+
+func (a *App) baseContext(net.Listener) context.Context {
+	return reqctx.SetShutdown(context.Background(), a.shutdown)
+}
+
+// Run listens on the configured ports and serves until ctx is done.
+func (a *App) Run(ctx context.Context) error {
+	httpListener, err := net.Listen("tcp", a.cfg.Port)
+	if err != nil {
+		a.close()
+		return err
+	}
+	grpcListener, err := net.Listen("tcp", a.cfg.GrpcPort)
 	if err != nil {
-		log.Fatalf("Unable to start server: %v\n", err)
+		_ = httpListener.Close()
+		a.close()
+		return err
+	}
+	return a.Serve(ctx, httpListener, grpcListener)
+}
+
+// Serve runs the workers and serves the listeners until ctx is done or a
+// server fails, and then shuts down in order: streams end, in-flight requests
+// drain until SHUTDOWN_TIMEOUT, the workers stop (the event buffer flushes on
+// the way out) within another SHUTDOWN_TIMEOUT, and the outbox sinks and the
+// pool close. An App serves once.
+func (a *App) Serve(ctx context.Context, httpListener, grpcListener net.Listener) error {
+	workerCtx, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	for _, w := range a.workers {
+		workers.Add(1)
+		go func(w runner) {
+			defer workers.Done()
+			w.Start(workerCtx)
+		}(w)
 	}
 
+	serveErr := make(chan error, 2)
+	for _, s := range []struct {
+		server   *http.Server
+		listener net.Listener
+	}{{a.httpServer, httpListener}, {a.grpcServer, grpcListener}} {
+		go func(server *http.Server, listener net.Listener) {
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- err
+			}
+		}(s.server, s.listener)
+	}
+	log.Printf("serving HTTP on %s and gRPC on %s", httpListener.Addr(), grpcListener.Addr())
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Println("shutting down")
+	case err = <-serveErr:
+		log.Printf("shutting down: %v", err)
+	}
+
+	close(a.shutdown)
+	a.drain()
+
+	stopWorkers()
+	a.waitWorkers(&workers)
+	a.close()
+	return err
+}
+
+// waitWorkers waits for the stopped workers until SHUTDOWN_TIMEOUT, so a
+// worker that hangs does not keep the pool open forever.
+func (a *App) waitWorkers(workers *sync.WaitGroup) {
+	stopped := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(a.cfg.ShutdownTimeout)
+	defer timer.Stop()
+	select {
+	case <-stopped:
+	case <-timer.C:
+		log.Printf("workers did not stop within %s, closing anyway", a.cfg.ShutdownTimeout)
+	}
+}
+
+// drain stops the servers accepting connections and waits for the in-flight
+// requests until SHUTDOWN_TIMEOUT, after which it closes what is left.
+func (a *App) drain() {
+	drainCtx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	var servers sync.WaitGroup
+	servers.Add(2)
+	go func() {
+		defer servers.Done()
+		if err := a.httpServer.Shutdown(drainCtx); err != nil {
+			log.Printf("drain http: %v, closing connections", err)
+			_ = a.httpServer.Close()
+		}
+	}()
+	go func() {
+		defer servers.Done()
+		err := a.grpcServer.Shutdown(drainCtx)
+		if err == nil {
+			err = a.grpcCalls.Wait(drainCtx)
+		}
+		if err != nil {
+			log.Printf("drain grpc: %v, closing connections", err)
+			_ = a.grpcServer.Close()
+		}
+	}()
+	servers.Wait()
+}
+
+func (a *App) close() {
+	a.closeSinks()
+	a.pool.Close()
 }
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"banner-service/internal/config"
+	"banner-service/internal/outbox"
+	"banner-service/internal/worker"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const webhookSinkTimeout = 10 * time.Second
+
+func newOutboxSinks(cfg *config.Config) ([]worker.Sink, func(), error) {
+	var closers []func()
+	closeAll := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	sinks := make([]worker.Sink, 0, len(cfg.OutboxSinks))
+	for _, name := range cfg.OutboxSinks {
+		switch name {
+		case "stdout":
+			sinks = append(sinks, outbox.NewStdoutSink())
+		case "file":
+			sink, f, err := outbox.NewFileSink(cfg.OutboxFilePath)
+			if err != nil {
+				closeAll()
+				return nil, nil, err
+			}
+			closers = append(closers, func() { _ = f.Close() })
+			sinks = append(sinks, sink)
+		case "webhook":
+			if cfg.OutboxWebhookURL == "" {
+				closeAll()
+				return nil, nil, fmt.Errorf("outbox webhook sink requires OUTBOX_WEBHOOK_URL")
+			}
+			sinks = append(sinks, outbox.NewWebhookSink(cfg.OutboxWebhookURL, &http.Client{Timeout: webhookSinkTimeout}))
+		default:
+			closeAll()
+			return nil, nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+
+	return sinks, closeAll, nil
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const trackerPollInterval = 10 * time.Millisecond
+
+// requestTracker counts requests in flight. http.Server.Shutdown does not wait
+// for requests on hijacked connections, such as the h2c ones of gRPC.
+type requestTracker struct {
+	active atomic.Int64
+}
+
+func (t *requestTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.active.Add(1)
+		defer t.active.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Wait polls until no request is in flight or ctx is done, like
+// http.Server.Shutdown does for its connections.
+func (t *requestTracker) Wait(ctx context.Context) error {
+	ticker := time.NewTicker(trackerPollInterval)
+	defer ticker.Stop()
+
+	for t.active.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
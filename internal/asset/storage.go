@@ -0,0 +1,198 @@
+package asset
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+var (
+	// ErrTooLarge means the file exceeds the size limit.
+	ErrTooLarge = errors.New("asset is too large")
+	// ErrUnsupportedType means the detected MIME type is not allowed.
+	ErrUnsupportedType = errors.New("unsupported asset type")
+)
+
+var (
+	hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	// refPattern matches the path the service serves an asset by. The path
+	// must not follow a host, so a URL of another server that happens to
+	// end the same way is not a reference; the second group tells a longer
+	// hex string from a hash.
+	refPattern = regexp.MustCompile(`(?:^|[^0-9A-Za-z._~%:@/-])/assets/([0-9a-f]{64})([0-9A-Za-z]?)`)
+)
+
+// Storage keeps files by the SHA-256 of their content, so storing the same
+// file twice stores it once.
+type Storage interface {
+	// Stage writes the content of r aside and returns its hash and size. A
+	// failed read, including ErrTooLarge from a limiting reader, stages
+	// nothing.
+	Stage(ctx context.Context, r io.Reader) (Staged, error)
+	// Place makes a staged file the file of its hash.
+	Place(ctx context.Context, staged Staged) error
+	// Discard removes a staged file that was not placed.
+	Discard(staged Staged)
+	// Open returns the file; a missing one is an os.ErrNotExist error.
+	Open(ctx context.Context, hash string) (io.ReadCloser, error)
+	// Delete removes the file; a missing one is not an error.
+	Delete(ctx context.Context, hash string) error
+}
+
+// ValidHash reports whether s is a hex-encoded SHA-256 hash.
+func ValidHash(s string) bool {
+	return hashPattern.MatchString(s)
+}
+
+// Staged is a file written aside that is not the file of its hash yet.
+type Staged struct {
+	Hash string
+	Size int64
+	path string
+}
+
+// References returns the sorted unique hashes of the assets content refers
+// to by the path the service serves them by.
+func References(content []byte) []string {
+	return VersionReferences(content, nil)
+}
+
+// VersionReferences returns the sorted unique hashes of the assets the
+// default and the localized content of a banner version refer to.
+func VersionReferences(content json.RawMessage, localized map[string]json.RawMessage) []string {
+	seen := make(map[string]bool)
+	collect := func(content []byte) {
+		for _, match := range refPattern.FindAllSubmatch(content, -1) {
+			if len(match[2]) == 0 {
+				seen[string(match[1])] = true
+			}
+		}
+	}
+	collect(content)
+	for _, translation := range localized {
+		collect(translation)
+	}
+
+	hashes := make([]string, 0, len(seen))
+	for hash := range seen {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// FileStorage keeps files in a local directory, sharded by the first bytes of
+// the hash: ab/cd/abcd....
+type FileStorage struct {
+	root string
+}
+
+func NewFileStorage(root string) (*FileStorage, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("create asset directory: %w", err)
+	}
+	return &FileStorage{root: root}, nil
+}
+
+var _ Storage = (*FileStorage)(nil)
+
+// Stage writes the content to a temporary file while hashing it.
+func (s *FileStorage) Stage(_ context.Context, r io.Reader) (Staged, error) {
+	tmp, err := os.CreateTemp(s.root, "upload-*")
+	if err != nil {
+		return Staged{}, err
+	}
+	staged := Staged{path: tmp.Name()}
+	defer func() {
+		_ = tmp.Close()
+		if err != nil {
+			s.Discard(staged)
+		}
+	}()
+
+	hasher := sha256.New()
+	if staged.Size, err = io.Copy(io.MultiWriter(tmp, hasher), r); err != nil {
+		return Staged{}, err
+	}
+	if err = tmp.Sync(); err != nil {
+		return Staged{}, err
+	}
+	if err = tmp.Close(); err != nil {
+		return Staged{}, err
+	}
+
+	staged.Hash = hex.EncodeToString(hasher.Sum(nil))
+	return staged, nil
+}
+
+// Place renames the temporary file to its hash.
+func (s *FileStorage) Place(_ context.Context, staged Staged) error {
+	path := s.path(staged.Hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(staged.path, path)
+}
+
+func (s *FileStorage) Discard(staged Staged) {
+	if staged.path != "" {
+		_ = os.Remove(staged.path)
+	}
+}
+
+func (s *FileStorage) Open(_ context.Context, hash string) (io.ReadCloser, error) {
+	if !ValidHash(hash) {
+		return nil, fmt.Errorf("asset %q: %w", hash, os.ErrNotExist)
+	}
+	return os.Open(s.path(hash))
+}
+
+func (s *FileStorage) Delete(_ context.Context, hash string) error {
+	if !ValidHash(hash) {
+		return nil
+	}
+	if err := os.Remove(s.path(hash)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStorage) path(hash string) string {
+	return filepath.Join(s.root, hash[:2], hash[2:4], hash)
+}
+
+// LimitReader reads from r and fails with ErrTooLarge once more than max
+// bytes are read.
+func LimitReader(r io.Reader, max int64) io.Reader {
+	return &limitedReader{r: r, max: max, remaining: max}
+}
+
+type limitedReader struct {
+	r              io.Reader
+	max, remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, l.max)
+	}
+	// One byte over the limit tells a file of exactly max bytes from a larger
+	// one.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, l.max)
+	}
+	return n, err
+}
@@ -0,0 +1,95 @@
+package asset
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFileStorage(t *testing.T) {
+	ctx := context.Background()
+	storage, err := NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+
+	staged, err := storage.Stage(ctx, strings.NewReader("hello"))
+	require.NoError(t, err)
+	hash := staged.Hash
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", hash)
+	assert.Equal(t, int64(5), staged.Size)
+	_, err = storage.Open(ctx, hash)
+	assert.ErrorIs(t, err, os.ErrNotExist, "a staged file is not served")
+	require.NoError(t, storage.Place(ctx, staged))
+	storage.Discard(staged)
+
+	again, err := storage.Stage(ctx, strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, hash, again.Hash, "the same content has the same address")
+	require.NoError(t, storage.Place(ctx, again))
+
+	f, err := storage.Open(ctx, hash)
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "hello", string(content))
+
+	require.NoError(t, storage.Delete(ctx, hash))
+	require.NoError(t, storage.Delete(ctx, hash), "deleting a missing file is not an error")
+	_, err = storage.Open(ctx, hash)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = storage.Open(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, ErrTooLarge
+}
+
+func TestFileStorageStageFailureStoresNothing(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewFileStorage(root)
+	require.NoError(t, err)
+
+	_, err = storage.Stage(context.Background(), io.MultiReader(strings.NewReader("partial"), failingReader{}))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestFileStorageDiscard(t *testing.T) {
+	root := t.TempDir()
+	storage, err := NewFileStorage(root)
+	require.NoError(t, err)
+
+	staged, err := storage.Stage(context.Background(), strings.NewReader("hello"))
+	require.NoError(t, err)
+	storage.Discard(staged)
+
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestReferences(t *testing.T) {
+	a := strings.Repeat("a", 64)
+	b := strings.Repeat("b", 64)
+	c := strings.Repeat("c", 64)
+	content := `{"image": "https://cdn.example.com/assets/` + c + `", "icon": "/assets/` + a + `",
+		"html": "<img src='/assets/` + b + `'>", "text": "/assets/short", "long": "/assets/` + c + `d"}`
+
+	assert.Equal(t, []string{a, b}, References([]byte(content)), "paths on other hosts and longer strings are not references")
+	assert.Empty(t, References([]byte(`{"title": "no images"}`)))
+
+	localized := map[string]json.RawMessage{"en": json.RawMessage(`{"icon": "/assets/` + c + `"}`)}
+	assert.Equal(t, []string{a, b, c}, VersionReferences(json.RawMessage(content), localized))
+}
@@ -6,9 +6,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/samber/lo"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrInvalidCredentials = errors.New("invalid login or password")
+
 type Repository interface {
 	SignUp(ctx context.Context, user *models.User) error
 	GetHashPassword(ctx context.Context, username string) (string, error)
@@ -58,18 +61,20 @@ func (p *Provider) SignUp(ctx context.Context, user *models.User) (string, error
 
 func (p *Provider) SignIn(ctx context.Context, signInInput *models.User) (string, error) {
 	hashPassword, err := p.AuthRepo.GetHashPassword(ctx, signInInput.Username)
-	if err != nil {
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", fmt.Errorf("user `%s` does not exist: %w", signInInput.Username, ErrInvalidCredentials)
+	} else if err != nil {
 		return "", err
 	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(hashPassword), []byte(signInInput.Password))
 	if err != nil {
-		return "", fmt.Errorf("invalid login or password: user=%v", signInInput)
+		return "", fmt.Errorf("wrong password of user `%s`: %w", signInInput.Username, ErrInvalidCredentials)
 	}
 
 	resources, err := p.AuthRepo.GetUserResources(ctx, signInInput.Username)
 	if errors.Is(err, repository.ErrNotFound) {
-		return "", fmt.Errorf("user with name `%s` does not exist", signInInput.Username)
+		return "", fmt.Errorf("user `%s` has no resources: %w", signInInput.Username, ErrInvalidCredentials)
 	} else if err != nil {
 		return "", err
 	}
@@ -82,6 +87,12 @@ func (p *Provider) SignIn(ctx context.Context, signInInput *models.User) (string
 	return token, nil
 }
 
+// HasAccess reports whether the resources grant the "<METHOD> <path>"
+// resource. Admins have access to everything.
+func HasAccess(resources models.UserResources, resource string) bool {
+	return resources.Role == models.Admin || lo.Contains(resources.Resources, resource)
+}
+
 type roleKey struct{}
 
 func SetRole(ctx context.Context, role models.UserRole) context.Context {
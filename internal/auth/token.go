@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/jellydator/ttlcache/v3"
+	"log"
 	"time"
 )
 
@@ -89,6 +90,33 @@ func (tp *TokenProvider) ValidateToken(token string, publicKey string) (models.U
 	if err = json.Unmarshal(jsonResource, &resources); err != nil {
 		return models.UserResources{}, fmt.Errorf("validate: %w", err)
 	}
+	resources.Username, _ = claims["sub"].(string)
+	resources.Claims = make(map[string]string, len(claims))
+	for name, value := range claims {
+		switch value := value.(type) {
+		case string:
+			resources.Claims[name] = value
+		case float64, bool:
+			resources.Claims[name] = fmt.Sprint(value)
+		}
+	}
+
+	return resources, nil
+}
+
+// Authenticate returns the resources of the token, validating it only when it
+// is not cached yet.
+func (tp *TokenProvider) Authenticate(token string) (models.UserResources, error) {
+	if cached := tp.Cache.Get(token); cached != nil {
+		return cached.Value(), nil
+	}
+
+	resources, err := tp.ValidateToken(token, tp.PublicKey)
+	if err != nil {
+		return models.UserResources{}, err
+	}
+	log.Println("set cache[token]model.UserResources")
+	tp.Cache.Set(token, resources, ttlcache.DefaultTTL)
 
 	return resources, nil
 }
@@ -0,0 +1,321 @@
+package grpc
+
+import (
+	"banner-service/internal/auth"
+	"banner-service/internal/cursor"
+	"banner-service/internal/models"
+	bannerv1 "banner-service/internal/pb/banner/v1"
+	bannerv1connect "banner-service/internal/pb/banner/v1/bannerv1connect"
+	"banner-service/internal/repository"
+	"banner-service/internal/reqctx"
+	"banner-service/internal/schema"
+	"banner-service/internal/template"
+	"connectrpc.com/connect"
+	"context"
+	"encoding/json"
+	"errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type Controller struct {
+	BannerService BannerManagement
+	auth.TokenProvider
+}
+
+func NewController(bs BannerManagement, tp auth.TokenProvider) *Controller {
+	return &Controller{
+		BannerService: bs,
+		TokenProvider: tp,
+	}
+}
+
+// NewHandler serves the banner service over gRPC, gRPC-Web and Connect.
+func (ctr *Controller) NewHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(bannerv1connect.NewBannerServiceHandler(ctr,
+		connect.WithInterceptors(NewAuthInterceptor(ctr.TokenProvider)),
+	))
+	return mux
+}
+
+func (ctr *Controller) GetBanner(ctx context.Context, req *connect.Request[bannerv1.GetBannerRequest]) (*connect.Response[bannerv1.GetBannerResponse], error) {
+	tagIds := req.Msg.TagIds
+	if req.Msg.TagId != 0 {
+		tagIds = append(tagIds, req.Msg.TagId)
+	}
+	if len(tagIds) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("tag_id is required"))
+	}
+
+	vars := models.TemplateVars{User: reqctx.GetClaims(ctx), Param: req.Msg.Params, Strict: req.Msg.StrictTemplates}
+	content, err := ctr.BannerService.GetBanner(ctx, tagIds, req.Msg.FeatureId, auth.GetRole(ctx), req.Msg.UseLastRevision, vars, req.Msg.Locale)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.GetBannerResponse{
+		Content:         content.Content,
+		MatchedTagId:    content.TagId,
+		ContentLanguage: content.Locale,
+	}), nil
+}
+
+func (ctr *Controller) ListBanners(ctx context.Context, req *connect.Request[bannerv1.ListBannersRequest]) (*connect.Response[bannerv1.ListBannersResponse], error) {
+	filter := models.FilterBanner{
+		FeatureIds:  req.Msg.FeatureIds,
+		TagIds:      req.Msg.TagIds,
+		IsActive:    req.Msg.IsActive,
+		CreatedFrom: toTime(req.Msg.CreatedFrom),
+		CreatedTo:   toTime(req.Msg.CreatedTo),
+		UpdatedFrom: toTime(req.Msg.UpdatedFrom),
+		UpdatedTo:   toTime(req.Msg.UpdatedTo),
+		HasDraft:    req.Msg.HasDraft,
+		ContentPath: req.Msg.ContentPath,
+		Limit:       req.Msg.Limit,
+		Offset:      req.Msg.Offset,
+		SortBy:      sortFields[req.Msg.SortBy],
+		Order:       models.OrderDesc,
+		Cursor:      req.Msg.Cursor,
+		WithTotal:   req.Msg.WithTotal,
+	}
+	if req.Msg.FeatureId != nil {
+		filter.FeatureIds = append(filter.FeatureIds, *req.Msg.FeatureId)
+	}
+	if req.Msg.TagId != nil {
+		filter.TagIds = append(filter.TagIds, *req.Msg.TagId)
+	}
+	if req.Msg.Content != "" {
+		if !json.Valid([]byte(req.Msg.Content)) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("content must be valid JSON"))
+		}
+		filter.Content = json.RawMessage(req.Msg.Content)
+	}
+	if req.Msg.Ascending {
+		filter.Order = models.OrderAsc
+	}
+
+	page, err := ctr.BannerService.GetFilteredBanners(ctx, &filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.ListBannersResponse{
+		Banners:    toBanners(page.Banners),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}), nil
+}
+
+func (ctr *Controller) SearchBanners(ctx context.Context, req *connect.Request[bannerv1.SearchBannersRequest]) (*connect.Response[bannerv1.SearchBannersResponse], error) {
+	if strings.TrimSpace(req.Msg.Query) == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("query must not be empty"))
+	}
+
+	results, err := ctr.BannerService.SearchBanners(ctx, &models.BannerSearch{
+		Query:       strings.TrimSpace(req.Msg.Query),
+		WithHistory: req.Msg.WithHistory,
+		Limit:       req.Msg.Limit,
+		Offset:      req.Msg.Offset,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &bannerv1.SearchBannersResponse{Results: make([]*bannerv1.SearchResult, 0, len(results))}
+	for _, result := range results {
+		res.Results = append(res.Results, &bannerv1.SearchResult{
+			Banner:    toBanner(result.Banner),
+			IsCurrent: result.IsCurrent,
+			Rank:      result.Rank,
+			Highlight: string(result.Highlight),
+		})
+	}
+
+	return connect.NewResponse(res), nil
+}
+
+func (ctr *Controller) CreateBanner(ctx context.Context, req *connect.Request[bannerv1.CreateBannerRequest]) (*connect.Response[bannerv1.CreateBannerResponse], error) {
+	if !json.Valid([]byte(req.Msg.Content)) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("content must be valid JSON"))
+	}
+
+	bannerId, err := ctr.BannerService.CreateBanner(ctx, &models.Banner{
+		TagIds:       req.Msg.TagIds,
+		FeatureId:    req.Msg.FeatureId,
+		Content:      json.RawMessage(req.Msg.Content),
+		IsActive:     req.Msg.IsActive,
+		Priority:     int(req.Msg.Priority),
+		FrequencyCap: fromFrequencyCap(req.Msg.FrequencyCap),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.CreateBannerResponse{BannerId: bannerId}), nil
+}
+
+func (ctr *Controller) UpdateBanner(ctx context.Context, req *connect.Request[bannerv1.UpdateBannerRequest]) (*connect.Response[bannerv1.UpdateBannerResponse], error) {
+	patch := models.PatchBanner{
+		FeatureId: req.Msg.FeatureId,
+		IsActive:  req.Msg.IsActive,
+	}
+	if req.Msg.TagIds != nil {
+		patch.TagIds = req.Msg.TagIds.TagIds
+	}
+	if req.Msg.Priority != nil {
+		priority := int(*req.Msg.Priority)
+		patch.Priority = &priority
+	}
+	patch.FrequencyCap = fromFrequencyCap(req.Msg.FrequencyCap)
+	if req.Msg.Content != nil {
+		if !json.Valid([]byte(*req.Msg.Content)) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("content must be valid JSON"))
+		}
+		patch.Content = json.RawMessage(*req.Msg.Content)
+	}
+
+	if err := ctr.BannerService.PartialUpdateBanner(ctx, req.Msg.BannerId, &patch); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.UpdateBannerResponse{}), nil
+}
+
+func (ctr *Controller) DeleteBanner(ctx context.Context, req *connect.Request[bannerv1.DeleteBannerRequest]) (*connect.Response[bannerv1.DeleteBannerResponse], error) {
+	if err := ctr.BannerService.DeleteBanner(ctx, req.Msg.BannerId); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.DeleteBannerResponse{}), nil
+}
+
+func (ctr *Controller) ListVersions(ctx context.Context, req *connect.Request[bannerv1.ListVersionsRequest]) (*connect.Response[bannerv1.ListVersionsResponse], error) {
+	banners, err := ctr.BannerService.GetListOfVersions(ctx, req.Msg.BannerId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.ListVersionsResponse{Banners: toBanners(banners)}), nil
+}
+
+func (ctr *Controller) ChooseVersion(ctx context.Context, req *connect.Request[bannerv1.ChooseVersionRequest]) (*connect.Response[bannerv1.ChooseVersionResponse], error) {
+	if err := ctr.BannerService.ChooseBannerVersion(ctx, req.Msg.BannerId, req.Msg.Version); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return connect.NewResponse(&bannerv1.ChooseVersionResponse{}), nil
+}
+
+func (ctr *Controller) WatchBanner(ctx context.Context, req *connect.Request[bannerv1.WatchBannerRequest], stream *connect.ServerStream[bannerv1.WatchBannerResponse]) error {
+	vars := models.TemplateVars{User: reqctx.GetClaims(ctx), Param: req.Msg.Params, Strict: req.Msg.StrictTemplates}
+	updates, err := ctr.BannerService.WatchBanner(ctx, req.Msg.TagId, req.Msg.FeatureId, auth.GetRole(ctx), req.Msg.LastEventId, vars, req.Msg.Locale)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	shutdown := reqctx.GetShutdown(ctx)
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return ctx.Err()
+			}
+			if err = stream.Send(&bannerv1.WatchBannerResponse{
+				EventId: update.EventId,
+				Status:  toUpdateStatus(update.Status),
+				Content: update.Content,
+			}); err != nil {
+				return err
+			}
+		case <-shutdown:
+			return connect.NewError(connect.CodeUnavailable, errors.New("server is shutting down"))
+		}
+	}
+}
+
+var sortFields = map[bannerv1.BannerSortField]models.BannerSortField{
+	bannerv1.BannerSortField_BANNER_SORT_FIELD_BANNER_ID:  models.SortByBannerId,
+	bannerv1.BannerSortField_BANNER_SORT_FIELD_CREATED_AT: models.SortByCreatedAt,
+	bannerv1.BannerSortField_BANNER_SORT_FIELD_UPDATED_AT: models.SortByUpdatedAt,
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, repository.ErrBannerInactive):
+		return connect.NewError(connect.CodePermissionDenied, err)
+	case errors.Is(err, repository.ErrAlreadyExists):
+		return connect.NewError(connect.CodeAlreadyExists, err)
+	case errors.Is(err, cursor.ErrInvalidCursor), errors.Is(err, repository.ErrInvalidFilter), errors.Is(err, template.ErrMissingVariable):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case errors.As(err, new(*schema.ViolationError)), errors.Is(err, repository.ErrInvalidReference),
+		errors.Is(err, repository.ErrInvalidLocale), errors.Is(err, repository.ErrInvalidFrequencyCap):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	default:
+		log.Printf("grpc: %v", err)
+		return connect.NewError(connect.CodeInternal, errors.New("internal server error"))
+	}
+}
+
+func toTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func toBanners(banners []models.Banner) []*bannerv1.Banner {
+	res := make([]*bannerv1.Banner, 0, len(banners))
+	for _, banner := range banners {
+		res = append(res, toBanner(banner))
+	}
+	return res
+}
+
+func toBanner(banner models.Banner) *bannerv1.Banner {
+	return &bannerv1.Banner{
+		BannerId:     banner.BannerId,
+		FeatureId:    banner.FeatureId,
+		TagIds:       banner.TagIds,
+		Content:      string(banner.Content),
+		IsActive:     banner.IsActive,
+		Priority:     int32(banner.Priority),
+		FrequencyCap: toFrequencyCap(banner.FrequencyCap),
+		Version:      banner.Version,
+		CreatedAt:    timestamppb.New(banner.CreatedAt),
+		UpdatedAt:    timestamppb.New(banner.UpdatedAt),
+	}
+}
+
+func toFrequencyCap(frequencyCap *models.FrequencyCap) *bannerv1.FrequencyCap {
+	if frequencyCap == nil {
+		return nil
+	}
+	return &bannerv1.FrequencyCap{Limit: frequencyCap.Limit, WindowSeconds: frequencyCap.WindowSeconds}
+}
+
+func fromFrequencyCap(frequencyCap *bannerv1.FrequencyCap) *models.FrequencyCap {
+	if frequencyCap == nil {
+		return nil
+	}
+	return &models.FrequencyCap{Limit: frequencyCap.Limit, WindowSeconds: frequencyCap.WindowSeconds}
+}
+
+func toUpdateStatus(status models.BannerUpdateStatus) bannerv1.BannerUpdateStatus {
+	switch status {
+	case models.BannerAvailable:
+		return bannerv1.BannerUpdateStatus_BANNER_UPDATE_STATUS_BANNER
+	case models.BannerInactive:
+		return bannerv1.BannerUpdateStatus_BANNER_UPDATE_STATUS_INACTIVE
+	case models.BannerNotFound:
+		return bannerv1.BannerUpdateStatus_BANNER_UPDATE_STATUS_NOT_FOUND
+	default:
+		return bannerv1.BannerUpdateStatus_BANNER_UPDATE_STATUS_UNSPECIFIED
+	}
+}
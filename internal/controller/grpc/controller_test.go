@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"banner-service/internal/auth"
+	"banner-service/internal/models"
+	bannerv1 "banner-service/internal/pb/banner/v1"
+	bannerv1connect "banner-service/internal/pb/banner/v1/bannerv1connect"
+	"banner-service/internal/repository"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	adminToken  = "admin-token"
+	clientToken = "client-token"
+)
+
+type bannerService struct {
+	BannerManagement
+	tagIds  []uint64
+	role    models.UserRole
+	vars    models.TemplateVars
+	created *models.Banner
+	err     error
+	updates chan models.BannerUpdate
+}
+
+func (s *bannerService) GetBanner(_ context.Context, tagIds []uint64, _ uint64, role models.UserRole, _ bool, vars models.TemplateVars, _ string) (models.BannerContent, error) {
+	s.tagIds, s.role, s.vars = tagIds, role, vars
+	if s.err != nil {
+		return models.BannerContent{}, s.err
+	}
+	return models.BannerContent{Content: `{"title":"hello"}`, TagId: tagIds[len(tagIds)-1], Locale: "ru"}, nil
+}
+
+func (s *bannerService) CreateBanner(_ context.Context, banner *models.Banner) (uint64, error) {
+	s.created = banner
+	return 42, s.err
+}
+
+// WatchBanner relays the queued updates until ctx is done, like the service.
+func (s *bannerService) WatchBanner(ctx context.Context, _ uint64, _ uint64, _ models.UserRole, _ *uint64, vars models.TemplateVars, _ string) (<-chan models.BannerUpdate, error) {
+	s.vars = vars
+	if s.err != nil {
+		return nil, s.err
+	}
+	updates := make(chan models.BannerUpdate)
+	go func() {
+		defer close(updates)
+		for {
+			select {
+			case update := <-s.updates:
+				select {
+				case updates <- update:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+func newTestClient(t *testing.T, service BannerManagement) bannerv1connect.BannerServiceClient {
+	t.Helper()
+
+	cache := ttlcache.New[string, models.UserResources]()
+	cache.Set(adminToken, models.UserResources{Username: "admin", Role: models.Admin}, time.Hour)
+	cache.Set(clientToken, models.UserResources{
+		Username:  "client",
+		Role:      models.Client,
+		Resources: []string{"GET /user_banner"},
+		Claims:    map[string]string{"sub": "alice"},
+	}, time.Hour)
+	tp := auth.NewTokenProvider(cache, "", "", time.Hour)
+
+	server := httptest.NewServer(NewController(service, tp).NewHandler())
+	t.Cleanup(server.Close)
+	return bannerv1connect.NewBannerServiceClient(server.Client(), server.URL)
+}
+
+func withToken[T any](msg *T, token string) *connect.Request[T] {
+	req := connect.NewRequest(msg)
+	req.Header().Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestGetBanner(t *testing.T) {
+	service := &bannerService{}
+	client := newTestClient(t, service)
+
+	res, err := client.GetBanner(context.Background(), withToken(&bannerv1.GetBannerRequest{
+		TagId:     3,
+		TagIds:    []uint64{1, 2},
+		FeatureId: 7,
+		Params:    map[string]string{"city": "Moscow"},
+	}, clientToken))
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"title":"hello"}`, res.Msg.Content)
+	assert.Equal(t, uint64(3), res.Msg.MatchedTagId)
+	assert.Equal(t, "ru", res.Msg.ContentLanguage)
+	assert.Equal(t, []uint64{1, 2, 3}, service.tagIds)
+	assert.Equal(t, models.Client, service.role)
+	assert.Equal(t, "alice", service.vars.User["sub"])
+	assert.Equal(t, "Moscow", service.vars.Param["city"])
+}
+
+func TestGetBannerRequiresTag(t *testing.T) {
+	client := newTestClient(t, &bannerService{})
+
+	_, err := client.GetBanner(context.Background(), withToken(&bannerv1.GetBannerRequest{FeatureId: 7}, clientToken))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	client := newTestClient(t, &bannerService{})
+	ctx := context.Background()
+
+	_, err := client.GetBanner(ctx, connect.NewRequest(&bannerv1.GetBannerRequest{TagId: 1}))
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), "no token")
+
+	_, err = client.GetBanner(ctx, withToken(&bannerv1.GetBannerRequest{TagId: 1}, "unknown"))
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), "unknown token")
+
+	_, err = client.CreateBanner(ctx, withToken(&bannerv1.CreateBannerRequest{Content: "{}"}, clientToken))
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err), "the client has no access to POST /banner")
+
+	_, err = client.CreateBanner(ctx, withToken(&bannerv1.CreateBannerRequest{Content: "{}", TagIds: []uint64{1}, FeatureId: 1}, adminToken))
+	assert.NoError(t, err)
+}
+
+func TestCreateBanner(t *testing.T) {
+	service := &bannerService{}
+	client := newTestClient(t, service)
+	ctx := context.Background()
+
+	res, err := client.CreateBanner(ctx, withToken(&bannerv1.CreateBannerRequest{
+		TagIds:       []uint64{1, 2},
+		FeatureId:    3,
+		Content:      `{"title":"hello"}`,
+		IsActive:     true,
+		Priority:     5,
+		FrequencyCap: &bannerv1.FrequencyCap{Limit: 3, WindowSeconds: 60},
+	}, adminToken))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), res.Msg.BannerId)
+	require.NotNil(t, service.created)
+	assert.Equal(t, []uint64{1, 2}, service.created.TagIds)
+	assert.Equal(t, 5, service.created.Priority)
+	assert.Equal(t, &models.FrequencyCap{Limit: 3, WindowSeconds: 60}, service.created.FrequencyCap)
+
+	_, err = client.CreateBanner(ctx, withToken(&bannerv1.CreateBannerRequest{Content: "{"}, adminToken))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "content must be valid JSON")
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code connect.Code
+	}{
+		{repository.ErrNotFound, connect.CodeNotFound},
+		{repository.ErrBannerInactive, connect.CodePermissionDenied},
+		{repository.ErrAlreadyExists, connect.CodeAlreadyExists},
+		{repository.ErrInvalidReference, connect.CodeInvalidArgument},
+		{errors.New("boom"), connect.CodeInternal},
+	} {
+		client := newTestClient(t, &bannerService{err: tc.err})
+
+		_, err := client.GetBanner(context.Background(), withToken(&bannerv1.GetBannerRequest{TagId: 1}, clientToken))
+		assert.Equal(t, tc.code, connect.CodeOf(err), tc.err.Error())
+		if tc.code == connect.CodeInternal {
+			assert.NotContains(t, err.Error(), "boom", "internal errors are not exposed")
+		}
+	}
+}
+
+func TestWatchBanner(t *testing.T) {
+	updates := make(chan models.BannerUpdate, 2)
+	updates <- models.BannerUpdate{EventId: 1, Status: models.BannerAvailable, Content: `{"v":1}`}
+	updates <- models.BannerUpdate{EventId: 2, Status: models.BannerInactive}
+	service := &bannerService{updates: updates}
+	client := newTestClient(t, service)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.WatchBanner(ctx, withToken(&bannerv1.WatchBannerRequest{
+		TagId:           1,
+		FeatureId:       2,
+		Params:          map[string]string{"city": "Moscow"},
+		StrictTemplates: true,
+	}, adminToken))
+	require.NoError(t, err)
+	defer stream.Close()
+
+	require.True(t, stream.Receive(), stream.Err())
+	assert.Equal(t, "Moscow", service.vars.Param["city"])
+	assert.True(t, service.vars.Strict)
+	assert.Equal(t, uint64(1), stream.Msg().EventId)
+	assert.Equal(t, bannerv1.BannerUpdateStatus_BANNER_UPDATE_STATUS_BANNER, stream.Msg().Status)
+	assert.Equal(t, `{"v":1}`, stream.Msg().Content)
+
+	require.True(t, stream.Receive(), stream.Err())
+	assert.Equal(t, uint64(2), stream.Msg().EventId)
+	assert.Equal(t, bannerv1.BannerUpdateStatus_BANNER_UPDATE_STATUS_INACTIVE, stream.Msg().Status)
+}
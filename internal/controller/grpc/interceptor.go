@@ -0,0 +1,82 @@
+package grpc
+
+import (
+	"banner-service/internal/auth"
+	bannerv1connect "banner-service/internal/pb/banner/v1/bannerv1connect"
+	"banner-service/internal/reqctx"
+	"connectrpc.com/connect"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// procedureResources maps every RPC to the resource of the matching HTTP
+// endpoint, so roles are granted access to both APIs at once.
+var procedureResources = map[string]string{
+	bannerv1connect.BannerServiceGetBannerProcedure:     "GET /user_banner",
+	bannerv1connect.BannerServiceListBannersProcedure:   "GET /banner",
+	bannerv1connect.BannerServiceSearchBannersProcedure: "GET /banner/search",
+	bannerv1connect.BannerServiceCreateBannerProcedure:  "POST /banner",
+	bannerv1connect.BannerServiceUpdateBannerProcedure:  "PATCH /banner",
+	bannerv1connect.BannerServiceDeleteBannerProcedure:  "DELETE /banner",
+	bannerv1connect.BannerServiceListVersionsProcedure:  "GET /banner/versions",
+	bannerv1connect.BannerServiceChooseVersionProcedure: "PATCH /banner/version",
+	bannerv1connect.BannerServiceWatchBannerProcedure:   "GET /user_banner/stream",
+}
+
+type AuthInterceptor struct {
+	auth.TokenProvider
+}
+
+func NewAuthInterceptor(tp auth.TokenProvider) *AuthInterceptor {
+	return &AuthInterceptor{TokenProvider: tp}
+}
+
+func (ai *AuthInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, err := ai.authorize(ctx, req.Spec().Procedure, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (ai *AuthInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (ai *AuthInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := ai.authorize(ctx, conn.Spec().Procedure, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+func (ai *AuthInterceptor) authorize(ctx context.Context, procedure string, header http.Header) (context.Context, error) {
+	authFields := strings.Fields(header.Get("Authorization"))
+	if len(authFields) != 2 || authFields[0] != "Bearer" {
+		return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("missing bearer token"))
+	}
+
+	resources, err := ai.Authenticate(authFields[1])
+	if err != nil {
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+
+	resource, ok := procedureResources[procedure]
+	if !ok {
+		resource = procedure
+	}
+	if !auth.HasAccess(resources, resource) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("access denied"))
+	}
+
+	ctx = auth.SetRole(ctx, resources.Role)
+	ctx = reqctx.SetClaims(ctx, resources.Claims)
+	return reqctx.SetUsername(ctx, resources.Username), nil
+}
@@ -0,0 +1,18 @@
+package grpc
+
+import (
+	"banner-service/internal/models"
+	"context"
+)
+
+type BannerManagement interface {
+	GetBanner(ctx context.Context, tagIds []uint64, featureId uint64, role models.UserRole, useLastRevision bool, vars models.TemplateVars, languages string) (models.BannerContent, error)
+	GetListOfVersions(ctx context.Context, bannersId uint64) ([]models.Banner, error)
+	ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) error
+	GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) (models.BannerPage, error)
+	SearchBanners(ctx context.Context, search *models.BannerSearch) ([]models.BannerSearchResult, error)
+	CreateBanner(ctx context.Context, banner *models.Banner) (uint64, error)
+	PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner) error
+	DeleteBanner(ctx context.Context, id uint64) error
+	WatchBanner(ctx context.Context, tagId uint64, featureId uint64, role models.UserRole, lastEventId *uint64, vars models.TemplateVars, languages string) (<-chan models.BannerUpdate, error)
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// assetFormField is the multipart field the file is uploaded in.
+	assetFormField = "file"
+	// Assets are addressed by their content, so a response never goes stale.
+	immutableCacheControl = "public, max-age=31536000, immutable"
+)
+
+// UploadAssetEndpoint stores the file of the multipart request. The parts are
+// streamed, so the size limit applies without buffering the file.
+func (ctr *Controller) UploadAssetEndpoint(w http.ResponseWriter, r *http.Request) {
+	// The size is bounded by ASSET_MAX_SIZE rather than the read timeout, which
+	// a slow link would hit first.
+	holdOpen(w)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			apierror.Write(w, r, apierror.BadRequest("multipart field "+assetFormField+" is missing"))
+			return
+		} else if err != nil {
+			apierror.Write(w, r, apierror.InvalidBody(err))
+			return
+		}
+		if part.FormName() != assetFormField {
+			continue
+		}
+
+		uploaded, created, err := ctr.AssetService.UploadAsset(r.Context(), part)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		assetJSON, err := json.Marshal(uploaded)
+		if err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", uploaded.URL)
+		if created {
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		_, _ = w.Write(assetJSON)
+		return
+	}
+}
+
+// GetAssetEndpoint serves the file of the asset. A matching If-None-Match is
+// answered with 304 only for an asset that exists.
+func (ctr *Controller) GetAssetEndpoint(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	stored, content, err := ctr.AssetService.OpenAsset(r.Context(), hash)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	etag := strconv.Quote(hash)
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", immutableCacheControl)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", stored.MimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(stored.Size, 10))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", immutableCacheControl)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, content); err != nil {
+		log.Printf("asset %s: %v", hash, err)
+	}
+}
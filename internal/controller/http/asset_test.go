@@ -0,0 +1,55 @@
+package http
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assetService holds a single asset.
+type assetService struct {
+	AssetManagement
+	hash string
+}
+
+func (s *assetService) OpenAsset(_ context.Context, hash string) (models.Asset, io.ReadCloser, error) {
+	if hash != s.hash {
+		return models.Asset{}, nil, repository.ErrNotFound
+	}
+	return models.Asset{Hash: hash, MimeType: "image/png", Size: 3}, io.NopCloser(strings.NewReader("png")), nil
+}
+
+func TestGetAssetNotModifiedOnlyIfItExists(t *testing.T) {
+	stored := strings.Repeat("a", 64)
+	missing := strings.Repeat("b", 64)
+	ctr := newTestController(t)
+	ctr.AssetService = AssetService{&assetService{hash: stored}}
+
+	for _, tt := range []struct {
+		hash        string
+		ifNoneMatch string
+		status      int
+	}{
+		{stored, "", http.StatusOK},
+		{stored, strconv.Quote(stored), http.StatusNotModified},
+		{missing, strconv.Quote(missing), http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/assets/"+tt.hash, nil)
+		if tt.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+
+		ctr.NewRouter().ServeHTTP(rec, req)
+
+		assert.Equal(t, tt.status, rec.Code, "%s %s", tt.hash, tt.ifNoneMatch)
+	}
+}
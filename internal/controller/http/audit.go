@@ -0,0 +1,71 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+func (ctr *Controller) GetAuditEventsEndpoint(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{Cursor: query.Get("cursor")}
+
+	if actor := query.Get("actor"); actor != "" {
+		filter.Actor = &actor
+	}
+
+	if bannerIdStr := query.Get("banner_id"); bannerIdStr != "" {
+		bannerId, err := strconv.ParseUint(bannerIdStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
+			return
+		}
+		filter.BannerId = &bannerId
+	}
+
+	if actionStr := query.Get("action"); actionStr != "" {
+		action := models.AuditAction(actionStr)
+		filter.Action = &action
+	}
+
+	from, err := parseOptionalTime(query, "from")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	filter.From = from
+
+	to, err := parseOptionalTime(query, "to")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	filter.To = to
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	page, err := ctr.BannerService.GetAuditEvents(r.Context(), &filter)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	pageJSON, err := json.Marshal(page)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(pageJSON)
+}
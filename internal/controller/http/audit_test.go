@@ -0,0 +1,59 @@
+package http
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type auditService struct {
+	BannerManagement
+	filter *models.AuditFilter
+}
+
+func (s *auditService) GetAuditEvents(_ context.Context, filter *models.AuditFilter) (models.AuditPage, error) {
+	s.filter = filter
+	return models.AuditPage{Events: []models.AuditEvent{}}, nil
+}
+
+func TestGetAuditEventsNormalizesTimeFilters(t *testing.T) {
+	service := &auditService{}
+	ctr := newTestController(t)
+	ctr.BannerService = BannerService{service}
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/audit?actor=admin&banner_id=7&from=2024-04-15T12:00:00%2B03:00&to=2024-04-16T00:00:00Z", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+
+	ctr.NewRouter().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotNil(t, service.filter)
+	require.NotNil(t, service.filter.From)
+	require.NotNil(t, service.filter.To)
+	assert.Equal(t, time.UTC, service.filter.From.Location())
+	assert.Equal(t, time.Date(2024, 4, 15, 9, 0, 0, 0, time.UTC), *service.filter.From)
+	assert.Equal(t, time.Date(2024, 4, 16, 0, 0, 0, 0, time.UTC), *service.filter.To)
+	assert.Equal(t, "admin", *service.filter.Actor)
+	assert.Equal(t, uint64(7), *service.filter.BannerId)
+}
+
+func TestGetAuditEventsRejectsMalformedTime(t *testing.T) {
+	ctr := newTestController(t)
+	ctr.BannerService = BannerService{&auditService{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/audit?from=yesterday", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+
+	ctr.NewRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
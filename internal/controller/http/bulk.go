@@ -0,0 +1,105 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// BulkFilterDTO selects banners like the query parameters of GET /banner.
+type BulkFilterDTO struct {
+	FeatureIds  []uint64        `json:"feature_ids"`
+	TagIds      []uint64        `json:"tag_ids"`
+	IsActive    *bool           `json:"is_active"`
+	CreatedFrom *time.Time      `json:"created_from"`
+	CreatedTo   *time.Time      `json:"created_to"`
+	UpdatedFrom *time.Time      `json:"updated_from"`
+	UpdatedTo   *time.Time      `json:"updated_to"`
+	HasDraft    *bool           `json:"has_draft"`
+	Content     json.RawMessage `json:"content"`
+	ContentPath string          `json:"content_path"`
+}
+
+type BulkOperationDTO struct {
+	Action    models.BulkAction `json:"action"`
+	BannerIds []uint64          `json:"banner_ids"`
+	Filter    *BulkFilterDTO    `json:"filter"`
+	Version   uint64            `json:"version"`
+	TagIds    []uint64          `json:"tag_ids"`
+	FeatureId *uint64           `json:"feature_id"`
+}
+
+type BulkDTO struct {
+	Operations []BulkOperationDTO `json:"operations"`
+	Atomic     bool               `json:"atomic"`
+}
+
+func (ctr *Controller) BulkUpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	var dto BulkDTO
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	req := models.BulkRequest{Atomic: dto.Atomic, Operations: make([]models.BulkOperation, 0, len(dto.Operations))}
+	for _, op := range dto.Operations {
+		req.Operations = append(req.Operations, models.BulkOperation{
+			Action:    op.Action,
+			BannerIds: op.BannerIds,
+			Filter:    op.Filter.toFilter(),
+			Version:   op.Version,
+			TagIds:    op.TagIds,
+			FeatureId: op.FeatureId,
+		})
+	}
+
+	report, err := ctr.BannerService.BulkUpdate(r.Context(), &req)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(reportJSON)
+}
+
+func (dto *BulkFilterDTO) toFilter() *models.FilterBanner {
+	if dto == nil {
+		return nil
+	}
+
+	filter := &models.FilterBanner{
+		FeatureIds:  dto.FeatureIds,
+		TagIds:      dto.TagIds,
+		IsActive:    dto.IsActive,
+		CreatedFrom: utc(dto.CreatedFrom),
+		CreatedTo:   utc(dto.CreatedTo),
+		UpdatedFrom: utc(dto.UpdatedFrom),
+		UpdatedTo:   utc(dto.UpdatedTo),
+		HasDraft:    dto.HasDraft,
+		ContentPath: dto.ContentPath,
+	}
+	if len(dto.Content) > 0 && !bytes.Equal(dto.Content, []byte("null")) {
+		filter.Content = dto.Content
+	}
+	return filter
+}
+
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
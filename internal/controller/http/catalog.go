@@ -0,0 +1,311 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type CatalogEntryDTO struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	Archived    bool   `json:"archived"`
+}
+
+type FeatureDTO struct {
+	FeatureId       uint64  `json:"feature_id"`
+	DefaultBannerId *uint64 `json:"default_banner_id"`
+	CatalogEntryDTO
+}
+
+type TagDTO struct {
+	TagId       uint64  `json:"tag_id"`
+	ParentTagId *uint64 `json:"parent_tag_id"`
+	CatalogEntryDTO
+}
+
+func (ctr *Controller) CreateFeatureEndpoint(w http.ResponseWriter, r *http.Request) {
+	var dto FeatureDTO
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	if strings.TrimSpace(dto.Name) == "" {
+		apierror.Write(w, r, apierror.BadRequest("name must not be empty"))
+		return
+	}
+
+	feature, err := ctr.CatalogService.CreateFeature(r.Context(), &models.Feature{
+		FeatureId:       dto.FeatureId,
+		DefaultBannerId: dto.DefaultBannerId,
+		CatalogEntry:    dto.toEntry(),
+	})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	featureJSON, err := json.Marshal(feature)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(featureJSON)
+}
+
+func (ctr *Controller) GetFeaturesEndpoint(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCatalogFilter(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	features, err := ctr.CatalogService.GetFeatures(r.Context(), &filter)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	featuresJSON, err := json.Marshal(features)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(featuresJSON)
+}
+
+func (ctr *Controller) GetFeatureEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	feature, err := ctr.CatalogService.GetFeature(r.Context(), featureId)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	featureJSON, err := json.Marshal(feature)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(featureJSON)
+}
+
+func (ctr *Controller) UpdateFeatureEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	var patch models.PatchFeature
+	err = json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+		apierror.Write(w, r, apierror.BadRequest("name must not be empty"))
+		return
+	}
+
+	if err = ctr.CatalogService.UpdateFeature(r.Context(), featureId, &patch); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ctr *Controller) DeleteFeatureEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	if err = ctr.CatalogService.DeleteFeature(r.Context(), featureId); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctr *Controller) CreateTagEndpoint(w http.ResponseWriter, r *http.Request) {
+	var dto TagDTO
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	if strings.TrimSpace(dto.Name) == "" {
+		apierror.Write(w, r, apierror.BadRequest("name must not be empty"))
+		return
+	}
+
+	tag, err := ctr.CatalogService.CreateTag(r.Context(), &models.Tag{
+		TagId:        dto.TagId,
+		ParentTagId:  dto.ParentTagId,
+		CatalogEntry: dto.toEntry(),
+	})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	tagJSON, err := json.Marshal(tag)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(tagJSON)
+}
+
+func (ctr *Controller) GetTagsEndpoint(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseCatalogFilter(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	tags, err := ctr.CatalogService.GetTags(r.Context(), &filter)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	tagsJSON, err := json.Marshal(tags)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(tagsJSON)
+}
+
+func (ctr *Controller) GetTagEndpoint(w http.ResponseWriter, r *http.Request) {
+	tagId, err := strconv.ParseUint(chi.URLParam(r, "tag_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
+		return
+	}
+
+	tag, err := ctr.CatalogService.GetTag(r.Context(), tagId)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	tagJSON, err := json.Marshal(tag)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(tagJSON)
+}
+
+func (ctr *Controller) UpdateTagEndpoint(w http.ResponseWriter, r *http.Request) {
+	tagId, err := strconv.ParseUint(chi.URLParam(r, "tag_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
+		return
+	}
+
+	var patch models.PatchTag
+	err = json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	if patch.Name != nil && strings.TrimSpace(*patch.Name) == "" {
+		apierror.Write(w, r, apierror.BadRequest("name must not be empty"))
+		return
+	}
+
+	if err = ctr.CatalogService.UpdateTag(r.Context(), tagId, &patch); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ctr *Controller) DeleteTagEndpoint(w http.ResponseWriter, r *http.Request) {
+	tagId, err := strconv.ParseUint(chi.URLParam(r, "tag_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
+		return
+	}
+
+	if err = ctr.CatalogService.DeleteTag(r.Context(), tagId); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (dto *CatalogEntryDTO) toEntry() models.CatalogEntry {
+	return models.CatalogEntry{
+		Name:        strings.TrimSpace(dto.Name),
+		Description: dto.Description,
+		Owner:       dto.Owner,
+		Archived:    dto.Archived,
+	}
+}
+
+// parseCatalogFilter reads the archived, limit and offset parameters of the
+// feature and tag lists.
+func parseCatalogFilter(query url.Values) (models.CatalogFilter, error) {
+	var (
+		filter models.CatalogFilter
+		err    error
+	)
+
+	if filter.Archived, err = parseOptionalBool(query, "archived"); err != nil {
+		return filter, err
+	}
+
+	for name, dst := range map[string]*uint64{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := query.Get(name); value != "" {
+			if *dst, err = strconv.ParseUint(value, 10, 64); err != nil {
+				return filter, apierror.InvalidParameter(name)
+			}
+		}
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,597 @@
+package http
+
+import (
+	"banner-service/api"
+	"banner-service/internal/apierror"
+	"banner-service/internal/auth"
+	"banner-service/internal/middleware"
+	"banner-service/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi/v5"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const adminToken = "admin-token"
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+func newTestController(t *testing.T) *Controller {
+	t.Helper()
+
+	rv, err := middleware.NewRequestValidator(api.Spec)
+	require.NoError(t, err)
+
+	cache := ttlcache.New[string, models.UserResources]()
+	cache.Set(adminToken, models.UserResources{Username: "admin", Role: models.Admin}, time.Hour)
+	tp := auth.NewTokenProvider(cache, "", "", time.Hour)
+
+	return NewController(AuthProvider{TokenProvider: tp}, BannerService{}, WebhookService{}, CatalogService{}, AssetService{}, EventService{}, rv)
+}
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(api.Spec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+	return doc
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	doc := loadSpec(t)
+	router, ok := newTestController(t).NewRouter().(chi.Routes)
+	require.True(t, ok)
+
+	var checked int
+	err := chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if strings.HasPrefix(route, "/debug/pprof") || strings.HasPrefix(route, "/metrics") {
+			return nil
+		}
+		// Nested Route("/") groups show up as "/*" segments.
+		route = strings.ReplaceAll(route, "/*/", "/")
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+
+		checked++
+		pathItem := doc.Paths.Value(route)
+		if !assert.NotNilf(t, pathItem, "route %s is not documented", route) {
+			return nil
+		}
+		op := pathItem.GetOperation(method)
+		if !assert.NotNilf(t, op, "route %s %s is not documented", method, route) {
+			return nil
+		}
+
+		var routeParams []string
+		for _, match := range pathParamRe.FindAllStringSubmatch(route, -1) {
+			routeParams = append(routeParams, match[1])
+		}
+		var docParams []string
+		for _, params := range []openapi3.Parameters{pathItem.Parameters, op.Parameters} {
+			for _, param := range params {
+				if param.Value.In == openapi3.ParameterInPath {
+					docParams = append(docParams, param.Value.Name)
+				}
+			}
+		}
+		sort.Strings(routeParams)
+		sort.Strings(docParams)
+		assert.Equalf(t, routeParams, docParams, "path parameters of %s %s", method, route)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, countOperations(doc), checked, "every documented operation must be routed")
+}
+
+func countOperations(doc *openapi3.T) int {
+	var n int
+	for _, pathItem := range doc.Paths.Map() {
+		n += len(pathItem.Operations())
+	}
+	return n
+}
+
+func TestUserSchemaMatchesValidateTags(t *testing.T) {
+	doc := loadSpec(t)
+	schema := doc.Components.Schemas["User"]
+	require.NotNil(t, schema)
+
+	userType := reflect.TypeOf(models.User{})
+	for i := 0; i < userType.NumField(); i++ {
+		field := userType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		assert.Containsf(t, schema.Value.Properties, name, "field %s is not documented", field.Name)
+		if strings.Contains(field.Tag.Get("validate"), "required") {
+			assert.Containsf(t, schema.Value.Required, name, "field %s must be required", field.Name)
+		}
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	router := newTestController(t).NewRouter()
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		fields      []string
+	}{
+		{
+			name:   "sign-up without password",
+			method: http.MethodPost,
+			target: "/sign-up",
+			body:   `{"username": "user"}`,
+			fields: []string{"/password"},
+		},
+		{
+			name:   "banner with empty tags, zero feature and string content",
+			method: http.MethodPost,
+			target: "/banner",
+			body:   `{"tag_ids": [], "feature_id": 0, "content": "text"}`,
+			fields: []string{"/tag_ids", "/feature_id", "/content"},
+		},
+		{
+			name:   "banner filter with non-integer limit",
+			method: http.MethodGet,
+			target: "/banner?limit=ten",
+			fields: []string{"limit"},
+		},
+		{
+			name:   "banner filter with a non-integer tag among repeated ones",
+			method: http.MethodGet,
+			target: "/banner?tag_id=1&tag_id=two&is_active=yes",
+			fields: []string{"tag_id", "is_active"},
+		},
+		{
+			name:   "feature with zero id and an empty name",
+			method: http.MethodPost,
+			target: "/feature",
+			body:   `{"feature_id": 0, "name": ""}`,
+			fields: []string{"/feature_id", "/name"},
+		},
+		{
+			name:   "content validation without content",
+			method: http.MethodPost,
+			target: "/banner/validate",
+			body:   `{"feature_id": 0}`,
+			fields: []string{"/feature_id", "/content"},
+		},
+		{
+			name:        "json patch with an unknown operation",
+			method:      http.MethodPatch,
+			target:      "/banner/1",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "append", "path": "/title", "value": "x"}]`,
+			fields:      []string{"/0/op"},
+		},
+		{
+			name:   "user banner with a non-integer tag in a comma-separated list",
+			method: http.MethodGet,
+			target: "/user_banner?feature_id=1&tag_id=1&tag_id=2,x",
+			fields: []string{"tag_id"},
+		},
+		{
+			name:   "user banner without tag",
+			method: http.MethodGet,
+			target: "/user_banner?feature_id=1",
+			fields: []string{"tag_id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+			var resp apierror.Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, apierror.CodeValidationFailed, resp.Code)
+
+			var fields []string
+			for _, detail := range resp.Details {
+				fields = append(fields, detail.Field)
+			}
+			assert.ElementsMatch(t, tt.fields, fields)
+		})
+	}
+}
+
+// contractService answers every call with a fully populated sample, so the
+// responses exercise all the fields of their schemas.
+type contractService struct {
+	AuthManagement
+	BannerManagement
+	WebhookManagement
+	CatalogManagement
+	EventManagement
+}
+
+var (
+	sampleTime   = time.Date(2024, 5, 17, 12, 0, 0, 0, time.UTC)
+	sampleId     = uint64(1)
+	sampleBanner = models.Banner{
+		BannerId:      1,
+		FeatureId:     2,
+		TagIds:        []uint64{3, 4},
+		Content:       json.RawMessage(`{"title":"hello"}`),
+		Localized:     map[string]json.RawMessage{"en": json.RawMessage(`{"title":"hello"}`)},
+		DefaultLocale: "ru",
+		IsActive:      true,
+		Priority:      1,
+		FrequencyCap:  &models.FrequencyCap{Limit: 3, WindowSeconds: 3600},
+		Version:       5,
+		CreatedAt:     sampleTime,
+		UpdatedAt:     sampleTime,
+	}
+	sampleEntry        = models.CatalogEntry{Name: "name", Description: "description", Owner: "admin", CreatedAt: sampleTime, UpdatedAt: sampleTime}
+	sampleSubscription = models.WebhookSubscription{
+		SubscriptionId: 1,
+		URL:            "https://example.com/hook",
+		Secret:         "secret",
+		FeatureId:      &sampleId,
+		EventTypes:     []string{"banner.created"},
+		IsActive:       true,
+		CreatedAt:      sampleTime,
+	}
+	sampleSchema = models.FeatureSchema{FeatureId: 2, Version: 1, Schema: json.RawMessage(`{"type":"object"}`), CreatedBy: "admin", CreatedAt: sampleTime}
+)
+
+func (s contractService) SignUp(context.Context, *models.User) (string, error) {
+	return "token", nil
+}
+
+func (s contractService) SignIn(context.Context, *models.User) (string, error) {
+	return "token", nil
+}
+
+func (s contractService) GetBanner(context.Context, []uint64, uint64, models.UserRole, bool, models.TemplateVars, string) (models.BannerContent, error) {
+	return models.BannerContent{Content: `{"title":"hello"}`, Locale: "ru", IsActive: true, TagId: 3, BannerId: 1}, nil
+}
+
+func (s contractService) GetListOfVersions(context.Context, uint64) ([]models.Banner, error) {
+	return []models.Banner{sampleBanner}, nil
+}
+
+func (s contractService) ChooseBannerVersion(context.Context, uint64, uint64) error {
+	return nil
+}
+
+func (s contractService) GetFilteredBanners(context.Context, *models.FilterBanner) (models.BannerPage, error) {
+	total := uint64(1)
+	return models.BannerPage{Banners: []models.Banner{sampleBanner}, NextCursor: "cursor", Total: &total}, nil
+}
+
+func (s contractService) SearchBanners(context.Context, *models.BannerSearch) ([]models.BannerSearchResult, error) {
+	return []models.BannerSearchResult{{
+		Banner:    sampleBanner,
+		IsCurrent: true,
+		Rank:      0.5,
+		Highlight: json.RawMessage(`{"title":"<mark>hello</mark>"}`),
+	}}, nil
+}
+
+func (s contractService) BulkUpdate(context.Context, *models.BulkRequest) (models.BulkReport, error) {
+	return models.BulkReport{Atomic: true, Committed: true, Applied: 1, Failed: 1, Items: []models.BulkItemResult{
+		{Operation: 0, BannerId: 1, Status: models.BulkApplied},
+		{Operation: 0, BannerId: 2, Status: models.BulkFailed, Error: "banner or version not found"},
+	}}, nil
+}
+
+func (s contractService) ValidateContent(context.Context, uint64, json.RawMessage) (models.ContentValidation, error) {
+	return models.ContentValidation{SchemaVersion: 1, Violations: []models.SchemaViolation{{Path: "/title", Message: "is required"}}}, nil
+}
+
+func (s contractService) GetSchemaReport(context.Context, uint64, uint64) (models.SchemaReport, error) {
+	return models.SchemaReport{FeatureId: 2, SchemaVersion: 1, Checked: 1, Banners: []models.NonConformingBanner{{
+		BannerId:   1,
+		Version:    5,
+		Locale:     "en",
+		Violations: []models.SchemaViolation{{Path: "/title", Message: "is required"}},
+	}}}, nil
+}
+
+func (s contractService) CreateBanner(context.Context, *models.Banner) (uint64, error) {
+	return 1, nil
+}
+
+func (s contractService) PartialUpdateBanner(context.Context, uint64, *models.PatchBanner) error {
+	return nil
+}
+
+func (s contractService) DeleteBanner(context.Context, uint64) error {
+	return nil
+}
+
+func (s contractService) MarkBannerAsDeleted(context.Context, *uint64, *uint64) error {
+	return nil
+}
+
+func (s contractService) GetAuditEvents(context.Context, *models.AuditFilter) (models.AuditPage, error) {
+	return models.AuditPage{Events: []models.AuditEvent{{
+		EventId:   1,
+		Actor:     "admin",
+		Action:    models.AuditCreate,
+		BannerId:  1,
+		Before:    json.RawMessage(`null`),
+		After:     json.RawMessage(`{"banner_id":1}`),
+		RequestId: "request",
+		CreatedAt: sampleTime,
+	}}, NextCursor: "cursor"}, nil
+}
+
+func (s contractService) GetDeletedBanners(context.Context) ([]models.DeletedBanner, error) {
+	return []models.DeletedBanner{{Banner: sampleBanner, DeletedAt: sampleTime, PurgeAt: sampleTime.Add(time.Hour)}}, nil
+}
+
+func (s contractService) RestoreBanner(context.Context, uint64) error {
+	return nil
+}
+
+func (s contractService) RestoreBanners(context.Context, *uint64, *uint64) ([]uint64, error) {
+	return []uint64{1}, nil
+}
+
+func (s contractService) GetSnapshot(context.Context, uint64, *uint64, models.TemplateVars) (models.TagSnapshot, error) {
+	return models.TagSnapshot{TagId: 3, Revision: 7, Banners: map[uint64]json.RawMessage{2: json.RawMessage(`{"title":"hello"}`)}}, nil
+}
+
+func (s contractService) CreateSubscription(context.Context, *models.WebhookSubscription) (models.WebhookSubscription, error) {
+	return sampleSubscription, nil
+}
+
+func (s contractService) GetSubscriptions(context.Context) ([]models.WebhookSubscription, error) {
+	return []models.WebhookSubscription{sampleSubscription}, nil
+}
+
+func (s contractService) GetSubscription(context.Context, uint64) (models.WebhookSubscription, error) {
+	return sampleSubscription, nil
+}
+
+func (s contractService) UpdateSubscription(context.Context, uint64, *models.PatchWebhookSubscription) error {
+	return nil
+}
+
+func (s contractService) DeleteSubscription(context.Context, uint64) error {
+	return nil
+}
+
+func (s contractService) GetDeliveries(context.Context, *models.DeliveryFilter) ([]models.WebhookDelivery, error) {
+	status := http.StatusOK
+	return []models.WebhookDelivery{{
+		DeliveryId:     1,
+		SubscriptionId: 1,
+		EventId:        1,
+		EventType:      "banner.created",
+		Payload:        json.RawMessage(`{"banner_id":1}`),
+		Status:         models.DeliveryDelivered,
+		Attempts:       1,
+		ResponseStatus: &status,
+		NextAttemptAt:  sampleTime,
+		CreatedAt:      sampleTime,
+		DeliveredAt:    &sampleTime,
+	}}, nil
+}
+
+func (s contractService) CreateFeature(context.Context, *models.Feature) (models.Feature, error) {
+	return models.Feature{FeatureId: 2, DefaultBannerId: &sampleId, CatalogEntry: sampleEntry}, nil
+}
+
+func (s contractService) GetFeatures(context.Context, *models.CatalogFilter) ([]models.Feature, error) {
+	return []models.Feature{{FeatureId: 2, CatalogEntry: sampleEntry}}, nil
+}
+
+func (s contractService) GetFeature(context.Context, uint64) (models.Feature, error) {
+	return models.Feature{FeatureId: 2, DefaultBannerId: &sampleId, CatalogEntry: sampleEntry}, nil
+}
+
+func (s contractService) UpdateFeature(context.Context, uint64, *models.PatchFeature) error {
+	return nil
+}
+
+func (s contractService) DeleteFeature(context.Context, uint64) error {
+	return nil
+}
+
+func (s contractService) CreateTag(context.Context, *models.Tag) (models.Tag, error) {
+	return models.Tag{TagId: 4, ParentTagId: &sampleId, CatalogEntry: sampleEntry}, nil
+}
+
+func (s contractService) GetTags(context.Context, *models.CatalogFilter) ([]models.Tag, error) {
+	return []models.Tag{{TagId: 3, CatalogEntry: sampleEntry}}, nil
+}
+
+func (s contractService) GetTag(context.Context, uint64) (models.Tag, error) {
+	return models.Tag{TagId: 4, ParentTagId: &sampleId, CatalogEntry: sampleEntry}, nil
+}
+
+func (s contractService) UpdateTag(context.Context, uint64, *models.PatchTag) error {
+	return nil
+}
+
+func (s contractService) DeleteTag(context.Context, uint64) error {
+	return nil
+}
+
+func (s contractService) SetFeatureSchema(context.Context, uint64, json.RawMessage) (models.FeatureSchema, error) {
+	return sampleSchema, nil
+}
+
+func (s contractService) DeleteFeatureSchema(context.Context, uint64) (models.FeatureSchema, error) {
+	return sampleSchema, nil
+}
+
+func (s contractService) GetFeatureSchema(context.Context, uint64, uint64) (models.FeatureSchema, error) {
+	return sampleSchema, nil
+}
+
+func (s contractService) GetFeatureSchemas(context.Context, uint64) ([]models.FeatureSchema, error) {
+	return []models.FeatureSchema{sampleSchema}, nil
+}
+
+func (s contractService) TrackEvents(_ context.Context, events []models.BannerEvent) (models.EventReceipt, error) {
+	return models.EventReceipt{Accepted: len(events)}, nil
+}
+
+func (s contractService) GetBannerStats(context.Context, uint64, *models.StatsFilter) (models.BannerStats, error) {
+	counts := models.EventCounts{Impressions: 10, Clicks: 1, CTR: 0.1}
+	return models.BannerStats{
+		BannerId: 1,
+		Total:    counts,
+		Versions: []models.VersionStats{{Version: 5, EventCounts: counts}},
+		Days:     []models.DayStats{{Day: "2024-05-17", EventCounts: counts}},
+	}, nil
+}
+
+// streamedOperations answer with streams or files rather than JSON documents;
+// their responses are not validated here.
+var streamedOperations = map[string]bool{
+	"GET /banner/export":      true,
+	"POST /banner/import":     true,
+	"GET /user_banner/stream": true,
+	"POST /assets":            true,
+	"GET /assets/{hash}":      true,
+}
+
+func TestResponsesMatchSchema(t *testing.T) {
+	doc := loadSpec(t)
+	specRouter, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	service := contractService{}
+	ctr := newTestController(t)
+	ctr.AuthProvider.AuthManagement = service
+	ctr.BannerService = BannerService{service}
+	ctr.WebhookService = WebhookService{service}
+	ctr.CatalogService = CatalogService{service}
+	ctr.EventService = EventService{service}
+	router := ctr.NewRouter()
+
+	tests := []struct {
+		method      string
+		target      string
+		contentType string
+		body        string
+	}{
+		{method: http.MethodPost, target: "/sign-up", body: `{"username": "user", "password": "password"}`},
+		{method: http.MethodPost, target: "/sign-in", body: `{"username": "user", "password": "password"}`},
+		{method: http.MethodGet, target: "/user_banner?tag_id=3&feature_id=2"},
+		{method: http.MethodGet, target: "/user_banner/snapshot?tag_id=3"},
+		{method: http.MethodGet, target: "/audit?banner_id=1"},
+		{method: http.MethodPost, target: "/events", body: `[{"kind": "impression", "banner_id": 1, "version": 5}]`},
+		{method: http.MethodGet, target: "/webhooks"},
+		{method: http.MethodPost, target: "/webhooks", body: `{"url": "https://example.com/hook", "event_types": ["banner.created"]}`},
+		{method: http.MethodGet, target: "/webhooks/1"},
+		{method: http.MethodPatch, target: "/webhooks/1", body: `{"is_active": false}`},
+		{method: http.MethodDelete, target: "/webhooks/1"},
+		{method: http.MethodGet, target: "/webhooks/1/deliveries"},
+		{method: http.MethodGet, target: "/feature"},
+		{method: http.MethodPost, target: "/feature", body: `{"name": "name"}`},
+		{method: http.MethodGet, target: "/feature/2"},
+		{method: http.MethodPatch, target: "/feature/2", body: `{"description": "description"}`},
+		{method: http.MethodDelete, target: "/feature/2"},
+		{method: http.MethodGet, target: "/feature/2/schema"},
+		{method: http.MethodPut, target: "/feature/2/schema", body: `{"type": "object"}`},
+		{method: http.MethodDelete, target: "/feature/2/schema"},
+		{method: http.MethodGet, target: "/feature/2/schema/versions"},
+		{method: http.MethodGet, target: "/feature/2/schema/report"},
+		{method: http.MethodGet, target: "/tag"},
+		{method: http.MethodPost, target: "/tag", body: `{"name": "name", "parent_tag_id": 1}`},
+		{method: http.MethodGet, target: "/tag/4"},
+		{method: http.MethodPatch, target: "/tag/4", body: `{"description": "description"}`},
+		{method: http.MethodDelete, target: "/tag/4"},
+		{method: http.MethodGet, target: "/banner?with_total=true"},
+		{method: http.MethodGet, target: "/banner/search?q=hello"},
+		{method: http.MethodPost, target: "/banner/bulk", body: `{"operations": [{"action": "activate", "banner_ids": [1, 2]}], "atomic": true}`},
+		{method: http.MethodPost, target: "/banner/validate", body: `{"feature_id": 2, "content": {"title": "hello"}}`},
+		{method: http.MethodGet, target: "/banner/versions/1"},
+		{method: http.MethodGet, target: "/banner/trash"},
+		{method: http.MethodPost, target: "/banner/restore?feature_id=2"},
+		{method: http.MethodPost, target: "/banner/1/restore"},
+		{method: http.MethodGet, target: "/banner/1/stats"},
+		{method: http.MethodPost, target: "/banner", body: `{"tag_ids": [3, 4], "feature_id": 2, "content": {"title": "hello"}, "is_active": true}`},
+		{method: http.MethodPatch, target: "/banner/1", body: `{"is_active": false}`},
+		{method: http.MethodPatch, target: "/banner/1", contentType: "application/merge-patch+json", body: `{"content": {"title": null}}`},
+		{method: http.MethodPatch, target: "/banner/1", contentType: "application/json-patch+json", body: `[{"op": "replace", "path": "/title", "value": "bye"}]`},
+		{method: http.MethodPatch, target: "/banner/1/version/5"},
+		{method: http.MethodDelete, target: "/banner/1"},
+		{method: http.MethodDelete, target: "/banner?tag_id=3"},
+		// Errors are documented too.
+		{method: http.MethodGet, target: "/banner?limit=ten"},
+		{method: http.MethodGet, target: "/feature/0"},
+	}
+
+	covered := make(map[string]bool)
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			newRequest := func() *http.Request {
+				req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", contentType)
+				req.Header.Set("Authorization", "Bearer "+adminToken)
+				return req
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, newRequest())
+
+			req := newRequest()
+			route, pathParams, err := specRouter.FindRoute(req)
+			require.NoError(t, err)
+			covered[tt.method+" "+route.Path] = true
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: &openapi3filter.RequestValidationInput{
+					Request:    req,
+					PathParams: pathParams,
+					Route:      route,
+				},
+				Status: rec.Code,
+				Header: rec.Header(),
+				Body:   io.NopCloser(bytes.NewReader(rec.Body.Bytes())),
+				Options: &openapi3filter.Options{
+					IncludeResponseStatus: true,
+				},
+			})
+			assert.NoErrorf(t, err, "%d %s", rec.Code, rec.Body.String())
+		})
+	}
+
+	for path, pathItem := range doc.Paths.Map() {
+		for method := range pathItem.Operations() {
+			operation := method + " " + path
+			assert.Truef(t, covered[operation] || streamedOperations[operation], "the response of %s is not checked", operation)
+		}
+	}
+}
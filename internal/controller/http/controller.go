@@ -1,17 +1,30 @@
 package http
 
 import (
+	"banner-service/internal/apierror"
 	"banner-service/internal/auth"
+	"banner-service/internal/middleware"
 	"banner-service/internal/models"
-	"banner-service/internal/repository"
+	"banner-service/internal/patch"
+	"banner-service/internal/reqctx"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
+	"mime"
 	"net/http"
 	"strconv"
 )
 
+const (
+	NextCursorHeader = "X-Next-Cursor"
+	TotalCountHeader = "X-Total-Count"
+	// MatchedTagHeader is the tag a user banner was found by, or
+	// MatchedFeatureDefault for the default banner of the feature.
+	MatchedTagHeader      = "X-Matched-Tag"
+	MatchedFeatureDefault = "default"
+)
+
 type AuthProvider struct {
 	AuthManagement
 	auth.TokenProvider
@@ -21,15 +34,42 @@ type BannerService struct {
 	BannerManagement
 }
 
+type WebhookService struct {
+	WebhookManagement
+}
+
+type CatalogService struct {
+	CatalogManagement
+}
+
+type AssetService struct {
+	AssetManagement
+}
+
+type EventService struct {
+	EventManagement
+}
+
 type Controller struct {
 	AuthProvider
 	BannerService
+	WebhookService
+	CatalogService
+	AssetService
+	EventService
+	RequestValidator *middleware.RequestValidator
 }
 
-func NewController(as AuthProvider, bs BannerService) *Controller {
+func NewController(as AuthProvider, bs BannerService, ws WebhookService, cs CatalogService, fs AssetService, es EventService,
+	rv *middleware.RequestValidator) *Controller {
 	return &Controller{
-		AuthProvider:  as,
-		BannerService: bs,
+		AuthProvider:     as,
+		BannerService:    bs,
+		WebhookService:   ws,
+		CatalogService:   cs,
+		AssetService:     fs,
+		EventService:     es,
+		RequestValidator: rv,
 	}
 }
 
@@ -37,55 +77,74 @@ func (ctr *Controller) SignUpEndpoint(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	err := json.NewDecoder(r.Body).Decode(&user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody(err))
 		return
 	}
 
 	token, err := ctr.AuthProvider.SignUp(r.Context(), &user)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusCreated)
-	_, err = w.Write([]byte(token))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	_, _ = w.Write([]byte(token))
 }
 
 func (ctr *Controller) SignInEndpoint(w http.ResponseWriter, r *http.Request) {
 	var signInInput models.User
 	err := json.NewDecoder(r.Body).Decode(&signInInput)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody(err))
 		return
 	}
 
 	token, err := ctr.AuthProvider.SignIn(r.Context(), &signInInput)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
-	_, err = w.Write([]byte(token))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	_, _ = w.Write([]byte(token))
+}
+
+// templateVars collects the values banner templates are rendered with: the
+// claims of the token and the query parameters. strict_templates=true asks for
+// strict rendering.
+func templateVars(r *http.Request) models.TemplateVars {
+	vars := models.TemplateVars{
+		User:   reqctx.GetClaims(r.Context()),
+		Param:  make(map[string]string),
+		Strict: r.URL.Query().Get("strict_templates") == "true",
 	}
+	for name, values := range r.URL.Query() {
+		vars.Param[name] = values[0]
+	}
+	return vars
+}
+
+// acceptedLanguages returns the locales the client prefers in the
+// Accept-Language syntax. An explicit locale parameter takes precedence over
+// the header.
+func acceptedLanguages(r *http.Request) string {
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		return locale
+	}
+	return r.Header.Get("Accept-Language")
 }
 
 func (ctr *Controller) GetBannerEndpoint(w http.ResponseWriter, r *http.Request) {
-	tagId, err := strconv.ParseUint(r.URL.Query().Get("tag_id"), 10, 64)
+	tagIds, err := parseTagIds(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, err)
 		return
 	}
 	featureId, err := strconv.ParseUint(r.URL.Query().Get("feature_id"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
 		return
 	}
 
@@ -93,51 +152,41 @@ func (ctr *Controller) GetBannerEndpoint(w http.ResponseWriter, r *http.Request)
 
 	role := auth.GetRole(r.Context())
 
-	content, err := ctr.BannerService.GetBanner(r.Context(), tagId, featureId, role, useLastRevision)
-	if errors.Is(err, repository.ErrBannerInactive) {
-		http.Error(w, err.Error(), http.StatusForbidden)
-		return
-	} else if errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	vars := templateVars(r)
 
-	w.WriteHeader(http.StatusOK)
-	_, err = w.Write([]byte(content))
+	content, err := ctr.BannerService.GetBanner(r.Context(), tagIds, featureId, role, useLastRevision, vars, acceptedLanguages(r))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
-}
 
-func (ctr *Controller) GetFilteredBannersEndpoint(w http.ResponseWriter, r *http.Request) {
-	var filter models.FilterBanner
+	w.Header().Set("Vary", "Accept-Language")
+	if content.Locale != "" {
+		w.Header().Set("Content-Language", content.Locale)
+	}
 
-	if tagIdStr := r.URL.Query().Get("tag_id"); tagIdStr != "" {
-		tag, err := strconv.ParseUint(tagIdStr, 10, 64)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		filter.TagId = tag
+	if content.TagId == 0 {
+		w.Header().Set(MatchedTagHeader, MatchedFeatureDefault)
+	} else {
+		w.Header().Set(MatchedTagHeader, strconv.FormatUint(content.TagId, 10))
 	}
 
-	if featureIdStr := r.URL.Query().Get("feature_id"); featureIdStr != "" {
-		feature, err := strconv.ParseUint(featureIdStr, 10, 64)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
-		filter.FeatureId = feature
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(content.Content))
+}
+
+func (ctr *Controller) GetFilteredBannersEndpoint(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseBannerFilter(r.URL.Query())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
 	}
 
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		limit, err := strconv.ParseUint(limitStr, 10, 64)
 		if err != nil {
-			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			apierror.Write(w, r, apierror.InvalidParameter("limit"))
 			return
 		}
 		filter.Limit = limit
@@ -145,88 +194,119 @@ func (ctr *Controller) GetFilteredBannersEndpoint(w http.ResponseWriter, r *http
 	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
 		offset, err := strconv.ParseUint(offsetStr, 10, 64)
 		if err != nil {
-			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			apierror.Write(w, r, apierror.InvalidParameter("offset"))
 			return
 		}
 		filter.Offset = offset
 	}
 
-	banners, err := ctr.BannerService.GetFilteredBanners(r.Context(), &filter)
-	if errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	filter.SortBy = models.BannerSortField(r.URL.Query().Get("sort_by"))
+	filter.Order = models.SortOrder(r.URL.Query().Get("order"))
+	filter.Cursor = r.URL.Query().Get("cursor")
+	filter.WithTotal = r.URL.Query().Get("with_total") == "true"
+	for _, expand := range r.URL.Query()["expand"] {
+		if !lo.Contains(models.BannerExpansions, models.BannerExpansion(expand)) {
+			apierror.Write(w, r, apierror.InvalidParameter("expand"))
+			return
+		}
+		filter.Expand = append(filter.Expand, models.BannerExpansion(expand))
 	}
 
-	bannersJSON, err := json.Marshal(banners)
+	page, err := ctr.BannerService.GetFilteredBanners(r.Context(), &filter)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
-	_, err = w.Write(bannersJSON)
+	bannersJSON, err := json.Marshal(page.Banners)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
+	if page.NextCursor != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", page.NextCursor)
+		query.Del("offset")
+		next.RawQuery = query.Encode()
+		w.Header().Set(NextCursorHeader, page.NextCursor)
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+	if page.Total != nil {
+		w.Header().Set(TotalCountHeader, strconv.FormatUint(*page.Total, 10))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(bannersJSON)
 }
 
 type CreateDTO struct {
-	TagIds    []uint64        `json:"tag_ids"`
-	FeatureId uint64          `json:"feature_id"`
-	Content   json.RawMessage `json:"content"`
-	IsActive  bool            `json:"is_active"`
+	TagIds        []uint64                   `json:"tag_ids"`
+	FeatureId     uint64                     `json:"feature_id"`
+	Content       json.RawMessage            `json:"content"`
+	Localized     map[string]json.RawMessage `json:"localized,omitempty"`
+	DefaultLocale string                     `json:"default_locale,omitempty"`
+	IsActive      bool                       `json:"is_active"`
+	Priority      int                        `json:"priority"`
+	FrequencyCap  *models.FrequencyCap       `json:"frequency_cap"`
 }
 
 func (ctr *Controller) CreateBannerEndpoint(w http.ResponseWriter, r *http.Request) {
 	var banner *CreateDTO
 	err := json.NewDecoder(r.Body).Decode(&banner)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidBody(err))
 		return
 	}
 	bannerId, err := ctr.BannerService.CreateBanner(r.Context(), &models.Banner{
-		TagIds:    banner.TagIds,
-		FeatureId: banner.FeatureId,
-		Content:   banner.Content,
-		IsActive:  banner.IsActive,
+		TagIds:        banner.TagIds,
+		FeatureId:     banner.FeatureId,
+		Content:       banner.Content,
+		Localized:     banner.Localized,
+		DefaultLocale: banner.DefaultLocale,
+		IsActive:      banner.IsActive,
+		Priority:      banner.Priority,
+		FrequencyCap:  banner.FrequencyCap,
 	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("CreateBanner error: %v ", err), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	_, err = w.Write([]byte(strconv.FormatUint(bannerId, 10)))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	_, _ = w.Write([]byte(strconv.FormatUint(bannerId, 10)))
 }
 
 func (ctr *Controller) PartialUpdateBannerEndpoint(w http.ResponseWriter, r *http.Request) {
 	bannerId, err := strconv.ParseUint(chi.URLParam(r, "banner_id"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
 		return
 	}
 	var banner models.PatchBanner
-	err = json.NewDecoder(r.Body).Decode(&banner)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case patch.MergePatch, patch.JSONPatch:
+		// The document patches the content; the rest of the banner stays.
+		var document json.RawMessage
+		if err = json.NewDecoder(r.Body).Decode(&document); err != nil {
+			apierror.Write(w, r, apierror.InvalidBody(err))
+			return
+		}
+		banner.ContentPatch = &models.ContentPatch{MediaType: mediaType, Document: document}
+	default:
+		if err = json.NewDecoder(r.Body).Decode(&banner); err != nil {
+			apierror.Write(w, r, apierror.InvalidBody(err))
+			return
+		}
 	}
 
 	err = ctr.BannerService.PartialUpdateBanner(r.Context(), bannerId, &banner)
-	if errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -236,15 +316,12 @@ func (ctr *Controller) PartialUpdateBannerEndpoint(w http.ResponseWriter, r *htt
 func (ctr *Controller) DeleteBannerEndpoint(w http.ResponseWriter, r *http.Request) {
 	bannerId, err := strconv.ParseUint(chi.URLParam(r, "banner_id"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
 		return
 	}
 
-	if err = ctr.BannerService.DeleteBanner(r.Context(), bannerId); errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err = ctr.BannerService.DeleteBanner(r.Context(), bannerId); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -254,51 +331,42 @@ func (ctr *Controller) DeleteBannerEndpoint(w http.ResponseWriter, r *http.Reque
 func (ctr *Controller) GetListOfVersionsEndpoint(w http.ResponseWriter, r *http.Request) {
 	bannerId, err := strconv.ParseUint(chi.URLParam(r, "banner_id"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
 		return
 	}
 
 	banners, err := ctr.BannerService.GetListOfVersions(r.Context(), bannerId)
-	if errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	bannersJSON, err := json.Marshal(banners)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apierror.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_, err = w.Write(bannersJSON)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+	_, _ = w.Write(bannersJSON)
 }
 
 func (ctr *Controller) ChooseBannerVersionEndpoint(w http.ResponseWriter, r *http.Request) {
 	bannerId, err := strconv.ParseUint(chi.URLParam(r, "banner_id"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
 		return
 	}
 	version, err := strconv.ParseUint(chi.URLParam(r, "version"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierror.Write(w, r, apierror.InvalidParameter("version"))
 		return
 	}
 
 	err = ctr.BannerService.ChooseBannerVersion(r.Context(), bannerId, version)
-	if errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
@@ -311,7 +379,7 @@ func (ctr *Controller) MarkBannerAsDeletedEndpoint(w http.ResponseWriter, r *htt
 	if tagIdStr := r.URL.Query().Get("tag_id"); tagIdStr != "" {
 		tag, err := strconv.ParseUint(tagIdStr, 10, 64)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
 			return
 		}
 		tagId = &tag
@@ -320,20 +388,103 @@ func (ctr *Controller) MarkBannerAsDeletedEndpoint(w http.ResponseWriter, r *htt
 	if featureIdStr := r.URL.Query().Get("feature_id"); featureIdStr != "" {
 		feature, err := strconv.ParseUint(featureIdStr, 10, 64)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
 			return
 		}
 		featureId = &feature
 	}
 
-	err := ctr.BannerService.MarkBannerAsDeleted(r.Context(), featureId, tagId)
-	if errors.Is(err, repository.ErrNotFound) {
-		http.Error(w, err.Error(), http.StatusNotFound)
+	if featureId == nil && tagId == nil {
+		apierror.Write(w, r, apierror.BadRequest("feature_id or tag_id is required"))
 		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+
+	err := ctr.BannerService.MarkBannerAsDeleted(r.Context(), featureId, tagId)
+	if err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (ctr *Controller) GetDeletedBannersEndpoint(w http.ResponseWriter, r *http.Request) {
+	banners, err := ctr.BannerService.GetDeletedBanners(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	bannersJSON, err := json.Marshal(banners)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(bannersJSON)
+}
+
+func (ctr *Controller) RestoreBannerEndpoint(w http.ResponseWriter, r *http.Request) {
+	bannerId, err := strconv.ParseUint(chi.URLParam(r, "banner_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
+		return
+	}
+
+	err = ctr.BannerService.RestoreBanner(r.Context(), bannerId)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type RestoredDTO struct {
+	BannerIds []uint64 `json:"banner_ids"`
+}
+
+func (ctr *Controller) RestoreBannersEndpoint(w http.ResponseWriter, r *http.Request) {
+	var featureId, tagId *uint64
+
+	if tagIdStr := r.URL.Query().Get("tag_id"); tagIdStr != "" {
+		tag, err := strconv.ParseUint(tagIdStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
+			return
+		}
+		tagId = &tag
+	}
+
+	if featureIdStr := r.URL.Query().Get("feature_id"); featureIdStr != "" {
+		feature, err := strconv.ParseUint(featureIdStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+			return
+		}
+		featureId = &feature
+	}
+
+	if featureId == nil && tagId == nil {
+		apierror.Write(w, r, apierror.BadRequest("feature_id or tag_id is required"))
+		return
+	}
+
+	bannerIds, err := ctr.BannerService.RestoreBanners(r.Context(), featureId, tagId)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	restoredJSON, err := json.Marshal(RestoredDTO{BannerIds: bannerIds})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(restoredJSON)
+}
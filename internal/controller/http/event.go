@@ -0,0 +1,71 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+// TrackEventsEndpoint queues the reported events and answers before they are
+// written.
+func (ctr *Controller) TrackEventsEndpoint(w http.ResponseWriter, r *http.Request) {
+	var events []models.BannerEvent
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	receipt, err := ctr.EventService.TrackEvents(r.Context(), events)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	receiptJSON, err := json.Marshal(receipt)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(receiptJSON)
+}
+
+func (ctr *Controller) GetBannerStatsEndpoint(w http.ResponseWriter, r *http.Request) {
+	bannerId, err := strconv.ParseUint(chi.URLParam(r, "banner_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("banner_id"))
+		return
+	}
+
+	query := r.URL.Query()
+	var filter models.StatsFilter
+	if filter.From, err = parseOptionalTime(query, "from"); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if filter.To, err = parseOptionalTime(query, "to"); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	stats, err := ctr.EventService.GetBannerStats(r.Context(), bannerId, &filter)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(statsJSON)
+}
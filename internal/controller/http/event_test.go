@@ -0,0 +1,43 @@
+package http
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statsService struct {
+	EventManagement
+	filter *models.StatsFilter
+}
+
+func (s *statsService) GetBannerStats(_ context.Context, bannerId uint64, filter *models.StatsFilter) (models.BannerStats, error) {
+	s.filter = filter
+	return models.BannerStats{BannerId: bannerId, Versions: []models.VersionStats{}, Days: []models.DayStats{}}, nil
+}
+
+func TestGetBannerStatsNormalizesTimeFilters(t *testing.T) {
+	service := &statsService{}
+	ctr := newTestController(t)
+	ctr.EventService = EventService{service}
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/banner/7/stats?from=2024-05-15T12:00:00%2B03:00&to=2024-05-16T00:00:00Z", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	rec := httptest.NewRecorder()
+
+	ctr.NewRouter().ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NotNil(t, service.filter.From)
+	require.NotNil(t, service.filter.To)
+	assert.Equal(t, time.UTC, service.filter.From.Location())
+	assert.Equal(t, time.Date(2024, 5, 15, 9, 0, 0, 0, time.UTC), *service.filter.From)
+	assert.Equal(t, time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC), *service.filter.To)
+}
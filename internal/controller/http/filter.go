@@ -0,0 +1,117 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseBannerFilter reads the filter conditions of GET /banner. feature_id and
+// tag_id may be repeated; the time bounds are RFC 3339 timestamps.
+func parseBannerFilter(query url.Values) (models.FilterBanner, error) {
+	var (
+		filter models.FilterBanner
+		err    error
+	)
+
+	if filter.FeatureIds, err = parseUintList(query, "feature_id"); err != nil {
+		return filter, err
+	}
+	if filter.TagIds, err = parseUintList(query, "tag_id"); err != nil {
+		return filter, err
+	}
+	if filter.IsActive, err = parseOptionalBool(query, "is_active"); err != nil {
+		return filter, err
+	}
+	if filter.HasDraft, err = parseOptionalBool(query, "has_draft"); err != nil {
+		return filter, err
+	}
+
+	for name, dst := range map[string]**time.Time{
+		"created_from": &filter.CreatedFrom,
+		"created_to":   &filter.CreatedTo,
+		"updated_from": &filter.UpdatedFrom,
+		"updated_to":   &filter.UpdatedTo,
+	} {
+		if *dst, err = parseOptionalTime(query, name); err != nil {
+			return filter, err
+		}
+	}
+
+	if content := query.Get("content"); content != "" {
+		if !json.Valid([]byte(content)) {
+			return filter, apierror.InvalidParameter("content")
+		}
+		filter.Content = json.RawMessage(content)
+	}
+	filter.ContentPath = query.Get("content_path")
+
+	return filter, nil
+}
+
+func parseUintList(query url.Values, name string) ([]uint64, error) {
+	values := query[name]
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint64, 0, len(values))
+	for _, value := range values {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, apierror.InvalidParameter(name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseTagIds reads the tags of a user banner request: tag_id may be repeated
+// or hold a comma-separated list, and at least one tag is required.
+func parseTagIds(query url.Values) ([]uint64, error) {
+	var tagIds []uint64
+	for _, value := range query["tag_id"] {
+		for _, part := range strings.Split(value, ",") {
+			tagId, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil {
+				return nil, apierror.InvalidParameter("tag_id")
+			}
+			tagIds = append(tagIds, tagId)
+		}
+	}
+	if len(tagIds) == 0 {
+		return nil, apierror.InvalidParameter("tag_id")
+	}
+	return tagIds, nil
+}
+
+func parseOptionalBool(query url.Values, name string) (*bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, apierror.InvalidParameter(name)
+	}
+	return &b, nil
+}
+
+func parseOptionalTime(query url.Values, name string) (*time.Time, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, apierror.InvalidParameter(name)
+	}
+	t = t.UTC()
+	return &t, nil
+}
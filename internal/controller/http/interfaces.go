@@ -3,6 +3,8 @@ package http
 import (
 	"banner-service/internal/models"
 	"context"
+	"encoding/json"
+	"io"
 )
 
 type AuthManagement interface {
@@ -11,12 +13,60 @@ type AuthManagement interface {
 }
 
 type BannerManagement interface {
-	GetBanner(ctx context.Context, tagId uint64, featureId uint64, role models.UserRole, useLastRevision bool) (string, error)
+	GetBanner(ctx context.Context, tagIds []uint64, featureId uint64, role models.UserRole, useLastRevision bool, vars models.TemplateVars, languages string) (models.BannerContent, error)
 	GetListOfVersions(ctx context.Context, bannersId uint64) ([]models.Banner, error)
 	ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) error
-	GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) ([]models.Banner, error)
+	GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) (models.BannerPage, error)
+	SearchBanners(ctx context.Context, search *models.BannerSearch) ([]models.BannerSearchResult, error)
+	ExportBanners(ctx context.Context, fn func(models.BannerExport) error) error
+	ImportBanners(ctx context.Context, r io.Reader, opts models.ImportOptions) (models.ImportReport, error)
+	BulkUpdate(ctx context.Context, req *models.BulkRequest) (models.BulkReport, error)
+	ValidateContent(ctx context.Context, featureId uint64, content json.RawMessage) (models.ContentValidation, error)
+	GetSchemaReport(ctx context.Context, featureId, version uint64) (models.SchemaReport, error)
 	CreateBanner(ctx context.Context, banner *models.Banner) (uint64, error)
 	PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner) error
 	DeleteBanner(ctx context.Context, id uint64) error
 	MarkBannerAsDeleted(ctx context.Context, featureId, tagId *uint64) error
+	GetAuditEvents(ctx context.Context, filter *models.AuditFilter) (models.AuditPage, error)
+	GetDeletedBanners(ctx context.Context) ([]models.DeletedBanner, error)
+	RestoreBanner(ctx context.Context, bannerId uint64) error
+	RestoreBanners(ctx context.Context, featureId, tagId *uint64) ([]uint64, error)
+	WatchBanner(ctx context.Context, tagId uint64, featureId uint64, role models.UserRole, lastEventId *uint64, vars models.TemplateVars, languages string) (<-chan models.BannerUpdate, error)
+	GetSnapshot(ctx context.Context, tagId uint64, since *uint64, vars models.TemplateVars) (models.TagSnapshot, error)
+}
+
+type WebhookManagement interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (models.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, subscriptionId uint64) (models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscriptionId uint64, patch *models.PatchWebhookSubscription) error
+	DeleteSubscription(ctx context.Context, subscriptionId uint64) error
+	GetDeliveries(ctx context.Context, filter *models.DeliveryFilter) ([]models.WebhookDelivery, error)
+}
+
+type AssetManagement interface {
+	UploadAsset(ctx context.Context, r io.Reader) (models.Asset, bool, error)
+	OpenAsset(ctx context.Context, hash string) (models.Asset, io.ReadCloser, error)
+}
+
+type EventManagement interface {
+	TrackEvents(ctx context.Context, events []models.BannerEvent) (models.EventReceipt, error)
+	GetBannerStats(ctx context.Context, bannerId uint64, filter *models.StatsFilter) (models.BannerStats, error)
+}
+
+type CatalogManagement interface {
+	CreateFeature(ctx context.Context, feature *models.Feature) (models.Feature, error)
+	GetFeatures(ctx context.Context, filter *models.CatalogFilter) ([]models.Feature, error)
+	GetFeature(ctx context.Context, featureId uint64) (models.Feature, error)
+	UpdateFeature(ctx context.Context, featureId uint64, patch *models.PatchFeature) error
+	DeleteFeature(ctx context.Context, featureId uint64) error
+	CreateTag(ctx context.Context, tag *models.Tag) (models.Tag, error)
+	GetTags(ctx context.Context, filter *models.CatalogFilter) ([]models.Tag, error)
+	GetTag(ctx context.Context, tagId uint64) (models.Tag, error)
+	UpdateTag(ctx context.Context, tagId uint64, patch *models.PatchTag) error
+	DeleteTag(ctx context.Context, tagId uint64) error
+	SetFeatureSchema(ctx context.Context, featureId uint64, schema json.RawMessage) (models.FeatureSchema, error)
+	DeleteFeatureSchema(ctx context.Context, featureId uint64) (models.FeatureSchema, error)
+	GetFeatureSchema(ctx context.Context, featureId, version uint64) (models.FeatureSchema, error)
+	GetFeatureSchemas(ctx context.Context, featureId uint64) ([]models.FeatureSchema, error)
 }
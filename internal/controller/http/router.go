@@ -1,6 +1,7 @@
 package http
 
 import (
+	"banner-service/internal/apierror"
 	"banner-service/internal/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -10,17 +11,67 @@ import (
 
 func (ctr *Controller) NewRouter() http.Handler {
 	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, apierror.New(http.StatusNotFound, apierror.CodeNotFound, "route not found"))
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		apierror.Write(w, r, apierror.New(http.StatusMethodNotAllowed, apierror.CodeMethodNotAllowed, "method not allowed"))
+	})
 	r.Mount("/debug/pprof", http.DefaultServeMux)
 	r.Mount("/metrics", promhttp.Handler())
 	r.With(middleware.MetricsMiddleware).Route("/", func(r chi.Router) {
-		r.Post("/sign-up", ctr.SignUpEndpoint)
-		r.Post("/sign-in", ctr.SignInEndpoint)
+		validate := ctr.RequestValidator.Middleware
+		r.With(validate).Post("/sign-up", ctr.SignUpEndpoint)
+		r.With(validate).Post("/sign-in", ctr.SignInEndpoint)
+		// Assets are loaded by browsers, which send no token.
+		r.With(validate).Get("/assets/{hash}", ctr.GetAssetEndpoint)
 		authMiddleware := middleware.NewAuthMiddleware(ctr.TokenProvider)
-		r.With(authMiddleware.Middleware).Route("/", func(r chi.Router) {
+		r.With(authMiddleware.Middleware, validate).Route("/", func(r chi.Router) {
 			r.Get("/user_banner", ctr.GetBannerEndpoint)
+			r.Get("/user_banner/stream", ctr.StreamBannerEndpoint)
+			r.Get("/user_banner/snapshot", ctr.GetSnapshotEndpoint)
+			r.Get("/audit", ctr.GetAuditEventsEndpoint)
+			r.Post("/assets", ctr.UploadAssetEndpoint)
+			r.Post("/events", ctr.TrackEventsEndpoint)
+			r.Route("/webhooks", func(r chi.Router) {
+				r.Get("/", ctr.GetSubscriptionsEndpoint)
+				r.Post("/", ctr.CreateSubscriptionEndpoint)
+				r.Get("/{subscription_id}", ctr.GetSubscriptionEndpoint)
+				r.Patch("/{subscription_id}", ctr.UpdateSubscriptionEndpoint)
+				r.Delete("/{subscription_id}", ctr.DeleteSubscriptionEndpoint)
+				r.Get("/{subscription_id}/deliveries", ctr.GetDeliveriesEndpoint)
+			})
+			r.Route("/feature", func(r chi.Router) {
+				r.Get("/", ctr.GetFeaturesEndpoint)
+				r.Post("/", ctr.CreateFeatureEndpoint)
+				r.Get("/{feature_id}", ctr.GetFeatureEndpoint)
+				r.Patch("/{feature_id}", ctr.UpdateFeatureEndpoint)
+				r.Delete("/{feature_id}", ctr.DeleteFeatureEndpoint)
+				r.Get("/{feature_id}/schema", ctr.GetFeatureSchemaEndpoint)
+				r.Put("/{feature_id}/schema", ctr.SetFeatureSchemaEndpoint)
+				r.Delete("/{feature_id}/schema", ctr.DeleteFeatureSchemaEndpoint)
+				r.Get("/{feature_id}/schema/versions", ctr.GetFeatureSchemasEndpoint)
+				r.Get("/{feature_id}/schema/report", ctr.GetSchemaReportEndpoint)
+			})
+			r.Route("/tag", func(r chi.Router) {
+				r.Get("/", ctr.GetTagsEndpoint)
+				r.Post("/", ctr.CreateTagEndpoint)
+				r.Get("/{tag_id}", ctr.GetTagEndpoint)
+				r.Patch("/{tag_id}", ctr.UpdateTagEndpoint)
+				r.Delete("/{tag_id}", ctr.DeleteTagEndpoint)
+			})
 			r.Route("/banner", func(r chi.Router) {
 				r.Get("/", ctr.GetFilteredBannersEndpoint)
+				r.Get("/search", ctr.SearchBannersEndpoint)
+				r.Get("/export", ctr.ExportBannersEndpoint)
+				r.Post("/import", ctr.ImportBannersEndpoint)
+				r.Post("/bulk", ctr.BulkUpdateEndpoint)
+				r.Post("/validate", ctr.ValidateContentEndpoint)
 				r.Get("/versions/{banner_id}", ctr.GetListOfVersionsEndpoint)
+				r.Get("/trash", ctr.GetDeletedBannersEndpoint)
+				r.Post("/restore", ctr.RestoreBannersEndpoint)
+				r.Post("/{banner_id}/restore", ctr.RestoreBannerEndpoint)
+				r.Get("/{banner_id}/stats", ctr.GetBannerStatsEndpoint)
 				r.Post("/", ctr.CreateBannerEndpoint)
 				r.Patch("/{banner_id}", ctr.PartialUpdateBannerEndpoint)
 				r.Patch("/{banner_id}/version/{version}", ctr.ChooseBannerVersionEndpoint)
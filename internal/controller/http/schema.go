@@ -0,0 +1,183 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type ValidateContentDTO struct {
+	FeatureId uint64          `json:"feature_id"`
+	Content   json.RawMessage `json:"content"`
+}
+
+func (ctr *Controller) SetFeatureSchemaEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	var raw json.RawMessage
+	if err = json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	featureSchema, err := ctr.CatalogService.SetFeatureSchema(r.Context(), featureId, raw)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	schemaJSON, err := json.Marshal(featureSchema)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(schemaJSON)
+}
+
+func (ctr *Controller) GetFeatureSchemaEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	version, err := parseSchemaVersion(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	featureSchema, err := ctr.CatalogService.GetFeatureSchema(r.Context(), featureId, version)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	schemaJSON, err := json.Marshal(featureSchema)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(schemaJSON)
+}
+
+func (ctr *Controller) DeleteFeatureSchemaEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	if _, err = ctr.CatalogService.DeleteFeatureSchema(r.Context(), featureId); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctr *Controller) GetFeatureSchemasEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	schemas, err := ctr.CatalogService.GetFeatureSchemas(r.Context(), featureId)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	schemasJSON, err := json.Marshal(schemas)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(schemasJSON)
+}
+
+func (ctr *Controller) GetSchemaReportEndpoint(w http.ResponseWriter, r *http.Request) {
+	featureId, err := strconv.ParseUint(chi.URLParam(r, "feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	version, err := parseSchemaVersion(r)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	report, err := ctr.BannerService.GetSchemaReport(r.Context(), featureId, version)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(reportJSON)
+}
+
+// ValidateContentEndpoint is a dry run of the schema check of CreateBanner and
+// PartialUpdateBanner. Violations are reported with 200, not as an error.
+func (ctr *Controller) ValidateContentEndpoint(w http.ResponseWriter, r *http.Request) {
+	var dto ValidateContentDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	validation, err := ctr.BannerService.ValidateContent(r.Context(), dto.FeatureId, dto.Content)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	validationJSON, err := json.Marshal(validation)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(validationJSON)
+}
+
+// parseSchemaVersion reads the optional version parameter; zero stands for the
+// schema in force.
+func parseSchemaVersion(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get("version")
+	if value == "" {
+		return 0, nil
+	}
+	version, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || version == 0 {
+		return 0, apierror.InvalidParameter("version")
+	}
+	return version, nil
+}
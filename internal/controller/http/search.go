@@ -0,0 +1,58 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (ctr *Controller) SearchBannersEndpoint(w http.ResponseWriter, r *http.Request) {
+	search := models.BannerSearch{Query: strings.TrimSpace(r.URL.Query().Get("q"))}
+	if search.Query == "" {
+		apierror.Write(w, r, apierror.InvalidParameter("q"))
+		return
+	}
+
+	history, err := parseOptionalBool(r.URL.Query(), "history")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	search.WithHistory = history != nil && *history
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("limit"))
+			return
+		}
+		search.Limit = limit
+	}
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err := strconv.ParseUint(offsetStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("offset"))
+			return
+		}
+		search.Offset = offset
+	}
+
+	results, err := ctr.BannerService.SearchBanners(r.Context(), &search)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resultsJSON)
+}
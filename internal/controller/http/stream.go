@@ -0,0 +1,155 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/auth"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"banner-service/internal/reqctx"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+func (ctr *Controller) StreamBannerEndpoint(w http.ResponseWriter, r *http.Request) {
+	tagId, err := strconv.ParseUint(r.URL.Query().Get("tag_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
+		return
+	}
+	featureId, err := strconv.ParseUint(r.URL.Query().Get("feature_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("feature_id"))
+		return
+	}
+
+	var lastEventId *uint64
+	lastEventIdStr := r.Header.Get("Last-Event-ID")
+	if lastEventIdStr == "" {
+		lastEventIdStr = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventIdStr != "" {
+		id, err := strconv.ParseUint(lastEventIdStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("Last-Event-ID"))
+			return
+		}
+		lastEventId = &id
+	}
+
+	role := auth.GetRole(r.Context())
+
+	updates, err := ctr.BannerService.WatchBanner(r.Context(), tagId, featureId, role, lastEventId, templateVars(r), acceptedLanguages(r))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	rc := holdOpen(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err = rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	// EventSource reconnects with Last-Event-ID, so a stream ends at once on
+	// shutdown and resumes on another instance.
+	shutdown := reqctx.GetShutdown(r.Context())
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-shutdown:
+			return
+		case <-heartbeat.C:
+			if _, err = fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if err = writeBannerEvent(w, update); err != nil {
+				return
+			}
+		}
+
+		if err = rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// holdOpen lifts the read and write deadlines of the server for a request
+// that waits for updates, or streams a body, longer than they allow.
+func holdOpen(w http.ResponseWriter) *http.ResponseController {
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	return rc
+}
+
+func writeBannerEvent(w http.ResponseWriter, update models.BannerUpdate) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "id: %d\nevent: %s\n", update.EventId, update.Status)
+	for _, line := range strings.Split(update.Content, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+
+	_, err := w.Write([]byte(b.String()))
+	return err
+}
+
+func (ctr *Controller) GetSnapshotEndpoint(w http.ResponseWriter, r *http.Request) {
+	tagId, err := strconv.ParseUint(r.URL.Query().Get("tag_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("tag_id"))
+		return
+	}
+
+	var since *uint64
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		revision, err := strconv.ParseUint(sinceStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("since"))
+			return
+		}
+		since = &revision
+	}
+
+	// The wait is bounded by SNAPSHOT_WAIT rather than the write timeout.
+	if since != nil {
+		holdOpen(w)
+	}
+	snapshot, err := ctr.BannerService.GetSnapshot(r.Context(), tagId, since, templateVars(r))
+	if errors.Is(err, repository.ErrNotModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	} else if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(snapshotJSON)
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"banner-service/internal/reqctx"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+const (
+	NDJSONContentType = "application/x-ndjson"
+
+	exportFlushInterval = 100
+)
+
+func (ctr *Controller) ExportBannersEndpoint(w http.ResponseWriter, r *http.Request) {
+	// A large export takes longer than the write timeout.
+	rc := holdOpen(w)
+	enc := json.NewEncoder(w)
+	var written int
+
+	// The status is sent with the first banner, so that a failure before it
+	// still gets a proper error response.
+	start := func() {
+		w.Header().Set("Content-Type", NDJSONContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="banners.ndjson"`)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	err := ctr.BannerService.ExportBanners(r.Context(), func(banner models.BannerExport) error {
+		if written == 0 {
+			start()
+		}
+		if err := enc.Encode(banner); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushInterval == 0 {
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err != nil && written == 0 {
+		apierror.Write(w, r, err)
+		return
+	} else if err != nil {
+		// The status has been sent already, the client only sees a truncated
+		// export.
+		log.Printf("request %s: export banners after %d lines: %v", reqctx.GetRequestId(r.Context()), written, err)
+		return
+	}
+
+	if written == 0 {
+		start()
+	}
+}
+
+func (ctr *Controller) ImportBannersEndpoint(w http.ResponseWriter, r *http.Request) {
+	opts := models.ImportOptions{Mode: models.ImportMode(r.URL.Query().Get("mode"))}
+	if opts.Mode == "" {
+		opts.Mode = models.ImportCreate
+	}
+
+	dryRun, err := parseOptionalBool(r.URL.Query(), "dry_run")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	opts.DryRun = dryRun != nil && *dryRun
+
+	// A large import takes longer than the read timeout to arrive.
+	holdOpen(w)
+	report, err := ctr.BannerService.ImportBanners(r.Context(), r.Body, opts)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(reportJSON)
+}
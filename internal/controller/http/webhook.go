@@ -0,0 +1,211 @@
+package http
+
+import (
+	"banner-service/internal/apierror"
+	"banner-service/internal/models"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type SubscriptionDTO struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	FeatureId  *uint64  `json:"feature_id"`
+	TagId      *uint64  `json:"tag_id"`
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+func (ctr *Controller) CreateSubscriptionEndpoint(w http.ResponseWriter, r *http.Request) {
+	var dto SubscriptionDTO
+	err := json.NewDecoder(r.Body).Decode(&dto)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	if err = validateWebhookURL(dto.URL); err != nil {
+		apierror.Write(w, r, apierror.BadRequest(err.Error()))
+		return
+	}
+	if err = validateEventTypes(dto.EventTypes); err != nil {
+		apierror.Write(w, r, apierror.BadRequest(err.Error()))
+		return
+	}
+
+	sub, err := ctr.WebhookService.CreateSubscription(r.Context(), &models.WebhookSubscription{
+		URL:        dto.URL,
+		Secret:     dto.Secret,
+		FeatureId:  dto.FeatureId,
+		TagId:      dto.TagId,
+		EventTypes: dto.EventTypes,
+		IsActive:   dto.IsActive == nil || *dto.IsActive,
+	})
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	subJSON, err := json.Marshal(sub)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(subJSON)
+}
+
+func (ctr *Controller) GetSubscriptionsEndpoint(w http.ResponseWriter, r *http.Request) {
+	subs, err := ctr.WebhookService.GetSubscriptions(r.Context())
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	subsJSON, err := json.Marshal(subs)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(subsJSON)
+}
+
+func (ctr *Controller) GetSubscriptionEndpoint(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.ParseUint(chi.URLParam(r, "subscription_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("subscription_id"))
+		return
+	}
+
+	sub, err := ctr.WebhookService.GetSubscription(r.Context(), subscriptionId)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	subJSON, err := json.Marshal(sub)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(subJSON)
+}
+
+func (ctr *Controller) UpdateSubscriptionEndpoint(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.ParseUint(chi.URLParam(r, "subscription_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("subscription_id"))
+		return
+	}
+
+	var patch models.PatchWebhookSubscription
+	err = json.NewDecoder(r.Body).Decode(&patch)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidBody(err))
+		return
+	}
+
+	if patch.URL != nil {
+		if err = validateWebhookURL(*patch.URL); err != nil {
+			apierror.Write(w, r, apierror.BadRequest(err.Error()))
+			return
+		}
+	}
+	if err = validateEventTypes(patch.EventTypes); err != nil {
+		apierror.Write(w, r, apierror.BadRequest(err.Error()))
+		return
+	}
+
+	err = ctr.WebhookService.UpdateSubscription(r.Context(), subscriptionId, &patch)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (ctr *Controller) DeleteSubscriptionEndpoint(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.ParseUint(chi.URLParam(r, "subscription_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("subscription_id"))
+		return
+	}
+
+	if err = ctr.WebhookService.DeleteSubscription(r.Context(), subscriptionId); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ctr *Controller) GetDeliveriesEndpoint(w http.ResponseWriter, r *http.Request) {
+	subscriptionId, err := strconv.ParseUint(chi.URLParam(r, "subscription_id"), 10, 64)
+	if err != nil {
+		apierror.Write(w, r, apierror.InvalidParameter("subscription_id"))
+		return
+	}
+
+	filter := models.DeliveryFilter{SubscriptionId: subscriptionId}
+
+	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
+		status := models.DeliveryStatus(statusStr)
+		filter.Status = &status
+	}
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil {
+			apierror.Write(w, r, apierror.InvalidParameter("limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	deliveries, err := ctr.WebhookService.GetDeliveries(r.Context(), &filter)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	deliveriesJSON, err := json.Marshal(deliveries)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(deliveriesJSON)
+}
+
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url %q", rawURL)
+	}
+	return nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !lo.Contains(models.BannerEventTypes, models.BannerEventType(eventType)) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type webhookService struct {
+	WebhookManagement
+	patch *models.PatchWebhookSubscription
+}
+
+func (s *webhookService) UpdateSubscription(_ context.Context, _ uint64, patch *models.PatchWebhookSubscription) error {
+	s.patch = patch
+	return nil
+}
+
+func TestUpdateSubscriptionFilters(t *testing.T) {
+	featureId := uint64(7)
+
+	tests := []struct {
+		name         string
+		body         string
+		featureId    *uint64
+		hasFeatureId bool
+		hasTagId     bool
+	}{
+		{name: "absent filters are kept", body: `{"is_active": false}`},
+		{name: "null clears the filter", body: `{"feature_id": null}`, hasFeatureId: true},
+		{name: "value sets the filter", body: `{"feature_id": 7, "tag_id": null}`, featureId: &featureId, hasFeatureId: true, hasTagId: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &webhookService{}
+			ctr := newTestController(t)
+			ctr.WebhookService = WebhookService{service}
+
+			req := httptest.NewRequest(http.MethodPatch, "/webhooks/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+adminToken)
+			rec := httptest.NewRecorder()
+
+			ctr.NewRouter().ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			require.NotNil(t, service.patch)
+			assert.Equal(t, tt.featureId, service.patch.FeatureId)
+			assert.Nil(t, service.patch.TagId)
+			assert.Equal(t, tt.hasFeatureId, service.patch.HasFeatureId)
+			assert.Equal(t, tt.hasTagId, service.patch.HasTagId)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode packs a pagination position into an opaque url-safe token.
+func Encode(position any) (string, error) {
+	raw, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func Decode(token string, position any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	if err = json.Unmarshal(raw, position); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
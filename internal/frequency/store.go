@@ -0,0 +1,112 @@
+package frequency
+
+import (
+	"context"
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+const (
+	// sweepInterval is how often MemoryStore forgets keys with no serve left
+	// in their window, so users who do not come back do not hold memory.
+	sweepInterval = time.Minute
+	// shardCount is the number of independently locked parts of MemoryStore.
+	// A serve locks only the shard of its key and a sweep one shard at a time.
+	shardCount = 64
+)
+
+// Store counts banner serves per key within a sliding window.
+type Store interface {
+	// Take counts a serve of key unless limit serves were already counted in
+	// the last window, and reports whether it counted it.
+	Take(ctx context.Context, key string, limit uint64, window time.Duration) (bool, error)
+}
+
+type serves struct {
+	times  []time.Time
+	window time.Duration
+}
+
+type shard struct {
+	mu     sync.Mutex
+	serves map[string]*serves
+}
+
+// MemoryStore keeps the serves of every key in memory, so the counts are per
+// instance and are lost on restart. Start sweeps the keys in the background.
+type MemoryStore struct {
+	seed   maphash.Seed
+	shards [shardCount]shard
+	now    func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	m := &MemoryStore{
+		seed: maphash.MakeSeed(),
+		now:  time.Now,
+	}
+	for i := range m.shards {
+		m.shards[i].serves = make(map[string]*serves)
+	}
+	return m
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit uint64, window time.Duration) (bool, error) {
+	sh := &m.shards[maphash.String(m.seed, key)%shardCount]
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := m.now()
+	s, ok := sh.serves[key]
+	if !ok {
+		s = &serves{}
+		sh.serves[key] = s
+	}
+	s.window = window
+	s.times = inWindow(s.times, now, window)
+	if uint64(len(s.times)) >= limit {
+		return false, nil
+	}
+	s.times = append(s.times, now)
+	return true, nil
+}
+
+// Start sweeps the store every sweepInterval until ctx is done.
+func (m *MemoryStore) Start(ctx context.Context) {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.sweep()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *MemoryStore) sweep() {
+	for i := range m.shards {
+		sh := &m.shards[i]
+		sh.mu.Lock()
+		now := m.now()
+		for key, s := range sh.serves {
+			if len(inWindow(s.times, now, s.window)) == 0 {
+				delete(sh.serves, key)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// inWindow drops the serves older than window; times are in serve order.
+func inWindow(times []time.Time, now time.Time, window time.Duration) []time.Time {
+	start := now.Add(-window)
+	i := 0
+	for i < len(times) && !times[i].After(start) {
+		i++
+	}
+	return times[i:]
+}
@@ -0,0 +1,63 @@
+package frequency
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, 5, 16, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	take := func(key string) bool {
+		t.Helper()
+		ok, err := store.Take(ctx, key, 2, time.Hour)
+		require.NoError(t, err)
+		return ok
+	}
+
+	assert.True(t, take("1/alice"))
+	now = now.Add(30 * time.Minute)
+	assert.True(t, take("1/alice"))
+	assert.False(t, take("1/alice"), "the limit is reached")
+	assert.True(t, take("1/bob"), "users are counted apart")
+	assert.True(t, take("2/alice"), "banners are counted apart")
+
+	now = now.Add(31 * time.Minute)
+	assert.True(t, take("1/alice"), "the first serve left the window")
+	assert.False(t, take("1/alice"))
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := store.Take(ctx, "1/alice", 1, time.Second)
+	require.NoError(t, err)
+	_, err = store.Take(ctx, "1/bob", 1, time.Hour)
+	require.NoError(t, err)
+	now = now.Add(sweepInterval)
+	store.sweep()
+
+	assert.Equal(t, []string{"1/bob"}, store.keys())
+}
+
+// keys returns the keys the store holds, in order.
+func (m *MemoryStore) keys() []string {
+	var keys []string
+	for i := range m.shards {
+		for key := range m.shards[i].serves {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
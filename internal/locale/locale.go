@@ -0,0 +1,100 @@
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// Rank orders the supported locales the client accepts, most preferred
+// first, so content can be served in the first one it has. The header has the
+// Accept-Language syntax ("kk, ru;q=0.8, *;q=0.1"); a range matches a
+// supported locale exactly or by its primary subtag, so "en-US" matches "en".
+// Ranges with q=0 exclude a locale.
+func Rank(header string, supported []string) []string {
+	ranges := parse(header)
+	excluded := make(map[string]bool)
+	for _, r := range ranges {
+		if r.q == 0 {
+			for _, match := range lookup(r.tag, supported) {
+				excluded[match] = true
+			}
+		}
+	}
+
+	ranked := make([]string, 0)
+	add := func(locales []string) {
+		for _, l := range locales {
+			if !excluded[l] {
+				excluded[l] = true
+				ranked = append(ranked, l)
+			}
+		}
+	}
+	for _, r := range ranges {
+		if r.q == 0 {
+			continue
+		}
+		if r.tag == "*" {
+			add(supported)
+			continue
+		}
+		add(lookup(r.tag, supported))
+	}
+	return ranked
+}
+
+// Normalize lowercases a locale; locales are compared case-insensitively.
+func Normalize(locale string) string {
+	return strings.ToLower(strings.TrimSpace(locale))
+}
+
+// parse reads the ranges of the header ordered by q, most preferred first.
+// Ranges with an invalid q are ignored.
+func parse(header string) []languageRange {
+	ranges := make([]languageRange, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = Normalize(tag)
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			var err error
+			if q, err = strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, languageRange{tag: tag, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+	return ranges
+}
+
+// lookup returns the supported locales the range matches: the exact one
+// first, then those with the same primary subtag.
+func lookup(tag string, supported []string) []string {
+	var matches []string
+	for _, s := range supported {
+		if s == tag {
+			matches = append(matches, s)
+		}
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	for _, s := range supported {
+		if sPrimary, _, _ := strings.Cut(s, "-"); sPrimary == primary && s != tag {
+			matches = append(matches, s)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,61 @@
+package locale
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// TestRankMostPreferred checks the locale ranked first, the one content is
+// served in when the banner has it.
+func TestRankMostPreferred(t *testing.T) {
+	supported := []string{"ru", "en", "kk"}
+
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "empty", header: "", expected: ""},
+		{name: "single", header: "kk", expected: "kk"},
+		{name: "q-values", header: "en;q=0.5, kk;q=0.9, ru;q=0.1", expected: "kk"},
+		{name: "order of equal q", header: "en, ru", expected: "en"},
+		{name: "region falls back to the language", header: "en-US,fr;q=0.9", expected: "en"},
+		{name: "case-insensitive", header: "EN-gb", expected: "en"},
+		{name: "unsupported only", header: "fr, de;q=0.5", expected: ""},
+		{name: "wildcard", header: "fr, *;q=0.1", expected: "ru"},
+		{name: "wildcard without excluded", header: "ru;q=0, *", expected: "en"},
+		{name: "invalid q is ignored", header: "kk;q=2, en;q=0.3", expected: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first string
+			if ranked := Rank(tt.header, supported); len(ranked) > 0 {
+				first = ranked[0]
+			}
+			assert.Equal(t, tt.expected, first)
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	supported := []string{"ru", "en", "kk", "en-gb"}
+
+	tests := []struct {
+		name     string
+		header   string
+		expected []string
+	}{
+		{name: "empty", header: "", expected: []string{}},
+		{name: "every accepted locale", header: "kk, ru;q=0.5", expected: []string{"kk", "ru"}},
+		{name: "exact match first", header: "en-GB", expected: []string{"en-gb", "en"}},
+		{name: "no duplicates", header: "en, en-gb;q=0.9, *;q=0.1", expected: []string{"en", "en-gb", "ru", "kk"}},
+		{name: "excluded", header: "ru;q=0, *", expected: []string{"en", "kk", "en-gb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Rank(tt.header, supported))
+		})
+	}
+}
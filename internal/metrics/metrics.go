@@ -23,4 +23,9 @@ var (
 		Help:    "Latency of HTTP requests in seconds",
 		Buckets: prometheus.ExponentialBuckets(0.001, 2, 17),
 	}, []string{"method"})
+
+	BannerEventsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "banner_events_dropped_total",
+		Help: "The total number of banner events lost because the buffer was full, the write failed or the banner version was unknown",
+	})
 )
@@ -1,12 +1,10 @@
 package middleware
 
 import (
+	"banner-service/internal/apierror"
 	"banner-service/internal/auth"
-	"banner-service/internal/models"
+	"banner-service/internal/reqctx"
 	"fmt"
-	"github.com/jellydator/ttlcache/v3"
-	"github.com/samber/lo"
-	"log"
 	"net/http"
 	"strings"
 )
@@ -24,39 +22,28 @@ func (am *AuthMiddleware) Middleware(next http.Handler) http.Handler {
 		authHeader := r.Header.Get("Authorization")
 		authFields := strings.Fields(authHeader)
 
-		if len(authFields) == 0 || authFields[0] != "Bearer" {
-			w.WriteHeader(http.StatusUnauthorized)
+		if len(authFields) != 2 || authFields[0] != "Bearer" {
+			apierror.Write(w, r, apierror.Unauthorized())
 			return
 		}
 
 		token := authFields[1]
 
-		cachedResources := am.TokenProvider.Cache.Get(token)
-
-		if cachedResources == nil {
-			validateResources, err := am.ValidateToken(token, am.PublicKey)
-			if err != nil {
-				w.WriteHeader(http.StatusUnauthorized)
-				return
-			}
-			log.Println("set cache[token]model.UserResources")
-			cachedResources = am.TokenProvider.Cache.Set(token, validateResources, ttlcache.DefaultTTL)
-		}
-
-		resources := cachedResources.Value()
-
-		ctx := auth.SetRole(r.Context(), resources.Role)
-		if resources.Role == models.Admin {
-			next.ServeHTTP(w, r.WithContext(ctx))
+		resources, err := am.Authenticate(token)
+		if err != nil {
+			apierror.Write(w, r, apierror.Unauthorized().WithCause(err))
 			return
 		}
 
-		if lo.Contains(resources.Resources, buildResource(r)) {
+		ctx := auth.SetRole(r.Context(), resources.Role)
+		ctx = reqctx.SetUsername(ctx, resources.Username)
+		ctx = reqctx.SetClaims(ctx, resources.Claims)
+		if auth.HasAccess(resources, buildResource(r)) {
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
-		w.WriteHeader(http.StatusForbidden)
+		apierror.Write(w, r, apierror.Forbidden())
 	})
 }
 
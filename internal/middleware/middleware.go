@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"banner-service/internal/apierror"
 	"banner-service/internal/metrics"
+	"fmt"
 	"github.com/fatih/color"
 	"github.com/prometheus/client_golang/prometheus"
 	"log"
@@ -20,6 +22,10 @@ func (m *MetricsResponseWriter) WriteHeader(code int) {
 	m.ResponseWriter.WriteHeader(code)
 }
 
+func (m *MetricsResponseWriter) Unwrap() http.ResponseWriter {
+	return m.ResponseWriter
+}
+
 func (m *MetricsResponseWriter) Status() int {
 	if m.statusCode == 0 {
 		return http.StatusOK
@@ -56,7 +62,7 @@ func PanicRecovery(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				apierror.Write(w, req, apierror.Internal(fmt.Errorf("panic: %v", err)))
 				log.Println(string(debug.Stack()))
 			}
 		}()
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"banner-service/internal/reqctx"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+func RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(RequestIdHeader)
+		if requestId == "" {
+			requestId = newRequestId()
+		}
+
+		w.Header().Set(RequestIdHeader, requestId)
+		next.ServeHTTP(w, r.WithContext(reqctx.SetRequestId(r.Context(), requestId)))
+	})
+}
+
+func newRequestId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
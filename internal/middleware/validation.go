@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"banner-service/internal/apierror"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+func init() {
+	// kin-openapi knows application/json-patch+json but not merge patches.
+	openapi3filter.RegisterBodyDecoder("application/merge-patch+json", openapi3filter.JSONBodyDecoder)
+}
+
+// RequestValidator checks requests against the OpenAPI description of the
+// API before they reach the handlers.
+type RequestValidator struct {
+	router routers.Router
+}
+
+func NewRequestValidator(spec []byte) (*RequestValidator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("load openapi spec: %w", err)
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validate openapi spec: %w", err)
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build openapi router: %w", err)
+	}
+
+	return &RequestValidator{router: router}, nil
+}
+
+// Middleware rejects requests that violate the schema of their operation.
+// Requests to undocumented routes are passed through.
+func (rv *RequestValidator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := rv.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError: true,
+				// Tokens are checked by AuthMiddleware.
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				// Streamed bodies would have to be buffered here; their
+				// handlers validate them record by record, or part by part,
+				// instead.
+				ExcludeRequestBody: isStreamed(r),
+			},
+		})
+		if err != nil {
+			apierror.Write(w, r, apierror.Validation(fieldErrors(err)).WithCause(err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isStreamed(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/x-ndjson" || mediaType == "multipart/form-data")
+}
+
+func fieldErrors(err error) []apierror.FieldError {
+	if multiErr, ok := err.(openapi3.MultiError); ok {
+		details := make([]apierror.FieldError, 0, len(multiErr))
+		for _, e := range multiErr {
+			details = append(details, fieldErrors(e)...)
+		}
+		return details
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return []apierror.FieldError{{Message: err.Error()}}
+	}
+
+	field := "body"
+	if reqErr.Parameter != nil {
+		field = reqErr.Parameter.Name
+	}
+
+	var multiErr openapi3.MultiError
+	if errors.As(reqErr.Err, &multiErr) {
+		details := make([]apierror.FieldError, 0, len(multiErr))
+		for _, e := range multiErr {
+			details = append(details, schemaFieldError(field, e))
+		}
+		return details
+	}
+	if reqErr.Err != nil {
+		return []apierror.FieldError{schemaFieldError(field, reqErr.Err)}
+	}
+	return []apierror.FieldError{{Field: field, Message: reqErr.Reason}}
+}
+
+func schemaFieldError(field string, err error) apierror.FieldError {
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		var parseErr *openapi3filter.ParseError
+		if errors.As(err, &parseErr) {
+			return apierror.FieldError{Field: field, Message: parseErr.Reason}
+		}
+		return apierror.FieldError{Field: field, Message: err.Error()}
+	}
+
+	if pointer := schemaErr.JSONPointer(); field == "body" && len(pointer) > 0 {
+		field = "/" + strings.Join(pointer, "/")
+	}
+	return apierror.FieldError{Field: field, Message: schemaErr.Reason}
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Asset is an uploaded binary file, addressed by the SHA-256 of its content.
+// Banner content refers to it by the path /assets/{hash}, on its own or in a
+// URL without a host.
+type Asset struct {
+	Hash      string    `db:"hash" json:"hash"`
+	MimeType  string    `db:"mime_type" json:"mime_type"`
+	Size      int64     `db:"size" json:"size"`
+	URL       string    `db:"-" json:"url"`
+	CreatedBy string    `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
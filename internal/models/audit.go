@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreate        AuditAction = "create"
+	AuditUpdate        AuditAction = "update"
+	AuditChooseVersion AuditAction = "choose_version"
+	AuditDelete        AuditAction = "delete"
+	AuditMarkDeleted   AuditAction = "mark_deleted"
+	AuditRestore       AuditAction = "restore"
+)
+
+type AuditEvent struct {
+	EventId   uint64          `db:"event_id" json:"event_id"`
+	Actor     string          `db:"actor" json:"actor"`
+	Action    AuditAction     `db:"action" json:"action"`
+	BannerId  uint64          `db:"banner_id" json:"banner_id"`
+	Before    json.RawMessage `db:"before" json:"before"`
+	After     json.RawMessage `db:"after" json:"after"`
+	RequestId string          `db:"request_id" json:"request_id"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+type AuditFilter struct {
+	Actor    *string
+	BannerId *uint64
+	Action   *AuditAction
+	From     *time.Time
+	To       *time.Time
+	Cursor   string
+	Limit    uint64
+}
+
+type AuditPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
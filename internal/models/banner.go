@@ -10,24 +10,109 @@ type Banner struct {
 	FeatureId uint64          `db:"feature_id" json:"feature_id"`
 	TagIds    []uint64        `db:"tag_ids" json:"tag_ids"`
 	Content   json.RawMessage `db:"content" json:"content"`
-	IsActive  bool            `db:"is_active" json:"is_active"`
-	Version   uint64          `db:"version" json:"version"`
-	CreatedAt time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt time.Time       `db:"updated_at" json:"updated_at"`
+	// Localized holds the content per locale; Content is the default, in
+	// DefaultLocale if it is set.
+	Localized     map[string]json.RawMessage `db:"localized" json:"localized,omitempty"`
+	DefaultLocale string                     `db:"default_locale" json:"default_locale,omitempty"`
+	IsActive      bool                       `db:"is_active" json:"is_active"`
+	// Priority decides between banners matched by different tags of a user;
+	// the higher one wins.
+	Priority     int           `db:"priority" json:"priority"`
+	FrequencyCap *FrequencyCap `db:"frequency_cap" json:"frequency_cap,omitempty"`
+	Version      uint64        `db:"version" json:"version"`
+	CreatedAt    time.Time     `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time     `db:"updated_at" json:"updated_at"`
+	// Feature and Tags are set when the list is expanded; ids that are not in
+	// the catalog are left out.
+	Feature *CatalogRef  `db:"-" json:"feature,omitempty"`
+	Tags    []CatalogRef `db:"-" json:"tags,omitempty"`
 }
 
+// FrequencyCap limits how many times a banner is served to the same user
+// within a sliding window.
+type FrequencyCap struct {
+	Limit         uint64 `json:"limit"`
+	WindowSeconds uint64 `json:"window_seconds"`
+}
+
+func (c FrequencyCap) Window() time.Duration {
+	return time.Duration(c.WindowSeconds) * time.Second
+}
+
+type DeletedBanner struct {
+	Banner
+	DeletedAt time.Time `db:"deleted_at" json:"deleted_at"`
+	PurgeAt   time.Time `db:"-" json:"purge_at"`
+}
+
+// PatchBanner changes a banner; Localized, if set, replaces all localized
+// content of the new version. ContentPatch, if set, is applied to the active
+// content instead of replacing it with Content. A FrequencyCap with a zero
+// limit removes the cap.
 type PatchBanner struct {
-	FeatureId *uint64         `db:"feature_id" json:"feature_id"`
-	TagIds    []uint64        `db:"tag_ids" json:"tag_ids"`
-	Content   json.RawMessage `db:"content" json:"content"`
-	IsActive  *bool           `db:"is_active" json:"is_active"`
+	FeatureId     *uint64                    `db:"feature_id" json:"feature_id"`
+	TagIds        []uint64                   `db:"tag_ids" json:"tag_ids"`
+	Content       json.RawMessage            `db:"content" json:"content"`
+	Localized     map[string]json.RawMessage `db:"localized" json:"localized"`
+	DefaultLocale *string                    `db:"default_locale" json:"default_locale"`
+	IsActive      *bool                      `db:"is_active" json:"is_active"`
+	Priority      *int                       `db:"priority" json:"priority"`
+	FrequencyCap  *FrequencyCap              `db:"frequency_cap" json:"frequency_cap"`
+	ContentPatch  *ContentPatch              `db:"-" json:"-"`
 }
 
+// ContentPatch is a JSON Merge Patch or JSON Patch document of banner content.
+type ContentPatch struct {
+	MediaType string
+	Document  json.RawMessage
+}
+
+type BannerSortField string
+
+const (
+	SortByBannerId  BannerSortField = "banner_id"
+	SortByCreatedAt BannerSortField = "created_at"
+	SortByUpdatedAt BannerSortField = "updated_at"
+)
+
+type SortOrder string
+
+const (
+	OrderAsc  SortOrder = "asc"
+	OrderDesc SortOrder = "desc"
+)
+
+// FilterBanner selects banners for the admin list. All set conditions must
+// hold: the banner belongs to one of FeatureIds and carries every tag of TagIds.
+// Time ranges include From and exclude To.
 type FilterBanner struct {
-	FeatureId uint64 `db:"feature_id" json:"featureId"`
-	TagId     uint64 `db:"tag_id" json:"tag_id"`
-	Limit     uint64
-	Offset    uint64
+	FeatureIds  []uint64
+	TagIds      []uint64
+	IsActive    *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	// HasDraft selects banners that do (or do not) have a version newer than
+	// the active one.
+	HasDraft *bool
+	// Content must be contained in the active content (jsonb @>).
+	Content json.RawMessage
+	// ContentPath is a JSONPath that must match the active content (jsonb @?).
+	ContentPath string
+	Limit       uint64
+	Offset      uint64
+	SortBy      BannerSortField
+	Order       SortOrder
+	Cursor      string
+	WithTotal   bool
+	Expand      []BannerExpansion
+}
+
+type BannerPage struct {
+	Banners    []Banner `json:"banners"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Total      *uint64  `json:"total,omitempty"`
 }
 
 type FeatureTag struct {
@@ -35,7 +120,64 @@ type FeatureTag struct {
 	TagId     uint64 `db:"tag_id" json:"tag_id"`
 }
 
+type TagSnapshot struct {
+	TagId    uint64                     `json:"tag_id"`
+	Revision uint64                     `json:"revision"`
+	Banners  map[uint64]json.RawMessage `json:"banners"`
+}
+
+type FeatureContent struct {
+	FeatureId uint64          `db:"feature_id"`
+	Content   json.RawMessage `db:"content"`
+}
+
+// BannerContent is the banner served for a feature and tag. TagId is the tag
+// the banner was found by: the requested one or its nearest ancestor that has
+// a banner, or zero for the default banner of the feature. Depth counts the
+// steps from the requested tag to TagId. Locale is the language of Content,
+// empty if it is unknown.
 type BannerContent struct {
-	Content  string `db:"content"`
-	IsActive bool   `db:"is_active"`
+	Content      string        `db:"content"`
+	Locale       string        `db:"locale"`
+	IsActive     bool          `db:"is_active"`
+	TagId        uint64        `db:"tag_id"`
+	BannerId     uint64        `db:"banner_id"`
+	Priority     int           `db:"priority"`
+	FrequencyCap *FrequencyCap `db:"frequency_cap"`
+	Depth        uint64        `db:"depth"`
 }
+
+// BannerCacheKey addresses a served banner: the requested feature and tag and
+// the locales the request accepts, comma separated in order of preference.
+type BannerCacheKey struct {
+	FeatureId uint64
+	TagId     uint64
+	Locale    string
+}
+
+// BannerSearch is a full-text query over the string values of banner content.
+type BannerSearch struct {
+	Query string
+	// WithHistory searches inactive versions as well as the active ones.
+	WithHistory bool
+	Limit       uint64
+	Offset      uint64
+}
+
+type BannerSearchResult struct {
+	Banner
+	// IsCurrent reports whether the matched version is the active one.
+	IsCurrent bool    `db:"is_current" json:"is_current"`
+	Rank      float64 `db:"rank" json:"rank"`
+	// Highlight is the matched content with the string values escaped as HTML
+	// and the found words wrapped in <mark>.
+	Highlight json.RawMessage `db:"highlight" json:"highlight"`
+}
+
+// HighlightStart and HighlightStop enclose the found words in the highlight
+// of a search result until it is escaped. They are private use characters,
+// which banner content has no reason to contain.
+const (
+	HighlightStart = "\uE000"
+	HighlightStop  = "\uE001"
+)
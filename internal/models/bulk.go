@@ -0,0 +1,63 @@
+package models
+
+type BulkAction string
+
+const (
+	BulkActivate   BulkAction = "activate"
+	BulkDeactivate BulkAction = "deactivate"
+	BulkSetVersion BulkAction = "set-version"
+	BulkRetag      BulkAction = "retag"
+	// BulkDelete marks banners as deleted, like DELETE /banner.
+	BulkDelete BulkAction = "delete"
+)
+
+var BulkActions = []BulkAction{BulkActivate, BulkDeactivate, BulkSetVersion, BulkRetag, BulkDelete}
+
+// BulkOperation applies an action to the banners listed in BannerIds, or to
+// those matching Filter when no ids are given.
+type BulkOperation struct {
+	Action    BulkAction
+	BannerIds []uint64
+	Filter    *FilterBanner
+	// Version is the version to activate for BulkSetVersion.
+	Version uint64
+	// TagIds replace the tags for BulkRetag; FeatureId, if set, the feature.
+	TagIds    []uint64
+	FeatureId *uint64
+}
+
+type BulkRequest struct {
+	Operations []BulkOperation
+	// Atomic rolls every change back when any item fails.
+	Atomic bool
+	// CheckContent, if set, checks the content of a banner a retag moves to
+	// another feature. Content that does not conform fails the item, other
+	// errors the request.
+	CheckContent ContentCheck
+}
+
+type BulkStatus string
+
+const (
+	BulkApplied    BulkStatus = "applied"
+	BulkUnchanged  BulkStatus = "unchanged"
+	BulkFailed     BulkStatus = "failed"
+	BulkRolledBack BulkStatus = "rolled_back"
+)
+
+type BulkItemResult struct {
+	// Operation is the index of the operation in the request.
+	Operation int        `json:"operation"`
+	BannerId  uint64     `json:"banner_id"`
+	Status    BulkStatus `json:"status"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type BulkReport struct {
+	Atomic bool `json:"atomic"`
+	// Committed is false when an atomic request was rolled back.
+	Committed bool             `json:"committed"`
+	Applied   uint64           `json:"applied"`
+	Failed    uint64           `json:"failed"`
+	Items     []BulkItemResult `json:"items"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// CatalogEntry describes a feature or a tag. Archived entries stay attached to
+// their banners but cannot be referenced by new ones.
+type CatalogEntry struct {
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description"`
+	Owner       string    `db:"owner" json:"owner"`
+	Archived    bool      `db:"archived" json:"archived"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+type Feature struct {
+	FeatureId uint64 `db:"feature_id" json:"feature_id"`
+	// DefaultBannerId is served to users when no banner of the feature matches
+	// the tag or any of its ancestors.
+	DefaultBannerId *uint64 `db:"default_banner_id" json:"default_banner_id"`
+	CatalogEntry
+}
+
+type Tag struct {
+	TagId       uint64  `db:"tag_id" json:"tag_id"`
+	ParentTagId *uint64 `db:"parent_tag_id" json:"parent_tag_id"`
+	CatalogEntry
+}
+
+type PatchCatalogEntry struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Owner       *string `json:"owner"`
+	Archived    *bool   `json:"archived"`
+}
+
+// PatchFeature changes a feature; a zero DefaultBannerId removes the default
+// banner.
+type PatchFeature struct {
+	PatchCatalogEntry
+	DefaultBannerId *uint64 `json:"default_banner_id"`
+}
+
+// PatchTag changes a tag; a zero ParentTagId makes it a root tag.
+type PatchTag struct {
+	PatchCatalogEntry
+	ParentTagId *uint64 `json:"parent_tag_id"`
+}
+
+// CatalogFilter selects features or tags; Ids, if set, limits the result to
+// these ids.
+type CatalogFilter struct {
+	Ids      []uint64
+	Archived *bool
+	Limit    uint64
+	Offset   uint64
+}
+
+// CatalogRef is a feature or tag id expanded with its name in banner lists.
+type CatalogRef struct {
+	Id       uint64 `json:"id"`
+	Name     string `json:"name"`
+	Archived bool   `json:"archived"`
+}
+
+type BannerExpansion string
+
+const (
+	ExpandFeature BannerExpansion = "feature"
+	ExpandTags    BannerExpansion = "tags"
+)
+
+var BannerExpansions = []BannerExpansion{ExpandFeature, ExpandTags}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+type EventKind string
+
+const (
+	EventImpression EventKind = "impression"
+	EventClick      EventKind = "click"
+)
+
+// BannerEvent is an impression or a click a client reports for the banner
+// version it was served. OccurredAt is set on receipt.
+type BannerEvent struct {
+	Kind       EventKind `db:"kind" json:"kind"`
+	BannerId   uint64    `db:"banner_id" json:"banner_id"`
+	Version    uint64    `db:"version" json:"version"`
+	TagId      uint64    `db:"tag_id" json:"tag_id"`
+	FeatureId  uint64    `db:"feature_id" json:"feature_id"`
+	OccurredAt time.Time `db:"occurred_at" json:"-"`
+}
+
+// EventReceipt tells how many events were queued; the rest were dropped
+// because the buffer was full.
+type EventReceipt struct {
+	Accepted int `json:"accepted"`
+	Dropped  int `json:"dropped"`
+}
+
+// StatsFilter limits stats to events in [From, To).
+type StatsFilter struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// EventCounts is the number of impressions and clicks of a group of events;
+// CTR is clicks per impression, zero without impressions.
+type EventCounts struct {
+	Impressions uint64  `db:"impressions" json:"impressions"`
+	Clicks      uint64  `db:"clicks" json:"clicks"`
+	CTR         float64 `db:"-" json:"ctr"`
+}
+
+type VersionStats struct {
+	Version uint64 `db:"version" json:"version"`
+	EventCounts
+}
+
+type DayStats struct {
+	Day string `db:"day" json:"day"`
+	EventCounts
+}
+
+type BannerStats struct {
+	BannerId uint64         `json:"banner_id"`
+	Total    EventCounts    `json:"total"`
+	Versions []VersionStats `json:"versions"`
+	Days     []DayStats     `json:"days"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type BannerEventType string
+
+const (
+	BannerCreated         BannerEventType = "banner.created"
+	BannerUpdated         BannerEventType = "banner.updated"
+	BannerVersionSwitched BannerEventType = "banner.version_switched"
+	BannerActivated       BannerEventType = "banner.activated"
+	BannerDeactivated     BannerEventType = "banner.deactivated"
+	BannerDeleted         BannerEventType = "banner.deleted"
+	BannerRestored        BannerEventType = "banner.restored"
+)
+
+var BannerEventTypes = []BannerEventType{
+	BannerCreated,
+	BannerUpdated,
+	BannerVersionSwitched,
+	BannerActivated,
+	BannerDeactivated,
+	BannerDeleted,
+	BannerRestored,
+}
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxDelivered OutboxStatus = "delivered"
+	OutboxDead      OutboxStatus = "dead"
+)
+
+type BannerEventPayload struct {
+	Before *Banner `json:"before"`
+	After  *Banner `json:"after"`
+}
+
+type OutboxEvent struct {
+	EventId   uint64          `db:"event_id" json:"event_id"`
+	BannerId  uint64          `db:"banner_id" json:"banner_id"`
+	Type      BannerEventType `db:"event_type" json:"type"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	Attempts  uint64          `db:"attempts" json:"-"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+const BannerEventsChannel = "banner_events"
+
+// BannerNotification tells subscribers which feature and tag pairs a
+// committed banner change touched. The NOTIFY on BannerEventsChannel carries
+// only the event id, since a payload must fit in 8000 bytes; listeners load
+// the rest from the outbox.
+type BannerNotification struct {
+	EventId    uint64   `db:"event_id" json:"event_id"`
+	BannerId   uint64   `db:"banner_id" json:"banner_id"`
+	FeatureIds []uint64 `db:"feature_ids" json:"feature_ids"`
+	TagIds     []uint64 `db:"tag_ids" json:"tag_ids"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FeatureSchema is a version of the JSON Schema banner content of a feature
+// must conform to. Versions are never changed; the latest one is in force, and
+// a version without a schema turns validation off.
+type FeatureSchema struct {
+	FeatureId uint64          `db:"feature_id" json:"feature_id"`
+	Version   uint64          `db:"version" json:"version"`
+	Schema    json.RawMessage `db:"schema" json:"schema"`
+	CreatedBy string          `db:"created_by" json:"created_by"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+// SchemaVersions are, by feature id, the versions of the feature schemas
+// content was checked against; zero stands for a feature without a schema. A
+// write is rejected if a newer version was added since.
+type SchemaVersions map[uint64]uint64
+
+// ContentCheck checks the default and localized content of a banner against
+// the schema of its feature in force, which the repository reads in the
+// transaction of the change; the schema is zero for a feature without one.
+type ContentCheck func(featureSchema FeatureSchema, content json.RawMessage, localized map[string]json.RawMessage) error
+
+// SchemaViolation is a single failed schema check: a JSON Pointer into the
+// content and what is wrong with the value there.
+type SchemaViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ContentValidation is the outcome of a dry run. SchemaVersion is zero when the
+// feature has no schema.
+type ContentValidation struct {
+	Valid         bool              `json:"valid"`
+	SchemaVersion uint64            `json:"schema_version"`
+	Violations    []SchemaViolation `json:"violations"`
+}
+
+// SchemaReport lists the banners of a feature whose active content does not
+// conform to a schema version.
+type SchemaReport struct {
+	FeatureId     uint64                `json:"feature_id"`
+	SchemaVersion uint64                `json:"schema_version"`
+	Checked       uint64                `json:"checked"`
+	Banners       []NonConformingBanner `json:"banners"`
+}
+
+// NonConformingBanner reports the default content of a banner, or its content
+// in Locale.
+type NonConformingBanner struct {
+	BannerId   uint64            `json:"banner_id"`
+	Version    uint64            `json:"version"`
+	Locale     string            `json:"locale,omitempty"`
+	Violations []SchemaViolation `json:"violations"`
+}
@@ -0,0 +1,15 @@
+package models
+
+type BannerUpdateStatus string
+
+const (
+	BannerAvailable BannerUpdateStatus = "banner"
+	BannerInactive  BannerUpdateStatus = "inactive"
+	BannerNotFound  BannerUpdateStatus = "not_found"
+)
+
+type BannerUpdate struct {
+	EventId uint64
+	Status  BannerUpdateStatus
+	Content string
+}
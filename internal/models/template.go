@@ -0,0 +1,10 @@
+package models
+
+// TemplateVars are the values banner templates are rendered with: User holds
+// the claims of the token, Param the query parameters of the request. Strict
+// asks for strict rendering even if the service does not enforce it.
+type TemplateVars struct {
+	User   map[string]string
+	Param  map[string]string
+	Strict bool
+}
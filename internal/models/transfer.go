@@ -0,0 +1,92 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BannerExport is a line of the NDJSON banner export: the banner with its
+// full version history. The import accepts the same format.
+type BannerExport struct {
+	BannerId      uint64          `json:"banner_id"`
+	FeatureId     uint64          `json:"feature_id"`
+	TagIds        []uint64        `json:"tag_ids"`
+	IsActive      bool            `json:"is_active"`
+	Priority      int             `json:"priority"`
+	FrequencyCap  *FrequencyCap   `json:"frequency_cap,omitempty"`
+	ActiveVersion uint64          `json:"active_version"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Versions      []BannerVersion `json:"versions"`
+}
+
+// Active returns the active version of the banner.
+func (b BannerExport) Active() BannerVersion {
+	for _, version := range b.Versions {
+		if version.Version == b.ActiveVersion {
+			return version
+		}
+	}
+	return BannerVersion{}
+}
+
+type BannerVersion struct {
+	Version       uint64                     `json:"version"`
+	Content       json.RawMessage            `json:"content"`
+	Localized     map[string]json.RawMessage `json:"localized,omitempty"`
+	DefaultLocale string                     `json:"default_locale,omitempty"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+}
+
+type ImportMode string
+
+const (
+	// ImportCreate creates a new banner from every line, ignoring banner_id.
+	ImportCreate ImportMode = "create"
+	// ImportUpsert replaces the banner with the banner_id of the line, or
+	// creates it under that id when it does not exist.
+	ImportUpsert ImportMode = "upsert"
+)
+
+type ImportOptions struct {
+	Mode ImportMode
+	// DryRun applies every chunk and rolls it back.
+	DryRun bool
+}
+
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportUpdated ImportStatus = "updated"
+	ImportInvalid ImportStatus = "invalid"
+	ImportFailed  ImportStatus = "failed"
+)
+
+type ImportLineResult struct {
+	Line     uint64       `json:"line"`
+	Status   ImportStatus `json:"status"`
+	BannerId uint64       `json:"banner_id,omitempty"`
+	Error    string       `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Mode    ImportMode         `json:"mode"`
+	DryRun  bool               `json:"dry_run"`
+	Created uint64             `json:"created"`
+	Updated uint64             `json:"updated"`
+	Failed  uint64             `json:"failed"`
+	Lines   []ImportLineResult `json:"lines"`
+}
+
+// Add records the result of a line.
+func (r *ImportReport) Add(result ImportLineResult) {
+	switch result.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	default:
+		r.Failed++
+	}
+	r.Lines = append(r.Lines, result)
+}
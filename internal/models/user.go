@@ -19,6 +19,9 @@ type Credentials struct {
 }
 
 type UserResources struct {
+	Username  string   `db:"-" json:"-"`
 	Role      UserRole `db:"role" json:"role"`
 	Resources []string `db:"resources" json:"resources"`
+	// Claims are the scalar claims of the token, available to banner templates.
+	Claims map[string]string `db:"-" json:"-"`
 }
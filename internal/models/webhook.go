@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebhookSubscription struct {
+	SubscriptionId uint64    `db:"subscription_id" json:"subscription_id"`
+	URL            string    `db:"url" json:"url"`
+	Secret         string    `db:"secret" json:"secret,omitempty"`
+	FeatureId      *uint64   `db:"feature_id" json:"feature_id"`
+	TagId          *uint64   `db:"tag_id" json:"tag_id"`
+	EventTypes     []string  `db:"event_types" json:"event_types"`
+	IsActive       bool      `db:"is_active" json:"is_active"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type PatchWebhookSubscription struct {
+	URL        *string  `json:"url"`
+	FeatureId  *uint64  `json:"feature_id"`
+	TagId      *uint64  `json:"tag_id"`
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active"`
+	// HasFeatureId and HasTagId report whether the patch sets the filter, so
+	// that a null feature_id or tag_id clears it.
+	HasFeatureId bool `json:"-"`
+	HasTagId     bool `json:"-"`
+}
+
+func (p *PatchWebhookSubscription) UnmarshalJSON(data []byte) error {
+	type plain PatchWebhookSubscription
+	if err := json.Unmarshal(data, (*plain)(p)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	_, p.HasFeatureId = fields["feature_id"]
+	_, p.HasTagId = fields["tag_id"]
+	return nil
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+type WebhookDelivery struct {
+	DeliveryId     uint64          `db:"delivery_id" json:"delivery_id"`
+	SubscriptionId uint64          `db:"subscription_id" json:"subscription_id"`
+	EventId        uint64          `db:"event_id" json:"event_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         DeliveryStatus  `db:"status" json:"status"`
+	Attempts       uint64          `db:"attempts" json:"attempts"`
+	ResponseStatus *int            `db:"response_status" json:"response_status"`
+	LastError      string          `db:"last_error" json:"last_error"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at"`
+}
+
+type PendingDelivery struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+type DeliveryFilter struct {
+	SubscriptionId uint64
+	Status         *DeliveryStatus
+	Limit          uint64
+}
@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"banner-service/internal/models"
+	"sync"
+)
+
+type (
+	tagKey     uint64
+	featureKey uint64
+)
+
+type subscriber struct {
+	events chan uint64
+}
+
+// Hub fans banner notifications out to in-process subscribers of a feature or
+// of a tag. A banner of the feature can change what any tag of the feature
+// resolves to, through the tag hierarchy or the default banner, so feature
+// subscribers get every event of the feature and filter by re-reading.
+// Delivery is lossy: a slow subscriber only ever sees the latest event id,
+// which is enough because subscribers re-read state.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[any]map[*subscriber]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[any]map[*subscriber]struct{}),
+	}
+}
+
+func (h *Hub) SubscribeFeature(featureId uint64) (<-chan uint64, func()) {
+	return h.subscribe(featureKey(featureId))
+}
+
+func (h *Hub) SubscribeTag(tagId uint64) (<-chan uint64, func()) {
+	return h.subscribe(tagKey(tagId))
+}
+
+func (h *Hub) subscribe(key any) (<-chan uint64, func()) {
+	sub := &subscriber{events: make(chan uint64, 1)}
+
+	h.mu.Lock()
+	if h.subscribers[key] == nil {
+		h.subscribers[key] = make(map[*subscriber]struct{})
+	}
+	h.subscribers[key][sub] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers[key], sub)
+			if len(h.subscribers[key]) == 0 {
+				delete(h.subscribers, key)
+			}
+		})
+	}
+
+	return sub.events, unsubscribe
+}
+
+func (h *Hub) Publish(n models.BannerNotification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, tagId := range n.TagIds {
+		h.notify(tagKey(tagId), n.EventId)
+	}
+	for _, featureId := range n.FeatureIds {
+		h.notify(featureKey(featureId), n.EventId)
+	}
+}
+
+func (h *Hub) notify(key any, eventId uint64) {
+	for sub := range h.subscribers[key] {
+		select {
+		case <-sub.events:
+		default:
+		}
+		sub.events <- eventId
+	}
+}
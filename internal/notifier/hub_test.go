@@ -0,0 +1,62 @@
+package notifier
+
+import (
+	"banner-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func received(events <-chan uint64) []uint64 {
+	var ids []uint64
+	for {
+		select {
+		case id := <-events:
+			ids = append(ids, id)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	feature, unsubscribeFeature := hub.SubscribeFeature(1)
+	defer unsubscribeFeature()
+	tag, unsubscribeTag := hub.SubscribeTag(2)
+	defer unsubscribeTag()
+	other, unsubscribeOther := hub.SubscribeFeature(2)
+	defer unsubscribeOther()
+	otherTag, unsubscribeOtherTag := hub.SubscribeTag(4)
+	defer unsubscribeOtherTag()
+
+	hub.Publish(models.BannerNotification{EventId: 10, FeatureIds: []uint64{1}, TagIds: []uint64{2, 3}})
+
+	assert.Equal(t, []uint64{10}, received(feature), "every tag of the feature may resolve to the banner")
+	assert.Equal(t, []uint64{10}, received(tag))
+	assert.Empty(t, received(other), "another feature is not notified")
+	assert.Empty(t, received(otherTag), "another tag is not notified")
+}
+
+func TestHubKeepsLatestEventForSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	events, unsubscribe := hub.SubscribeTag(1)
+	defer unsubscribe()
+
+	for _, eventId := range []uint64{1, 2, 3} {
+		hub.Publish(models.BannerNotification{EventId: eventId, FeatureIds: []uint64{1}, TagIds: []uint64{1}})
+	}
+
+	assert.Equal(t, []uint64{3}, received(events))
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	hub := NewHub()
+	events, unsubscribe := hub.SubscribeFeature(1)
+	unsubscribe()
+	unsubscribe()
+
+	hub.Publish(models.BannerNotification{EventId: 1, FeatureIds: []uint64{1}, TagIds: []uint64{1}})
+
+	assert.Empty(t, received(events))
+	assert.Empty(t, hub.subscribers, "the last subscriber of a key drops the key")
+}
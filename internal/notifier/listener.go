@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"github.com/jackc/pgx/v5"
+	"log"
+	"strconv"
+	"time"
+)
+
+const reconnectDelay = time.Second
+
+type publisher interface {
+	Publish(n models.BannerNotification)
+}
+
+type loader interface {
+	GetBannerNotification(ctx context.Context, eventId uint64) (models.BannerNotification, error)
+}
+
+// Listener receives the ids of banner events from every instance through
+// Postgres LISTEN/NOTIFY, loads what the events touched and forwards it to
+// the local hub.
+type Listener struct {
+	dsn    string
+	loader loader
+	hub    publisher
+}
+
+func NewListener(dsn string, loader loader, hub publisher) *Listener {
+	return &Listener{
+		dsn:    dsn,
+		loader: loader,
+		hub:    hub,
+	}
+}
+
+func (l *Listener) Start(ctx context.Context) {
+	for {
+		err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("notifier: %v", err)
+
+		select {
+		case <-time.After(reconnectDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, l.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "listen "+pgx.Identifier{models.BannerEventsChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		eventId, err := strconv.ParseUint(notification.Payload, 10, 64)
+		if err != nil {
+			log.Printf("notifier: malformed payload %q", notification.Payload)
+			continue
+		}
+		n, err := l.loader.GetBannerNotification(ctx, eventId)
+		if err != nil {
+			log.Printf("notifier: event %d: %v", eventId, err)
+			continue
+		}
+		l.hub.Publish(n)
+	}
+}
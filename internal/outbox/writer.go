@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterSink appends every event to w as a line of NDJSON.
+type WriterSink struct {
+	name string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+func NewWriterSink(name string, w io.Writer) *WriterSink {
+	return &WriterSink{
+		name: name,
+		w:    w,
+	}
+}
+
+func NewStdoutSink() *WriterSink {
+	return NewWriterSink("stdout", os.Stdout)
+}
+
+func NewFileSink(path string) (*WriterSink, *os.File, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewWriterSink("file", f), f, nil
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Send(_ context.Context, event models.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,48 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Media types of the patch documents PATCH /banner/{banner_id} accepts besides
+// application/json.
+const (
+	MergePatch = "application/merge-patch+json" // RFC 7396
+	JSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrTestFailed means a test operation of a JSON Patch did not hold.
+	ErrTestFailed = errors.New("patch test failed")
+)
+
+// Apply applies a patch document of the media type to doc. A JSON Patch is
+// applied atomically: if any operation fails, nothing is applied.
+func Apply(mediaType string, doc, patch json.RawMessage) (json.RawMessage, error) {
+	switch mediaType {
+	case MergePatch:
+		patched, err := jsonpatch.MergePatch(doc, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return patched, nil
+	case JSONPatch:
+		operations, err := jsonpatch.DecodePatch(patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		patched, err := operations.Apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, fmt.Errorf("%w: %v", ErrTestFailed, err)
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return patched, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported media type %q", ErrInvalidPatch, mediaType)
+	}
+}
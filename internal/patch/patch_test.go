@@ -0,0 +1,74 @@
+package patch
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	doc := json.RawMessage(`{"title": "Sale", "text": "Up to 50%", "tags": ["a", "b"]}`)
+	tests := []struct {
+		name      string
+		mediaType string
+		patch     string
+		want      string
+		err       error
+	}{
+		{
+			name:      "merge patch changes and removes fields",
+			mediaType: MergePatch,
+			patch:     `{"title": "Big sale", "text": null}`,
+			want:      `{"title": "Big sale", "tags": ["a", "b"]}`,
+		},
+		{
+			name:      "merge patch replaces arrays",
+			mediaType: MergePatch,
+			patch:     `{"tags": ["c"]}`,
+			want:      `{"title": "Sale", "text": "Up to 50%", "tags": ["c"]}`,
+		},
+		{
+			name:      "json patch",
+			mediaType: JSONPatch,
+			patch:     `[{"op": "test", "path": "/title", "value": "Sale"}, {"op": "add", "path": "/tags/-", "value": "c"}]`,
+			want:      `{"title": "Sale", "text": "Up to 50%", "tags": ["a", "b", "c"]}`,
+		},
+		{
+			name:      "failed test",
+			mediaType: JSONPatch,
+			patch:     `[{"op": "test", "path": "/title", "value": "Old"}, {"op": "remove", "path": "/text"}]`,
+			err:       ErrTestFailed,
+		},
+		{
+			name:      "missing path",
+			mediaType: JSONPatch,
+			patch:     `[{"op": "replace", "path": "/url", "value": "x"}]`,
+			err:       ErrInvalidPatch,
+		},
+		{
+			name:      "json patch must be an array",
+			mediaType: JSONPatch,
+			patch:     `{"title": "x"}`,
+			err:       ErrInvalidPatch,
+		},
+		{
+			name:      "unsupported media type",
+			mediaType: "application/xml",
+			patch:     `{}`,
+			err:       ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply(tt.mediaType, doc, json.RawMessage(tt.patch))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
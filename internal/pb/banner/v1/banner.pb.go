@@ -0,0 +1,2356 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v4.25.3
+// source: banner/v1/banner.proto
+
+package bannerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type BannerSortField int32
+
+const (
+	BannerSortField_BANNER_SORT_FIELD_UNSPECIFIED BannerSortField = 0
+	BannerSortField_BANNER_SORT_FIELD_BANNER_ID   BannerSortField = 1
+	BannerSortField_BANNER_SORT_FIELD_CREATED_AT  BannerSortField = 2
+	BannerSortField_BANNER_SORT_FIELD_UPDATED_AT  BannerSortField = 3
+)
+
+// Enum value maps for BannerSortField.
+var (
+	BannerSortField_name = map[int32]string{
+		0: "BANNER_SORT_FIELD_UNSPECIFIED",
+		1: "BANNER_SORT_FIELD_BANNER_ID",
+		2: "BANNER_SORT_FIELD_CREATED_AT",
+		3: "BANNER_SORT_FIELD_UPDATED_AT",
+	}
+	BannerSortField_value = map[string]int32{
+		"BANNER_SORT_FIELD_UNSPECIFIED": 0,
+		"BANNER_SORT_FIELD_BANNER_ID":   1,
+		"BANNER_SORT_FIELD_CREATED_AT":  2,
+		"BANNER_SORT_FIELD_UPDATED_AT":  3,
+	}
+)
+
+func (x BannerSortField) Enum() *BannerSortField {
+	p := new(BannerSortField)
+	*p = x
+	return p
+}
+
+func (x BannerSortField) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BannerSortField) Descriptor() protoreflect.EnumDescriptor {
+	return file_banner_v1_banner_proto_enumTypes[0].Descriptor()
+}
+
+func (BannerSortField) Type() protoreflect.EnumType {
+	return &file_banner_v1_banner_proto_enumTypes[0]
+}
+
+func (x BannerSortField) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BannerSortField.Descriptor instead.
+func (BannerSortField) EnumDescriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{0}
+}
+
+type BannerUpdateStatus int32
+
+const (
+	BannerUpdateStatus_BANNER_UPDATE_STATUS_UNSPECIFIED BannerUpdateStatus = 0
+	BannerUpdateStatus_BANNER_UPDATE_STATUS_BANNER      BannerUpdateStatus = 1
+	BannerUpdateStatus_BANNER_UPDATE_STATUS_INACTIVE    BannerUpdateStatus = 2
+	BannerUpdateStatus_BANNER_UPDATE_STATUS_NOT_FOUND   BannerUpdateStatus = 3
+)
+
+// Enum value maps for BannerUpdateStatus.
+var (
+	BannerUpdateStatus_name = map[int32]string{
+		0: "BANNER_UPDATE_STATUS_UNSPECIFIED",
+		1: "BANNER_UPDATE_STATUS_BANNER",
+		2: "BANNER_UPDATE_STATUS_INACTIVE",
+		3: "BANNER_UPDATE_STATUS_NOT_FOUND",
+	}
+	BannerUpdateStatus_value = map[string]int32{
+		"BANNER_UPDATE_STATUS_UNSPECIFIED": 0,
+		"BANNER_UPDATE_STATUS_BANNER":      1,
+		"BANNER_UPDATE_STATUS_INACTIVE":    2,
+		"BANNER_UPDATE_STATUS_NOT_FOUND":   3,
+	}
+)
+
+func (x BannerUpdateStatus) Enum() *BannerUpdateStatus {
+	p := new(BannerUpdateStatus)
+	*p = x
+	return p
+}
+
+func (x BannerUpdateStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BannerUpdateStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_banner_v1_banner_proto_enumTypes[1].Descriptor()
+}
+
+func (BannerUpdateStatus) Type() protoreflect.EnumType {
+	return &file_banner_v1_banner_proto_enumTypes[1]
+}
+
+func (x BannerUpdateStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BannerUpdateStatus.Descriptor instead.
+func (BannerUpdateStatus) EnumDescriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{1}
+}
+
+type Banner struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BannerId  uint64   `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+	FeatureId uint64   `protobuf:"varint,2,opt,name=feature_id,json=featureId,proto3" json:"feature_id,omitempty"`
+	TagIds    []uint64 `protobuf:"varint,3,rep,packed,name=tag_ids,json=tagIds,proto3" json:"tag_ids,omitempty"`
+	// JSON encoded banner content.
+	Content      string                 `protobuf:"bytes,4,opt,name=content,proto3" json:"content,omitempty"`
+	IsActive     bool                   `protobuf:"varint,5,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	Version      uint64                 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt    *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt    *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Priority     int32                  `protobuf:"varint,9,opt,name=priority,proto3" json:"priority,omitempty"`
+	FrequencyCap *FrequencyCap          `protobuf:"bytes,10,opt,name=frequency_cap,json=frequencyCap,proto3" json:"frequency_cap,omitempty"`
+}
+
+func (x *Banner) Reset() {
+	*x = Banner{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Banner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Banner) ProtoMessage() {}
+
+func (x *Banner) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Banner.ProtoReflect.Descriptor instead.
+func (*Banner) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Banner) GetBannerId() uint64 {
+	if x != nil {
+		return x.BannerId
+	}
+	return 0
+}
+
+func (x *Banner) GetFeatureId() uint64 {
+	if x != nil {
+		return x.FeatureId
+	}
+	return 0
+}
+
+func (x *Banner) GetTagIds() []uint64 {
+	if x != nil {
+		return x.TagIds
+	}
+	return nil
+}
+
+func (x *Banner) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Banner) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Banner) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Banner) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Banner) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Banner) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Banner) GetFrequencyCap() *FrequencyCap {
+	if x != nil {
+		return x.FrequencyCap
+	}
+	return nil
+}
+
+// Serves of a banner to the same user within a sliding window.
+type FrequencyCap struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Limit         uint64 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	WindowSeconds uint64 `protobuf:"varint,2,opt,name=window_seconds,json=windowSeconds,proto3" json:"window_seconds,omitempty"`
+}
+
+func (x *FrequencyCap) Reset() {
+	*x = FrequencyCap{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FrequencyCap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrequencyCap) ProtoMessage() {}
+
+func (x *FrequencyCap) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrequencyCap.ProtoReflect.Descriptor instead.
+func (*FrequencyCap) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FrequencyCap) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *FrequencyCap) GetWindowSeconds() uint64 {
+	if x != nil {
+		return x.WindowSeconds
+	}
+	return 0
+}
+
+type TagIds struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TagIds []uint64 `protobuf:"varint,1,rep,packed,name=tag_ids,json=tagIds,proto3" json:"tag_ids,omitempty"`
+}
+
+func (x *TagIds) Reset() {
+	*x = TagIds{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TagIds) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TagIds) ProtoMessage() {}
+
+func (x *TagIds) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TagIds.ProtoReflect.Descriptor instead.
+func (*TagIds) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TagIds) GetTagIds() []uint64 {
+	if x != nil {
+		return x.TagIds
+	}
+	return nil
+}
+
+type GetBannerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TagId           uint64 `protobuf:"varint,1,opt,name=tag_id,json=tagId,proto3" json:"tag_id,omitempty"`
+	FeatureId       uint64 `protobuf:"varint,2,opt,name=feature_id,json=featureId,proto3" json:"feature_id,omitempty"`
+	UseLastRevision bool   `protobuf:"varint,3,opt,name=use_last_revision,json=useLastRevision,proto3" json:"use_last_revision,omitempty"`
+	// Further tags of the user; the banner is chosen by priority among the
+	// banners of tag_id and tag_ids.
+	TagIds []uint64 `protobuf:"varint,4,rep,packed,name=tag_ids,json=tagIds,proto3" json:"tag_ids,omitempty"`
+	// Values of the {{param.*}} placeholders of templated content.
+	Params map[string]string `protobuf:"bytes,5,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Preferred locales in the Accept-Language syntax, e.g. "kk, ru;q=0.8".
+	Locale string `protobuf:"bytes,6,opt,name=locale,proto3" json:"locale,omitempty"`
+	// Fail with INVALID_ARGUMENT when a placeholder without a default has no
+	// value, even if the server renders such placeholders empty.
+	StrictTemplates bool `protobuf:"varint,7,opt,name=strict_templates,json=strictTemplates,proto3" json:"strict_templates,omitempty"`
+}
+
+func (x *GetBannerRequest) Reset() {
+	*x = GetBannerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBannerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBannerRequest) ProtoMessage() {}
+
+func (x *GetBannerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBannerRequest.ProtoReflect.Descriptor instead.
+func (*GetBannerRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetBannerRequest) GetTagId() uint64 {
+	if x != nil {
+		return x.TagId
+	}
+	return 0
+}
+
+func (x *GetBannerRequest) GetFeatureId() uint64 {
+	if x != nil {
+		return x.FeatureId
+	}
+	return 0
+}
+
+func (x *GetBannerRequest) GetUseLastRevision() bool {
+	if x != nil {
+		return x.UseLastRevision
+	}
+	return false
+}
+
+func (x *GetBannerRequest) GetTagIds() []uint64 {
+	if x != nil {
+		return x.TagIds
+	}
+	return nil
+}
+
+func (x *GetBannerRequest) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *GetBannerRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *GetBannerRequest) GetStrictTemplates() bool {
+	if x != nil {
+		return x.StrictTemplates
+	}
+	return false
+}
+
+type GetBannerResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// JSON encoded banner content.
+	Content string `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	// The requested tag or the ancestor the banner was found by; zero for the
+	// default banner of the feature.
+	MatchedTagId uint64 `protobuf:"varint,2,opt,name=matched_tag_id,json=matchedTagId,proto3" json:"matched_tag_id,omitempty"`
+	// The locale of the content; empty when the banner is not localized.
+	ContentLanguage string `protobuf:"bytes,3,opt,name=content_language,json=contentLanguage,proto3" json:"content_language,omitempty"`
+}
+
+func (x *GetBannerResponse) Reset() {
+	*x = GetBannerResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBannerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBannerResponse) ProtoMessage() {}
+
+func (x *GetBannerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBannerResponse.ProtoReflect.Descriptor instead.
+func (*GetBannerResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetBannerResponse) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *GetBannerResponse) GetMatchedTagId() uint64 {
+	if x != nil {
+		return x.MatchedTagId
+	}
+	return 0
+}
+
+func (x *GetBannerResponse) GetContentLanguage() string {
+	if x != nil {
+		return x.ContentLanguage
+	}
+	return ""
+}
+
+// ListBannersRequest returns banners matching all set conditions.
+type ListBannersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Added to feature_ids.
+	FeatureId *uint64 `protobuf:"varint,1,opt,name=feature_id,json=featureId,proto3,oneof" json:"feature_id,omitempty"`
+	// Added to tag_ids.
+	TagId  *uint64 `protobuf:"varint,2,opt,name=tag_id,json=tagId,proto3,oneof" json:"tag_id,omitempty"`
+	Limit  uint64  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset uint64  `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Defaults to BANNER_SORT_FIELD_BANNER_ID.
+	SortBy    BannerSortField `protobuf:"varint,5,opt,name=sort_by,json=sortBy,proto3,enum=banner.v1.BannerSortField" json:"sort_by,omitempty"`
+	Ascending bool            `protobuf:"varint,6,opt,name=ascending,proto3" json:"ascending,omitempty"`
+	// next_cursor of the previous page.
+	Cursor    string `protobuf:"bytes,7,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	WithTotal bool   `protobuf:"varint,8,opt,name=with_total,json=withTotal,proto3" json:"with_total,omitempty"`
+	// The banner belongs to one of the features.
+	FeatureIds []uint64 `protobuf:"varint,9,rep,packed,name=feature_ids,json=featureIds,proto3" json:"feature_ids,omitempty"`
+	// The banner carries every tag.
+	TagIds   []uint64 `protobuf:"varint,10,rep,packed,name=tag_ids,json=tagIds,proto3" json:"tag_ids,omitempty"`
+	IsActive *bool    `protobuf:"varint,11,opt,name=is_active,json=isActive,proto3,oneof" json:"is_active,omitempty"`
+	// Ranges include the lower bound and exclude the upper one.
+	CreatedFrom *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo   *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	UpdatedFrom *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=updated_from,json=updatedFrom,proto3" json:"updated_from,omitempty"`
+	UpdatedTo   *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=updated_to,json=updatedTo,proto3" json:"updated_to,omitempty"`
+	// The banner has a version newer than the active one.
+	HasDraft *bool `protobuf:"varint,16,opt,name=has_draft,json=hasDraft,proto3,oneof" json:"has_draft,omitempty"`
+	// JSON document contained in the active content.
+	Content string `protobuf:"bytes,17,opt,name=content,proto3" json:"content,omitempty"`
+	// JSONPath that must match the active content.
+	ContentPath string `protobuf:"bytes,18,opt,name=content_path,json=contentPath,proto3" json:"content_path,omitempty"`
+}
+
+func (x *ListBannersRequest) Reset() {
+	*x = ListBannersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListBannersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBannersRequest) ProtoMessage() {}
+
+func (x *ListBannersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBannersRequest.ProtoReflect.Descriptor instead.
+func (*ListBannersRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListBannersRequest) GetFeatureId() uint64 {
+	if x != nil && x.FeatureId != nil {
+		return *x.FeatureId
+	}
+	return 0
+}
+
+func (x *ListBannersRequest) GetTagId() uint64 {
+	if x != nil && x.TagId != nil {
+		return *x.TagId
+	}
+	return 0
+}
+
+func (x *ListBannersRequest) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListBannersRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListBannersRequest) GetSortBy() BannerSortField {
+	if x != nil {
+		return x.SortBy
+	}
+	return BannerSortField_BANNER_SORT_FIELD_UNSPECIFIED
+}
+
+func (x *ListBannersRequest) GetAscending() bool {
+	if x != nil {
+		return x.Ascending
+	}
+	return false
+}
+
+func (x *ListBannersRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListBannersRequest) GetWithTotal() bool {
+	if x != nil {
+		return x.WithTotal
+	}
+	return false
+}
+
+func (x *ListBannersRequest) GetFeatureIds() []uint64 {
+	if x != nil {
+		return x.FeatureIds
+	}
+	return nil
+}
+
+func (x *ListBannersRequest) GetTagIds() []uint64 {
+	if x != nil {
+		return x.TagIds
+	}
+	return nil
+}
+
+func (x *ListBannersRequest) GetIsActive() bool {
+	if x != nil && x.IsActive != nil {
+		return *x.IsActive
+	}
+	return false
+}
+
+func (x *ListBannersRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListBannersRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListBannersRequest) GetUpdatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedFrom
+	}
+	return nil
+}
+
+func (x *ListBannersRequest) GetUpdatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedTo
+	}
+	return nil
+}
+
+func (x *ListBannersRequest) GetHasDraft() bool {
+	if x != nil && x.HasDraft != nil {
+		return *x.HasDraft
+	}
+	return false
+}
+
+func (x *ListBannersRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *ListBannersRequest) GetContentPath() string {
+	if x != nil {
+		return x.ContentPath
+	}
+	return ""
+}
+
+type ListBannersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Banners []*Banner `protobuf:"bytes,1,rep,name=banners,proto3" json:"banners,omitempty"`
+	// Empty on the last page.
+	NextCursor string  `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	Total      *uint64 `protobuf:"varint,3,opt,name=total,proto3,oneof" json:"total,omitempty"`
+}
+
+func (x *ListBannersResponse) Reset() {
+	*x = ListBannersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListBannersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListBannersResponse) ProtoMessage() {}
+
+func (x *ListBannersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListBannersResponse.ProtoReflect.Descriptor instead.
+func (*ListBannersResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListBannersResponse) GetBanners() []*Banner {
+	if x != nil {
+		return x.Banners
+	}
+	return nil
+}
+
+func (x *ListBannersResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+func (x *ListBannersResponse) GetTotal() uint64 {
+	if x != nil && x.Total != nil {
+		return *x.Total
+	}
+	return 0
+}
+
+type SearchBannersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// Searches inactive versions as well.
+	WithHistory bool   `protobuf:"varint,2,opt,name=with_history,json=withHistory,proto3" json:"with_history,omitempty"`
+	Limit       uint64 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset      uint64 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+}
+
+func (x *SearchBannersRequest) Reset() {
+	*x = SearchBannersRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchBannersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchBannersRequest) ProtoMessage() {}
+
+func (x *SearchBannersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchBannersRequest.ProtoReflect.Descriptor instead.
+func (*SearchBannersRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SearchBannersRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchBannersRequest) GetWithHistory() bool {
+	if x != nil {
+		return x.WithHistory
+	}
+	return false
+}
+
+func (x *SearchBannersRequest) GetLimit() uint64 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchBannersRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type SearchResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The matched version of the banner.
+	Banner    *Banner `protobuf:"bytes,1,opt,name=banner,proto3" json:"banner,omitempty"`
+	IsCurrent bool    `protobuf:"varint,2,opt,name=is_current,json=isCurrent,proto3" json:"is_current,omitempty"`
+	Rank      float64 `protobuf:"fixed64,3,opt,name=rank,proto3" json:"rank,omitempty"`
+	// JSON encoded content with the string values escaped as HTML and the
+	// matches wrapped in <mark></mark>.
+	Highlight string `protobuf:"bytes,4,opt,name=highlight,proto3" json:"highlight,omitempty"`
+}
+
+func (x *SearchResult) Reset() {
+	*x = SearchResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResult) ProtoMessage() {}
+
+func (x *SearchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SearchResult) GetBanner() *Banner {
+	if x != nil {
+		return x.Banner
+	}
+	return nil
+}
+
+func (x *SearchResult) GetIsCurrent() bool {
+	if x != nil {
+		return x.IsCurrent
+	}
+	return false
+}
+
+func (x *SearchResult) GetRank() float64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *SearchResult) GetHighlight() string {
+	if x != nil {
+		return x.Highlight
+	}
+	return ""
+}
+
+type SearchBannersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Results []*SearchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (x *SearchBannersResponse) Reset() {
+	*x = SearchBannersResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SearchBannersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchBannersResponse) ProtoMessage() {}
+
+func (x *SearchBannersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchBannersResponse.ProtoReflect.Descriptor instead.
+func (*SearchBannersResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SearchBannersResponse) GetResults() []*SearchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type CreateBannerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TagIds    []uint64 `protobuf:"varint,1,rep,packed,name=tag_ids,json=tagIds,proto3" json:"tag_ids,omitempty"`
+	FeatureId uint64   `protobuf:"varint,2,opt,name=feature_id,json=featureId,proto3" json:"feature_id,omitempty"`
+	// JSON encoded banner content.
+	Content      string        `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	IsActive     bool          `protobuf:"varint,4,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	Priority     int32         `protobuf:"varint,5,opt,name=priority,proto3" json:"priority,omitempty"`
+	FrequencyCap *FrequencyCap `protobuf:"bytes,6,opt,name=frequency_cap,json=frequencyCap,proto3" json:"frequency_cap,omitempty"`
+}
+
+func (x *CreateBannerRequest) Reset() {
+	*x = CreateBannerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateBannerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBannerRequest) ProtoMessage() {}
+
+func (x *CreateBannerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBannerRequest.ProtoReflect.Descriptor instead.
+func (*CreateBannerRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CreateBannerRequest) GetTagIds() []uint64 {
+	if x != nil {
+		return x.TagIds
+	}
+	return nil
+}
+
+func (x *CreateBannerRequest) GetFeatureId() uint64 {
+	if x != nil {
+		return x.FeatureId
+	}
+	return 0
+}
+
+func (x *CreateBannerRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreateBannerRequest) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *CreateBannerRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *CreateBannerRequest) GetFrequencyCap() *FrequencyCap {
+	if x != nil {
+		return x.FrequencyCap
+	}
+	return nil
+}
+
+type CreateBannerResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BannerId uint64 `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+}
+
+func (x *CreateBannerResponse) Reset() {
+	*x = CreateBannerResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateBannerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateBannerResponse) ProtoMessage() {}
+
+func (x *CreateBannerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateBannerResponse.ProtoReflect.Descriptor instead.
+func (*CreateBannerResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CreateBannerResponse) GetBannerId() uint64 {
+	if x != nil {
+		return x.BannerId
+	}
+	return 0
+}
+
+type UpdateBannerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BannerId  uint64  `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+	FeatureId *uint64 `protobuf:"varint,2,opt,name=feature_id,json=featureId,proto3,oneof" json:"feature_id,omitempty"`
+	// Replaces the tags of the banner when set.
+	TagIds *TagIds `protobuf:"bytes,3,opt,name=tag_ids,json=tagIds,proto3" json:"tag_ids,omitempty"`
+	// JSON encoded banner content.
+	Content  *string `protobuf:"bytes,4,opt,name=content,proto3,oneof" json:"content,omitempty"`
+	IsActive *bool   `protobuf:"varint,5,opt,name=is_active,json=isActive,proto3,oneof" json:"is_active,omitempty"`
+	Priority *int32  `protobuf:"varint,6,opt,name=priority,proto3,oneof" json:"priority,omitempty"`
+	// Replaces the cap of the banner when set; a zero limit removes it.
+	FrequencyCap *FrequencyCap `protobuf:"bytes,7,opt,name=frequency_cap,json=frequencyCap,proto3" json:"frequency_cap,omitempty"`
+}
+
+func (x *UpdateBannerRequest) Reset() {
+	*x = UpdateBannerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateBannerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateBannerRequest) ProtoMessage() {}
+
+func (x *UpdateBannerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateBannerRequest.ProtoReflect.Descriptor instead.
+func (*UpdateBannerRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateBannerRequest) GetBannerId() uint64 {
+	if x != nil {
+		return x.BannerId
+	}
+	return 0
+}
+
+func (x *UpdateBannerRequest) GetFeatureId() uint64 {
+	if x != nil && x.FeatureId != nil {
+		return *x.FeatureId
+	}
+	return 0
+}
+
+func (x *UpdateBannerRequest) GetTagIds() *TagIds {
+	if x != nil {
+		return x.TagIds
+	}
+	return nil
+}
+
+func (x *UpdateBannerRequest) GetContent() string {
+	if x != nil && x.Content != nil {
+		return *x.Content
+	}
+	return ""
+}
+
+func (x *UpdateBannerRequest) GetIsActive() bool {
+	if x != nil && x.IsActive != nil {
+		return *x.IsActive
+	}
+	return false
+}
+
+func (x *UpdateBannerRequest) GetPriority() int32 {
+	if x != nil && x.Priority != nil {
+		return *x.Priority
+	}
+	return 0
+}
+
+func (x *UpdateBannerRequest) GetFrequencyCap() *FrequencyCap {
+	if x != nil {
+		return x.FrequencyCap
+	}
+	return nil
+}
+
+type UpdateBannerResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UpdateBannerResponse) Reset() {
+	*x = UpdateBannerResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateBannerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateBannerResponse) ProtoMessage() {}
+
+func (x *UpdateBannerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateBannerResponse.ProtoReflect.Descriptor instead.
+func (*UpdateBannerResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{13}
+}
+
+type DeleteBannerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BannerId uint64 `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+}
+
+func (x *DeleteBannerRequest) Reset() {
+	*x = DeleteBannerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteBannerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteBannerRequest) ProtoMessage() {}
+
+func (x *DeleteBannerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteBannerRequest.ProtoReflect.Descriptor instead.
+func (*DeleteBannerRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DeleteBannerRequest) GetBannerId() uint64 {
+	if x != nil {
+		return x.BannerId
+	}
+	return 0
+}
+
+type DeleteBannerResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteBannerResponse) Reset() {
+	*x = DeleteBannerResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteBannerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteBannerResponse) ProtoMessage() {}
+
+func (x *DeleteBannerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteBannerResponse.ProtoReflect.Descriptor instead.
+func (*DeleteBannerResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{15}
+}
+
+type ListVersionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BannerId uint64 `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+}
+
+func (x *ListVersionsRequest) Reset() {
+	*x = ListVersionsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVersionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsRequest) ProtoMessage() {}
+
+func (x *ListVersionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsRequest.ProtoReflect.Descriptor instead.
+func (*ListVersionsRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListVersionsRequest) GetBannerId() uint64 {
+	if x != nil {
+		return x.BannerId
+	}
+	return 0
+}
+
+type ListVersionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Banners []*Banner `protobuf:"bytes,1,rep,name=banners,proto3" json:"banners,omitempty"`
+}
+
+func (x *ListVersionsResponse) Reset() {
+	*x = ListVersionsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListVersionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListVersionsResponse) ProtoMessage() {}
+
+func (x *ListVersionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListVersionsResponse.ProtoReflect.Descriptor instead.
+func (*ListVersionsResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListVersionsResponse) GetBanners() []*Banner {
+	if x != nil {
+		return x.Banners
+	}
+	return nil
+}
+
+type ChooseVersionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BannerId uint64 `protobuf:"varint,1,opt,name=banner_id,json=bannerId,proto3" json:"banner_id,omitempty"`
+	Version  uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *ChooseVersionRequest) Reset() {
+	*x = ChooseVersionRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChooseVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChooseVersionRequest) ProtoMessage() {}
+
+func (x *ChooseVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChooseVersionRequest.ProtoReflect.Descriptor instead.
+func (*ChooseVersionRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ChooseVersionRequest) GetBannerId() uint64 {
+	if x != nil {
+		return x.BannerId
+	}
+	return 0
+}
+
+func (x *ChooseVersionRequest) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type ChooseVersionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ChooseVersionResponse) Reset() {
+	*x = ChooseVersionResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChooseVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChooseVersionResponse) ProtoMessage() {}
+
+func (x *ChooseVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChooseVersionResponse.ProtoReflect.Descriptor instead.
+func (*ChooseVersionResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{19}
+}
+
+type WatchBannerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TagId     uint64 `protobuf:"varint,1,opt,name=tag_id,json=tagId,proto3" json:"tag_id,omitempty"`
+	FeatureId uint64 `protobuf:"varint,2,opt,name=feature_id,json=featureId,proto3" json:"feature_id,omitempty"`
+	// Resume after this event; the current state is sent first when it is
+	// missing or outdated.
+	LastEventId *uint64 `protobuf:"varint,3,opt,name=last_event_id,json=lastEventId,proto3,oneof" json:"last_event_id,omitempty"`
+	// Values of the {{param.*}} placeholders of templated content.
+	Params map[string]string `protobuf:"bytes,4,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Skip updates whose content has a placeholder without a default and
+	// without a value, even if the server renders such placeholders empty.
+	StrictTemplates bool `protobuf:"varint,5,opt,name=strict_templates,json=strictTemplates,proto3" json:"strict_templates,omitempty"`
+	// Preferred locales in the Accept-Language syntax, e.g. "kk, ru;q=0.8".
+	Locale string `protobuf:"bytes,6,opt,name=locale,proto3" json:"locale,omitempty"`
+}
+
+func (x *WatchBannerRequest) Reset() {
+	*x = WatchBannerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchBannerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBannerRequest) ProtoMessage() {}
+
+func (x *WatchBannerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBannerRequest.ProtoReflect.Descriptor instead.
+func (*WatchBannerRequest) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *WatchBannerRequest) GetTagId() uint64 {
+	if x != nil {
+		return x.TagId
+	}
+	return 0
+}
+
+func (x *WatchBannerRequest) GetFeatureId() uint64 {
+	if x != nil {
+		return x.FeatureId
+	}
+	return 0
+}
+
+func (x *WatchBannerRequest) GetLastEventId() uint64 {
+	if x != nil && x.LastEventId != nil {
+		return *x.LastEventId
+	}
+	return 0
+}
+
+func (x *WatchBannerRequest) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *WatchBannerRequest) GetStrictTemplates() bool {
+	if x != nil {
+		return x.StrictTemplates
+	}
+	return false
+}
+
+func (x *WatchBannerRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+type WatchBannerResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	EventId uint64             `protobuf:"varint,1,opt,name=event_id,json=eventId,proto3" json:"event_id,omitempty"`
+	Status  BannerUpdateStatus `protobuf:"varint,2,opt,name=status,proto3,enum=banner.v1.BannerUpdateStatus" json:"status,omitempty"`
+	// JSON encoded banner content, set for BANNER_UPDATE_STATUS_BANNER.
+	Content string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+}
+
+func (x *WatchBannerResponse) Reset() {
+	*x = WatchBannerResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_banner_v1_banner_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchBannerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchBannerResponse) ProtoMessage() {}
+
+func (x *WatchBannerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_banner_v1_banner_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchBannerResponse.ProtoReflect.Descriptor instead.
+func (*WatchBannerResponse) Descriptor() ([]byte, []int) {
+	return file_banner_v1_banner_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *WatchBannerResponse) GetEventId() uint64 {
+	if x != nil {
+		return x.EventId
+	}
+	return 0
+}
+
+func (x *WatchBannerResponse) GetStatus() BannerUpdateStatus {
+	if x != nil {
+		return x.Status
+	}
+	return BannerUpdateStatus_BANNER_UPDATE_STATUS_UNSPECIFIED
+}
+
+func (x *WatchBannerResponse) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+var File_banner_v1_banner_proto protoreflect.FileDescriptor
+
+var file_banner_v1_banner_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x2f, 0x62, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0xfe, 0x02, 0x0a, 0x06, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12,
+	0x1b, 0x0a, 0x09, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x08, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x09, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x74,
+	0x61, 0x67, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x74, 0x61,
+	0x67, 0x49, 0x64, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x1b,
+	0x0a, 0x09, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x08, 0x69, 0x73, 0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x39, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x70,
+	0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70,
+	0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x3c, 0x0a, 0x0d, 0x66, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x79, 0x5f, 0x63, 0x61, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17,
+	0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x79, 0x43, 0x61, 0x70, 0x52, 0x0c, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x79, 0x43, 0x61, 0x70, 0x22, 0x4b, 0x0a, 0x0c, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x79, 0x43, 0x61, 0x70, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x77,
+	0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0d, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53, 0x65, 0x63, 0x6f, 0x6e,
+	0x64, 0x73, 0x22, 0x21, 0x0a, 0x06, 0x54, 0x61, 0x67, 0x49, 0x64, 0x73, 0x12, 0x17, 0x0a, 0x07,
+	0x74, 0x61, 0x67, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x74,
+	0x61, 0x67, 0x49, 0x64, 0x73, 0x22, 0xcc, 0x02, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x42, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x74, 0x61,
+	0x67, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x74, 0x61, 0x67, 0x49,
+	0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64,
+	0x12, 0x2a, 0x0a, 0x11, 0x75, 0x73, 0x65, 0x5f, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x76,
+	0x69, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x75, 0x73, 0x65,
+	0x4c, 0x61, 0x73, 0x74, 0x52, 0x65, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x17, 0x0a, 0x07,
+	0x74, 0x61, 0x67, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x74,
+	0x61, 0x67, 0x49, 0x64, 0x73, 0x12, 0x3f, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x18,
+	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x2e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06,
+	0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x65, 0x12, 0x29,
+	0x0a, 0x10, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74,
+	0x65, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74,
+	0x54, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x50, 0x61, 0x72,
+	0x61, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x7e, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x64, 0x5f, 0x74,
+	0x61, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c, 0x6d, 0x61, 0x74,
+	0x63, 0x68, 0x65, 0x64, 0x54, 0x61, 0x67, 0x49, 0x64, 0x12, 0x29, 0x0a, 0x10, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x5f, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0f, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x4c, 0x61, 0x6e, 0x67,
+	0x75, 0x61, 0x67, 0x65, 0x22, 0xf1, 0x05, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x22, 0x0a, 0x0a, 0x66,
+	0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x48,
+	0x00, 0x52, 0x09, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12,
+	0x1a, 0x0a, 0x06, 0x74, 0x61, 0x67, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x48,
+	0x01, 0x52, 0x05, 0x74, 0x61, 0x67, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x14, 0x0a, 0x05, 0x6c,
+	0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x33, 0x0a, 0x07, 0x73, 0x6f, 0x72,
+	0x74, 0x5f, 0x62, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x62, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x53, 0x6f, 0x72,
+	0x74, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x52, 0x06, 0x73, 0x6f, 0x72, 0x74, 0x42, 0x79, 0x12, 0x1c,
+	0x0a, 0x09, 0x61, 0x73, 0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x09, 0x61, 0x73, 0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06,
+	0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x75,
+	0x72, 0x73, 0x6f, 0x72, 0x12, 0x1d, 0x0a, 0x0a, 0x77, 0x69, 0x74, 0x68, 0x5f, 0x74, 0x6f, 0x74,
+	0x61, 0x6c, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x77, 0x69, 0x74, 0x68, 0x54, 0x6f,
+	0x74, 0x61, 0x6c, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69,
+	0x64, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x04, 0x52, 0x0a, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x49, 0x64, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x67, 0x5f, 0x69, 0x64, 0x73, 0x18,
+	0x0a, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x74, 0x61, 0x67, 0x49, 0x64, 0x73, 0x12, 0x20, 0x0a,
+	0x09, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x02, 0x52, 0x08, 0x69, 0x73, 0x41, 0x63, 0x74, 0x69, 0x76, 0x65, 0x88, 0x01, 0x01, 0x12,
+	0x3d, 0x0a, 0x0c, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x0b, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x39,
+	0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x6f, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x54, 0x6f, 0x12, 0x3d, 0x0a, 0x0c, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x75, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x64, 0x5f, 0x74, 0x6f, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x54, 0x6f, 0x12, 0x20, 0x0a, 0x09, 0x68, 0x61, 0x73, 0x5f, 0x64, 0x72, 0x61, 0x66, 0x74,
+	0x18, 0x10, 0x20, 0x01, 0x28, 0x08, 0x48, 0x03, 0x52, 0x08, 0x68, 0x61, 0x73, 0x44, 0x72, 0x61,
+	0x66, 0x74, 0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12,
+	0x21, 0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18,
+	0x12, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x50, 0x61,
+	0x74, 0x68, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69,
+	0x64, 0x42, 0x09, 0x0a, 0x07, 0x5f, 0x74, 0x61, 0x67, 0x5f, 0x69, 0x64, 0x42, 0x0c, 0x0a, 0x0a,
+	0x5f, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x68,
+	0x61, 0x73, 0x5f, 0x64, 0x72, 0x61, 0x66, 0x74, 0x22, 0x88, 0x01, 0x0a, 0x13, 0x4c, 0x69, 0x73,
+	0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x2b, 0x0a, 0x07, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x07, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x1f, 0x0a,
+	0x0b, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x6e, 0x65, 0x78, 0x74, 0x43, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x19,
+	0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52,
+	0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x88, 0x01, 0x01, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x22, 0x7d, 0x0a, 0x14, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x42, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x71,
+	0x75, 0x65, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x65, 0x72,
+	0x79, 0x12, 0x21, 0x0a, 0x0c, 0x77, 0x69, 0x74, 0x68, 0x5f, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x72,
+	0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x77, 0x69, 0x74, 0x68, 0x48, 0x69, 0x73,
+	0x74, 0x6f, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66,
+	0x66, 0x73, 0x65, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73,
+	0x65, 0x74, 0x22, 0x8a, 0x01, 0x0a, 0x0c, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x12, 0x29, 0x0a, 0x06, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x06, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x1d,
+	0x0a, 0x0a, 0x69, 0x73, 0x5f, 0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x09, 0x69, 0x73, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x72, 0x61, 0x6e, 0x6b, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x72, 0x61, 0x6e,
+	0x6b, 0x12, 0x1c, 0x0a, 0x09, 0x68, 0x69, 0x67, 0x68, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x68, 0x69, 0x67, 0x68, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x22,
+	0x4a, 0x0a, 0x15, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x07, 0x72, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x62, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x52, 0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xde, 0x01, 0x0a, 0x13,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x61, 0x67, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x74, 0x61, 0x67, 0x49, 0x64, 0x73, 0x12, 0x1d, 0x0a, 0x0a,
+	0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x09, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63,
+	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x41, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x12, 0x3c,
+	0x0a, 0x0d, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x63, 0x61, 0x70, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76,
+	0x31, 0x2e, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x43, 0x61, 0x70, 0x52, 0x0c,
+	0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x43, 0x61, 0x70, 0x22, 0x33, 0x0a, 0x14,
+	0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x49,
+	0x64, 0x22, 0xd8, 0x02, 0x0a, 0x13, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x61, 0x6e,
+	0x6e, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x62, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x12, 0x22, 0x0a, 0x0a, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x09, 0x66, 0x65,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x2a, 0x0a, 0x07, 0x74, 0x61,
+	0x67, 0x5f, 0x69, 0x64, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x62, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x67, 0x49, 0x64, 0x73, 0x52, 0x06,
+	0x74, 0x61, 0x67, 0x49, 0x64, 0x73, 0x12, 0x1d, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65,
+	0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x20, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x48, 0x02, 0x52, 0x08, 0x69, 0x73, 0x41, 0x63,
+	0x74, 0x69, 0x76, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72,
+	0x69, 0x74, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x48, 0x03, 0x52, 0x08, 0x70, 0x72, 0x69,
+	0x6f, 0x72, 0x69, 0x74, 0x79, 0x88, 0x01, 0x01, 0x12, 0x3c, 0x0a, 0x0d, 0x66, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x63, 0x61, 0x70, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x79, 0x43, 0x61, 0x70, 0x52, 0x0c, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x79, 0x43, 0x61, 0x70, 0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x66, 0x65, 0x61, 0x74, 0x75,
+	0x72, 0x65, 0x5f, 0x69, 0x64, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x69, 0x73, 0x5f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x65, 0x42,
+	0x0b, 0x0a, 0x09, 0x5f, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69, 0x74, 0x79, 0x22, 0x16, 0x0a, 0x14,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x32, 0x0a, 0x13, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x62,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x22, 0x16, 0x0a, 0x14, 0x44, 0x65, 0x6c, 0x65,
+	0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x32, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x62, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x49, 0x64, 0x22, 0x43, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2b, 0x0a, 0x07,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72,
+	0x52, 0x07, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x22, 0x4d, 0x0a, 0x14, 0x43, 0x68, 0x6f,
+	0x6f, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x49, 0x64, 0x12, 0x18,
+	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x17, 0x0a, 0x15, 0x43, 0x68, 0x6f, 0x6f,
+	0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x22, 0xc6, 0x02, 0x0a, 0x12, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x15, 0x0a, 0x06, 0x74, 0x61, 0x67, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x74, 0x61, 0x67, 0x49, 0x64, 0x12,
+	0x1d, 0x0a, 0x0a, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x09, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x49, 0x64, 0x12, 0x27,
+	0x0a, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x49, 0x64, 0x88, 0x01, 0x01, 0x12, 0x41, 0x0a, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x29, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x06, 0x70, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x12, 0x29, 0x0a, 0x10, 0x73, 0x74,
+	0x72, 0x69, 0x63, 0x74, 0x5f, 0x74, 0x65, 0x6d, 0x70, 0x6c, 0x61, 0x74, 0x65, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0f, 0x73, 0x74, 0x72, 0x69, 0x63, 0x74, 0x54, 0x65, 0x6d, 0x70,
+	0x6c, 0x61, 0x74, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x65, 0x1a, 0x39, 0x0a,
+	0x0b, 0x50, 0x61, 0x72, 0x61, 0x6d, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x22, 0x81, 0x01, 0x0a, 0x13, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12, 0x35, 0x0a,
+	0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x2a, 0x99,
+	0x01, 0x0a, 0x0f, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x53, 0x6f, 0x72, 0x74, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x12, 0x21, 0x0a, 0x1d, 0x42, 0x41, 0x4e, 0x4e, 0x45, 0x52, 0x5f, 0x53, 0x4f, 0x52,
+	0x54, 0x5f, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46,
+	0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1f, 0x0a, 0x1b, 0x42, 0x41, 0x4e, 0x4e, 0x45, 0x52, 0x5f,
+	0x53, 0x4f, 0x52, 0x54, 0x5f, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x42, 0x41, 0x4e, 0x4e, 0x45,
+	0x52, 0x5f, 0x49, 0x44, 0x10, 0x01, 0x12, 0x20, 0x0a, 0x1c, 0x42, 0x41, 0x4e, 0x4e, 0x45, 0x52,
+	0x5f, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x43, 0x52, 0x45, 0x41,
+	0x54, 0x45, 0x44, 0x5f, 0x41, 0x54, 0x10, 0x02, 0x12, 0x20, 0x0a, 0x1c, 0x42, 0x41, 0x4e, 0x4e,
+	0x45, 0x52, 0x5f, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x46, 0x49, 0x45, 0x4c, 0x44, 0x5f, 0x55, 0x50,
+	0x44, 0x41, 0x54, 0x45, 0x44, 0x5f, 0x41, 0x54, 0x10, 0x03, 0x2a, 0xa2, 0x01, 0x0a, 0x12, 0x42,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x24, 0x0a, 0x20, 0x42, 0x41, 0x4e, 0x4e, 0x45, 0x52, 0x5f, 0x55, 0x50, 0x44, 0x41,
+	0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43,
+	0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x1f, 0x0a, 0x1b, 0x42, 0x41, 0x4e, 0x4e, 0x45,
+	0x52, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x42, 0x41, 0x4e, 0x4e, 0x45, 0x52, 0x10, 0x01, 0x12, 0x21, 0x0a, 0x1d, 0x42, 0x41, 0x4e, 0x4e,
+	0x45, 0x52, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53,
+	0x5f, 0x49, 0x4e, 0x41, 0x43, 0x54, 0x49, 0x56, 0x45, 0x10, 0x02, 0x12, 0x22, 0x0a, 0x1e, 0x42,
+	0x41, 0x4e, 0x4e, 0x45, 0x52, 0x5f, 0x55, 0x50, 0x44, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x41,
+	0x54, 0x55, 0x53, 0x5f, 0x4e, 0x4f, 0x54, 0x5f, 0x46, 0x4f, 0x55, 0x4e, 0x44, 0x10, 0x03, 0x32,
+	0xe1, 0x05, 0x0a, 0x0d, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
+	0x65, 0x12, 0x46, 0x0a, 0x09, 0x47, 0x65, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x1b,
+	0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x62, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0b, 0x4c, 0x69, 0x73,
+	0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x1d, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x52, 0x0a, 0x0d, 0x53, 0x65, 0x61, 0x72, 0x63,
+	0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x73, 0x12, 0x1f, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x62, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0c, 0x43,
+	0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x1e, 0x2e, 0x62, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x62, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x42, 0x61,
+	0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0c,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x1e, 0x2e, 0x62,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x62,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x42,
+	0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a,
+	0x0c, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x12, 0x1e, 0x2e,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e,
+	0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f,
+	0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1e,
+	0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f,
+	0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x52, 0x0a, 0x0d, 0x43, 0x68, 0x6f, 0x6f, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x1f, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x6f,
+	0x6f, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x20, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68,
+	0x6f, 0x6f, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x4e, 0x0a, 0x0b, 0x57, 0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x12, 0x1d, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57,
+	0x61, 0x74, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x1e, 0x2e, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x42, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x30, 0x01, 0x42, 0x2f, 0x5a, 0x2d, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2d, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x70,
+	0x62, 0x2f, 0x62, 0x61, 0x6e, 0x6e, 0x65, 0x72, 0x2f, 0x76, 0x31, 0x3b, 0x62, 0x61, 0x6e, 0x6e,
+	0x65, 0x72, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_banner_v1_banner_proto_rawDescOnce sync.Once
+	file_banner_v1_banner_proto_rawDescData = file_banner_v1_banner_proto_rawDesc
+)
+
+func file_banner_v1_banner_proto_rawDescGZIP() []byte {
+	file_banner_v1_banner_proto_rawDescOnce.Do(func() {
+		file_banner_v1_banner_proto_rawDescData = protoimpl.X.CompressGZIP(file_banner_v1_banner_proto_rawDescData)
+	})
+	return file_banner_v1_banner_proto_rawDescData
+}
+
+var file_banner_v1_banner_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_banner_v1_banner_proto_msgTypes = make([]protoimpl.MessageInfo, 24)
+var file_banner_v1_banner_proto_goTypes = []any{
+	(BannerSortField)(0),          // 0: banner.v1.BannerSortField
+	(BannerUpdateStatus)(0),       // 1: banner.v1.BannerUpdateStatus
+	(*Banner)(nil),                // 2: banner.v1.Banner
+	(*FrequencyCap)(nil),          // 3: banner.v1.FrequencyCap
+	(*TagIds)(nil),                // 4: banner.v1.TagIds
+	(*GetBannerRequest)(nil),      // 5: banner.v1.GetBannerRequest
+	(*GetBannerResponse)(nil),     // 6: banner.v1.GetBannerResponse
+	(*ListBannersRequest)(nil),    // 7: banner.v1.ListBannersRequest
+	(*ListBannersResponse)(nil),   // 8: banner.v1.ListBannersResponse
+	(*SearchBannersRequest)(nil),  // 9: banner.v1.SearchBannersRequest
+	(*SearchResult)(nil),          // 10: banner.v1.SearchResult
+	(*SearchBannersResponse)(nil), // 11: banner.v1.SearchBannersResponse
+	(*CreateBannerRequest)(nil),   // 12: banner.v1.CreateBannerRequest
+	(*CreateBannerResponse)(nil),  // 13: banner.v1.CreateBannerResponse
+	(*UpdateBannerRequest)(nil),   // 14: banner.v1.UpdateBannerRequest
+	(*UpdateBannerResponse)(nil),  // 15: banner.v1.UpdateBannerResponse
+	(*DeleteBannerRequest)(nil),   // 16: banner.v1.DeleteBannerRequest
+	(*DeleteBannerResponse)(nil),  // 17: banner.v1.DeleteBannerResponse
+	(*ListVersionsRequest)(nil),   // 18: banner.v1.ListVersionsRequest
+	(*ListVersionsResponse)(nil),  // 19: banner.v1.ListVersionsResponse
+	(*ChooseVersionRequest)(nil),  // 20: banner.v1.ChooseVersionRequest
+	(*ChooseVersionResponse)(nil), // 21: banner.v1.ChooseVersionResponse
+	(*WatchBannerRequest)(nil),    // 22: banner.v1.WatchBannerRequest
+	(*WatchBannerResponse)(nil),   // 23: banner.v1.WatchBannerResponse
+	nil,                           // 24: banner.v1.GetBannerRequest.ParamsEntry
+	nil,                           // 25: banner.v1.WatchBannerRequest.ParamsEntry
+	(*timestamppb.Timestamp)(nil), // 26: google.protobuf.Timestamp
+}
+var file_banner_v1_banner_proto_depIdxs = []int32{
+	26, // 0: banner.v1.Banner.created_at:type_name -> google.protobuf.Timestamp
+	26, // 1: banner.v1.Banner.updated_at:type_name -> google.protobuf.Timestamp
+	3,  // 2: banner.v1.Banner.frequency_cap:type_name -> banner.v1.FrequencyCap
+	24, // 3: banner.v1.GetBannerRequest.params:type_name -> banner.v1.GetBannerRequest.ParamsEntry
+	0,  // 4: banner.v1.ListBannersRequest.sort_by:type_name -> banner.v1.BannerSortField
+	26, // 5: banner.v1.ListBannersRequest.created_from:type_name -> google.protobuf.Timestamp
+	26, // 6: banner.v1.ListBannersRequest.created_to:type_name -> google.protobuf.Timestamp
+	26, // 7: banner.v1.ListBannersRequest.updated_from:type_name -> google.protobuf.Timestamp
+	26, // 8: banner.v1.ListBannersRequest.updated_to:type_name -> google.protobuf.Timestamp
+	2,  // 9: banner.v1.ListBannersResponse.banners:type_name -> banner.v1.Banner
+	2,  // 10: banner.v1.SearchResult.banner:type_name -> banner.v1.Banner
+	10, // 11: banner.v1.SearchBannersResponse.results:type_name -> banner.v1.SearchResult
+	3,  // 12: banner.v1.CreateBannerRequest.frequency_cap:type_name -> banner.v1.FrequencyCap
+	4,  // 13: banner.v1.UpdateBannerRequest.tag_ids:type_name -> banner.v1.TagIds
+	3,  // 14: banner.v1.UpdateBannerRequest.frequency_cap:type_name -> banner.v1.FrequencyCap
+	2,  // 15: banner.v1.ListVersionsResponse.banners:type_name -> banner.v1.Banner
+	25, // 16: banner.v1.WatchBannerRequest.params:type_name -> banner.v1.WatchBannerRequest.ParamsEntry
+	1,  // 17: banner.v1.WatchBannerResponse.status:type_name -> banner.v1.BannerUpdateStatus
+	5,  // 18: banner.v1.BannerService.GetBanner:input_type -> banner.v1.GetBannerRequest
+	7,  // 19: banner.v1.BannerService.ListBanners:input_type -> banner.v1.ListBannersRequest
+	9,  // 20: banner.v1.BannerService.SearchBanners:input_type -> banner.v1.SearchBannersRequest
+	12, // 21: banner.v1.BannerService.CreateBanner:input_type -> banner.v1.CreateBannerRequest
+	14, // 22: banner.v1.BannerService.UpdateBanner:input_type -> banner.v1.UpdateBannerRequest
+	16, // 23: banner.v1.BannerService.DeleteBanner:input_type -> banner.v1.DeleteBannerRequest
+	18, // 24: banner.v1.BannerService.ListVersions:input_type -> banner.v1.ListVersionsRequest
+	20, // 25: banner.v1.BannerService.ChooseVersion:input_type -> banner.v1.ChooseVersionRequest
+	22, // 26: banner.v1.BannerService.WatchBanner:input_type -> banner.v1.WatchBannerRequest
+	6,  // 27: banner.v1.BannerService.GetBanner:output_type -> banner.v1.GetBannerResponse
+	8,  // 28: banner.v1.BannerService.ListBanners:output_type -> banner.v1.ListBannersResponse
+	11, // 29: banner.v1.BannerService.SearchBanners:output_type -> banner.v1.SearchBannersResponse
+	13, // 30: banner.v1.BannerService.CreateBanner:output_type -> banner.v1.CreateBannerResponse
+	15, // 31: banner.v1.BannerService.UpdateBanner:output_type -> banner.v1.UpdateBannerResponse
+	17, // 32: banner.v1.BannerService.DeleteBanner:output_type -> banner.v1.DeleteBannerResponse
+	19, // 33: banner.v1.BannerService.ListVersions:output_type -> banner.v1.ListVersionsResponse
+	21, // 34: banner.v1.BannerService.ChooseVersion:output_type -> banner.v1.ChooseVersionResponse
+	23, // 35: banner.v1.BannerService.WatchBanner:output_type -> banner.v1.WatchBannerResponse
+	27, // [27:36] is the sub-list for method output_type
+	18, // [18:27] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_banner_v1_banner_proto_init() }
+func file_banner_v1_banner_proto_init() {
+	if File_banner_v1_banner_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_banner_v1_banner_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Banner); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*FrequencyCap); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*TagIds); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*GetBannerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*GetBannerResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ListBannersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ListBannersResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*SearchBannersRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*SearchResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*SearchBannersResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateBannerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateBannerResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*UpdateBannerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*UpdateBannerResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteBannerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[15].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteBannerResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*ListVersionsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*ListVersionsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[18].Exporter = func(v any, i int) any {
+			switch v := v.(*ChooseVersionRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[19].Exporter = func(v any, i int) any {
+			switch v := v.(*ChooseVersionResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[20].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchBannerRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_banner_v1_banner_proto_msgTypes[21].Exporter = func(v any, i int) any {
+			switch v := v.(*WatchBannerResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_banner_v1_banner_proto_msgTypes[5].OneofWrappers = []any{}
+	file_banner_v1_banner_proto_msgTypes[6].OneofWrappers = []any{}
+	file_banner_v1_banner_proto_msgTypes[12].OneofWrappers = []any{}
+	file_banner_v1_banner_proto_msgTypes[20].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_banner_v1_banner_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   24,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_banner_v1_banner_proto_goTypes,
+		DependencyIndexes: file_banner_v1_banner_proto_depIdxs,
+		EnumInfos:         file_banner_v1_banner_proto_enumTypes,
+		MessageInfos:      file_banner_v1_banner_proto_msgTypes,
+	}.Build()
+	File_banner_v1_banner_proto = out.File
+	file_banner_v1_banner_proto_rawDesc = nil
+	file_banner_v1_banner_proto_goTypes = nil
+	file_banner_v1_banner_proto_depIdxs = nil
+}
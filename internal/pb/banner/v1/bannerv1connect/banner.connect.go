@@ -0,0 +1,358 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: banner/v1/banner.proto
+
+package bannerv1connect
+
+import (
+	v1 "banner-service/internal/pb/banner/v1"
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// BannerServiceName is the fully-qualified name of the BannerService service.
+	BannerServiceName = "banner.v1.BannerService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// BannerServiceGetBannerProcedure is the fully-qualified name of the BannerService's GetBanner RPC.
+	BannerServiceGetBannerProcedure = "/banner.v1.BannerService/GetBanner"
+	// BannerServiceListBannersProcedure is the fully-qualified name of the BannerService's ListBanners
+	// RPC.
+	BannerServiceListBannersProcedure = "/banner.v1.BannerService/ListBanners"
+	// BannerServiceSearchBannersProcedure is the fully-qualified name of the BannerService's
+	// SearchBanners RPC.
+	BannerServiceSearchBannersProcedure = "/banner.v1.BannerService/SearchBanners"
+	// BannerServiceCreateBannerProcedure is the fully-qualified name of the BannerService's
+	// CreateBanner RPC.
+	BannerServiceCreateBannerProcedure = "/banner.v1.BannerService/CreateBanner"
+	// BannerServiceUpdateBannerProcedure is the fully-qualified name of the BannerService's
+	// UpdateBanner RPC.
+	BannerServiceUpdateBannerProcedure = "/banner.v1.BannerService/UpdateBanner"
+	// BannerServiceDeleteBannerProcedure is the fully-qualified name of the BannerService's
+	// DeleteBanner RPC.
+	BannerServiceDeleteBannerProcedure = "/banner.v1.BannerService/DeleteBanner"
+	// BannerServiceListVersionsProcedure is the fully-qualified name of the BannerService's
+	// ListVersions RPC.
+	BannerServiceListVersionsProcedure = "/banner.v1.BannerService/ListVersions"
+	// BannerServiceChooseVersionProcedure is the fully-qualified name of the BannerService's
+	// ChooseVersion RPC.
+	BannerServiceChooseVersionProcedure = "/banner.v1.BannerService/ChooseVersion"
+	// BannerServiceWatchBannerProcedure is the fully-qualified name of the BannerService's WatchBanner
+	// RPC.
+	BannerServiceWatchBannerProcedure = "/banner.v1.BannerService/WatchBanner"
+)
+
+// BannerServiceClient is a client for the banner.v1.BannerService service.
+type BannerServiceClient interface {
+	// GET /user_banner
+	GetBanner(context.Context, *connect.Request[v1.GetBannerRequest]) (*connect.Response[v1.GetBannerResponse], error)
+	// GET /banner
+	ListBanners(context.Context, *connect.Request[v1.ListBannersRequest]) (*connect.Response[v1.ListBannersResponse], error)
+	// GET /banner/search
+	SearchBanners(context.Context, *connect.Request[v1.SearchBannersRequest]) (*connect.Response[v1.SearchBannersResponse], error)
+	// POST /banner
+	CreateBanner(context.Context, *connect.Request[v1.CreateBannerRequest]) (*connect.Response[v1.CreateBannerResponse], error)
+	// PATCH /banner/{banner_id}
+	UpdateBanner(context.Context, *connect.Request[v1.UpdateBannerRequest]) (*connect.Response[v1.UpdateBannerResponse], error)
+	// DELETE /banner/{banner_id}
+	DeleteBanner(context.Context, *connect.Request[v1.DeleteBannerRequest]) (*connect.Response[v1.DeleteBannerResponse], error)
+	// GET /banner/versions/{banner_id}
+	ListVersions(context.Context, *connect.Request[v1.ListVersionsRequest]) (*connect.Response[v1.ListVersionsResponse], error)
+	// PATCH /banner/{banner_id}/version/{version}
+	ChooseVersion(context.Context, *connect.Request[v1.ChooseVersionRequest]) (*connect.Response[v1.ChooseVersionResponse], error)
+	// GET /user_banner/stream
+	WatchBanner(context.Context, *connect.Request[v1.WatchBannerRequest]) (*connect.ServerStreamForClient[v1.WatchBannerResponse], error)
+}
+
+// NewBannerServiceClient constructs a client for the banner.v1.BannerService service. By default,
+// it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses, and
+// sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the connect.WithGRPC()
+// or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewBannerServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) BannerServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	bannerServiceMethods := v1.File_banner_v1_banner_proto.Services().ByName("BannerService").Methods()
+	return &bannerServiceClient{
+		getBanner: connect.NewClient[v1.GetBannerRequest, v1.GetBannerResponse](
+			httpClient,
+			baseURL+BannerServiceGetBannerProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("GetBanner")),
+			connect.WithClientOptions(opts...),
+		),
+		listBanners: connect.NewClient[v1.ListBannersRequest, v1.ListBannersResponse](
+			httpClient,
+			baseURL+BannerServiceListBannersProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("ListBanners")),
+			connect.WithClientOptions(opts...),
+		),
+		searchBanners: connect.NewClient[v1.SearchBannersRequest, v1.SearchBannersResponse](
+			httpClient,
+			baseURL+BannerServiceSearchBannersProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("SearchBanners")),
+			connect.WithClientOptions(opts...),
+		),
+		createBanner: connect.NewClient[v1.CreateBannerRequest, v1.CreateBannerResponse](
+			httpClient,
+			baseURL+BannerServiceCreateBannerProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("CreateBanner")),
+			connect.WithClientOptions(opts...),
+		),
+		updateBanner: connect.NewClient[v1.UpdateBannerRequest, v1.UpdateBannerResponse](
+			httpClient,
+			baseURL+BannerServiceUpdateBannerProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("UpdateBanner")),
+			connect.WithClientOptions(opts...),
+		),
+		deleteBanner: connect.NewClient[v1.DeleteBannerRequest, v1.DeleteBannerResponse](
+			httpClient,
+			baseURL+BannerServiceDeleteBannerProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("DeleteBanner")),
+			connect.WithClientOptions(opts...),
+		),
+		listVersions: connect.NewClient[v1.ListVersionsRequest, v1.ListVersionsResponse](
+			httpClient,
+			baseURL+BannerServiceListVersionsProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("ListVersions")),
+			connect.WithClientOptions(opts...),
+		),
+		chooseVersion: connect.NewClient[v1.ChooseVersionRequest, v1.ChooseVersionResponse](
+			httpClient,
+			baseURL+BannerServiceChooseVersionProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("ChooseVersion")),
+			connect.WithClientOptions(opts...),
+		),
+		watchBanner: connect.NewClient[v1.WatchBannerRequest, v1.WatchBannerResponse](
+			httpClient,
+			baseURL+BannerServiceWatchBannerProcedure,
+			connect.WithSchema(bannerServiceMethods.ByName("WatchBanner")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// bannerServiceClient implements BannerServiceClient.
+type bannerServiceClient struct {
+	getBanner     *connect.Client[v1.GetBannerRequest, v1.GetBannerResponse]
+	listBanners   *connect.Client[v1.ListBannersRequest, v1.ListBannersResponse]
+	searchBanners *connect.Client[v1.SearchBannersRequest, v1.SearchBannersResponse]
+	createBanner  *connect.Client[v1.CreateBannerRequest, v1.CreateBannerResponse]
+	updateBanner  *connect.Client[v1.UpdateBannerRequest, v1.UpdateBannerResponse]
+	deleteBanner  *connect.Client[v1.DeleteBannerRequest, v1.DeleteBannerResponse]
+	listVersions  *connect.Client[v1.ListVersionsRequest, v1.ListVersionsResponse]
+	chooseVersion *connect.Client[v1.ChooseVersionRequest, v1.ChooseVersionResponse]
+	watchBanner   *connect.Client[v1.WatchBannerRequest, v1.WatchBannerResponse]
+}
+
+// GetBanner calls banner.v1.BannerService.GetBanner.
+func (c *bannerServiceClient) GetBanner(ctx context.Context, req *connect.Request[v1.GetBannerRequest]) (*connect.Response[v1.GetBannerResponse], error) {
+	return c.getBanner.CallUnary(ctx, req)
+}
+
+// ListBanners calls banner.v1.BannerService.ListBanners.
+func (c *bannerServiceClient) ListBanners(ctx context.Context, req *connect.Request[v1.ListBannersRequest]) (*connect.Response[v1.ListBannersResponse], error) {
+	return c.listBanners.CallUnary(ctx, req)
+}
+
+// SearchBanners calls banner.v1.BannerService.SearchBanners.
+func (c *bannerServiceClient) SearchBanners(ctx context.Context, req *connect.Request[v1.SearchBannersRequest]) (*connect.Response[v1.SearchBannersResponse], error) {
+	return c.searchBanners.CallUnary(ctx, req)
+}
+
+// CreateBanner calls banner.v1.BannerService.CreateBanner.
+func (c *bannerServiceClient) CreateBanner(ctx context.Context, req *connect.Request[v1.CreateBannerRequest]) (*connect.Response[v1.CreateBannerResponse], error) {
+	return c.createBanner.CallUnary(ctx, req)
+}
+
+// UpdateBanner calls banner.v1.BannerService.UpdateBanner.
+func (c *bannerServiceClient) UpdateBanner(ctx context.Context, req *connect.Request[v1.UpdateBannerRequest]) (*connect.Response[v1.UpdateBannerResponse], error) {
+	return c.updateBanner.CallUnary(ctx, req)
+}
+
+// DeleteBanner calls banner.v1.BannerService.DeleteBanner.
+func (c *bannerServiceClient) DeleteBanner(ctx context.Context, req *connect.Request[v1.DeleteBannerRequest]) (*connect.Response[v1.DeleteBannerResponse], error) {
+	return c.deleteBanner.CallUnary(ctx, req)
+}
+
+// ListVersions calls banner.v1.BannerService.ListVersions.
+func (c *bannerServiceClient) ListVersions(ctx context.Context, req *connect.Request[v1.ListVersionsRequest]) (*connect.Response[v1.ListVersionsResponse], error) {
+	return c.listVersions.CallUnary(ctx, req)
+}
+
+// ChooseVersion calls banner.v1.BannerService.ChooseVersion.
+func (c *bannerServiceClient) ChooseVersion(ctx context.Context, req *connect.Request[v1.ChooseVersionRequest]) (*connect.Response[v1.ChooseVersionResponse], error) {
+	return c.chooseVersion.CallUnary(ctx, req)
+}
+
+// WatchBanner calls banner.v1.BannerService.WatchBanner.
+func (c *bannerServiceClient) WatchBanner(ctx context.Context, req *connect.Request[v1.WatchBannerRequest]) (*connect.ServerStreamForClient[v1.WatchBannerResponse], error) {
+	return c.watchBanner.CallServerStream(ctx, req)
+}
+
+// BannerServiceHandler is an implementation of the banner.v1.BannerService service.
+type BannerServiceHandler interface {
+	// GET /user_banner
+	GetBanner(context.Context, *connect.Request[v1.GetBannerRequest]) (*connect.Response[v1.GetBannerResponse], error)
+	// GET /banner
+	ListBanners(context.Context, *connect.Request[v1.ListBannersRequest]) (*connect.Response[v1.ListBannersResponse], error)
+	// GET /banner/search
+	SearchBanners(context.Context, *connect.Request[v1.SearchBannersRequest]) (*connect.Response[v1.SearchBannersResponse], error)
+	// POST /banner
+	CreateBanner(context.Context, *connect.Request[v1.CreateBannerRequest]) (*connect.Response[v1.CreateBannerResponse], error)
+	// PATCH /banner/{banner_id}
+	UpdateBanner(context.Context, *connect.Request[v1.UpdateBannerRequest]) (*connect.Response[v1.UpdateBannerResponse], error)
+	// DELETE /banner/{banner_id}
+	DeleteBanner(context.Context, *connect.Request[v1.DeleteBannerRequest]) (*connect.Response[v1.DeleteBannerResponse], error)
+	// GET /banner/versions/{banner_id}
+	ListVersions(context.Context, *connect.Request[v1.ListVersionsRequest]) (*connect.Response[v1.ListVersionsResponse], error)
+	// PATCH /banner/{banner_id}/version/{version}
+	ChooseVersion(context.Context, *connect.Request[v1.ChooseVersionRequest]) (*connect.Response[v1.ChooseVersionResponse], error)
+	// GET /user_banner/stream
+	WatchBanner(context.Context, *connect.Request[v1.WatchBannerRequest], *connect.ServerStream[v1.WatchBannerResponse]) error
+}
+
+// NewBannerServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewBannerServiceHandler(svc BannerServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	bannerServiceMethods := v1.File_banner_v1_banner_proto.Services().ByName("BannerService").Methods()
+	bannerServiceGetBannerHandler := connect.NewUnaryHandler(
+		BannerServiceGetBannerProcedure,
+		svc.GetBanner,
+		connect.WithSchema(bannerServiceMethods.ByName("GetBanner")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceListBannersHandler := connect.NewUnaryHandler(
+		BannerServiceListBannersProcedure,
+		svc.ListBanners,
+		connect.WithSchema(bannerServiceMethods.ByName("ListBanners")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceSearchBannersHandler := connect.NewUnaryHandler(
+		BannerServiceSearchBannersProcedure,
+		svc.SearchBanners,
+		connect.WithSchema(bannerServiceMethods.ByName("SearchBanners")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceCreateBannerHandler := connect.NewUnaryHandler(
+		BannerServiceCreateBannerProcedure,
+		svc.CreateBanner,
+		connect.WithSchema(bannerServiceMethods.ByName("CreateBanner")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceUpdateBannerHandler := connect.NewUnaryHandler(
+		BannerServiceUpdateBannerProcedure,
+		svc.UpdateBanner,
+		connect.WithSchema(bannerServiceMethods.ByName("UpdateBanner")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceDeleteBannerHandler := connect.NewUnaryHandler(
+		BannerServiceDeleteBannerProcedure,
+		svc.DeleteBanner,
+		connect.WithSchema(bannerServiceMethods.ByName("DeleteBanner")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceListVersionsHandler := connect.NewUnaryHandler(
+		BannerServiceListVersionsProcedure,
+		svc.ListVersions,
+		connect.WithSchema(bannerServiceMethods.ByName("ListVersions")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceChooseVersionHandler := connect.NewUnaryHandler(
+		BannerServiceChooseVersionProcedure,
+		svc.ChooseVersion,
+		connect.WithSchema(bannerServiceMethods.ByName("ChooseVersion")),
+		connect.WithHandlerOptions(opts...),
+	)
+	bannerServiceWatchBannerHandler := connect.NewServerStreamHandler(
+		BannerServiceWatchBannerProcedure,
+		svc.WatchBanner,
+		connect.WithSchema(bannerServiceMethods.ByName("WatchBanner")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/banner.v1.BannerService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case BannerServiceGetBannerProcedure:
+			bannerServiceGetBannerHandler.ServeHTTP(w, r)
+		case BannerServiceListBannersProcedure:
+			bannerServiceListBannersHandler.ServeHTTP(w, r)
+		case BannerServiceSearchBannersProcedure:
+			bannerServiceSearchBannersHandler.ServeHTTP(w, r)
+		case BannerServiceCreateBannerProcedure:
+			bannerServiceCreateBannerHandler.ServeHTTP(w, r)
+		case BannerServiceUpdateBannerProcedure:
+			bannerServiceUpdateBannerHandler.ServeHTTP(w, r)
+		case BannerServiceDeleteBannerProcedure:
+			bannerServiceDeleteBannerHandler.ServeHTTP(w, r)
+		case BannerServiceListVersionsProcedure:
+			bannerServiceListVersionsHandler.ServeHTTP(w, r)
+		case BannerServiceChooseVersionProcedure:
+			bannerServiceChooseVersionHandler.ServeHTTP(w, r)
+		case BannerServiceWatchBannerProcedure:
+			bannerServiceWatchBannerHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedBannerServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedBannerServiceHandler struct{}
+
+func (UnimplementedBannerServiceHandler) GetBanner(context.Context, *connect.Request[v1.GetBannerRequest]) (*connect.Response[v1.GetBannerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.GetBanner is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) ListBanners(context.Context, *connect.Request[v1.ListBannersRequest]) (*connect.Response[v1.ListBannersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.ListBanners is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) SearchBanners(context.Context, *connect.Request[v1.SearchBannersRequest]) (*connect.Response[v1.SearchBannersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.SearchBanners is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) CreateBanner(context.Context, *connect.Request[v1.CreateBannerRequest]) (*connect.Response[v1.CreateBannerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.CreateBanner is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) UpdateBanner(context.Context, *connect.Request[v1.UpdateBannerRequest]) (*connect.Response[v1.UpdateBannerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.UpdateBanner is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) DeleteBanner(context.Context, *connect.Request[v1.DeleteBannerRequest]) (*connect.Response[v1.DeleteBannerResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.DeleteBanner is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) ListVersions(context.Context, *connect.Request[v1.ListVersionsRequest]) (*connect.Response[v1.ListVersionsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.ListVersions is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) ChooseVersion(context.Context, *connect.Request[v1.ChooseVersionRequest]) (*connect.Response[v1.ChooseVersionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.ChooseVersion is not implemented"))
+}
+
+func (UnimplementedBannerServiceHandler) WatchBanner(context.Context, *connect.Request[v1.WatchBannerRequest], *connect.ServerStream[v1.WatchBannerResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("banner.v1.BannerService.WatchBanner is not implemented"))
+}
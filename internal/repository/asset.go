@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"banner-service/internal/asset"
+	"banner-service/internal/models"
+	"banner-service/internal/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type AssetRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAssetRepository(p *pgxpool.Pool) *AssetRepository {
+	return &AssetRepository{
+		pool: p,
+	}
+}
+
+// assetLockClass is the first key of the advisory locks on single assets; the
+// second is the hash of the asset hash. An upload and the collector take the
+// lock of the asset before they touch its file.
+const assetLockClass = 0x61737374
+
+const lockAssetQuery = `select pg_advisory_xact_lock($1, hashtext($2))`
+
+// CreateAsset records an uploaded file, which place moves into place under the
+// lock of the asset. The same content uploaded again keeps the first record,
+// and created reports whether this call added it; either way the grace period
+// of the collector starts anew.
+func (a *AssetRepository) CreateAsset(ctx context.Context, asset *models.Asset, place func() error) (models.Asset, bool, error) {
+	const (
+		upsertAssetQuery = `
+            insert into asset (hash, mime_type, size, created_by)
+            values ($1, $2, $3, $4)
+            on conflict (hash) do update set uploaded_at = now()
+            returning hash, mime_type, size, created_by, created_at, xmax = 0 as created`
+	)
+
+	var stored struct {
+		models.Asset
+		Created bool `db:"created"`
+	}
+	err := RunInTx(ctx, a.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, lockAssetQuery, assetLockClass, asset.Hash); err != nil {
+			return err
+		}
+		if err := place(); err != nil {
+			return err
+		}
+		return pgxscan.Get(ctx, tx, &stored, upsertAssetQuery, asset.Hash, asset.MimeType, asset.Size, reqctx.GetUsername(ctx))
+	})
+	if err != nil {
+		return models.Asset{}, false, err
+	}
+
+	return stored.Asset, stored.Created, nil
+}
+
+func (a *AssetRepository) GetAsset(ctx context.Context, hash string) (models.Asset, error) {
+	const (
+		selectAssetQuery = `
+            select hash, mime_type, size, created_by, created_at
+            from asset
+            where hash = $1`
+	)
+
+	var asset models.Asset
+	if err := pgxscan.Get(ctx, a.pool, &asset, selectAssetQuery, hash); errors.Is(err, pgx.ErrNoRows) {
+		return models.Asset{}, ErrNotFound
+	} else if err != nil {
+		return models.Asset{}, err
+	}
+
+	return asset, nil
+}
+
+// GetMissingAssets returns the hashes there is no asset for.
+func (a *AssetRepository) GetMissingAssets(ctx context.Context, hashes []string) ([]string, error) {
+	const (
+		selectMissingQuery = `
+            select h.hash
+            from unnest($1::text[]) as h(hash)
+            where not exists (select 1 from asset a where a.hash = h.hash)
+            order by h.hash`
+	)
+
+	missing := make([]string, 0)
+	if err := pgxscan.Select(ctx, a.pool, &missing, selectMissingQuery, hashes); err != nil {
+		return nil, err
+	}
+
+	return missing, nil
+}
+
+// DeleteUnreferencedAssets removes the assets uploaded before the grace
+// period that no banner version, active or not, refers to, and returns their
+// hashes. The grace period gives a fresh upload time to get referenced. The
+// record of each asset is deleted under its lock, and its file (with remove)
+// only once that is committed: a file left by a failed removal is harmless, a
+// record without its file is not. The file is removed under the lock again,
+// and kept if the same content has been uploaded since.
+func (a *AssetRepository) DeleteUnreferencedAssets(ctx context.Context, gracePeriod time.Duration, remove func(hash string) error) ([]string, error) {
+	const (
+		unreferencedCondition = `
+            a.uploaded_at < now() - $1 * interval '1 second'
+            and not exists (select 1 from banner_version_asset r where r.hash = a.hash)`
+
+		selectUnreferencedQuery = `select a.hash from asset a where` + unreferencedCondition
+
+		// The condition is checked again under the lock: the asset may have
+		// been uploaded or referenced since.
+		deleteUnreferencedQuery = `delete from asset a where a.hash = $2 and` + unreferencedCondition + ` returning a.hash`
+
+		existsQuery = `select exists (select 1 from asset where hash = $1)`
+	)
+
+	seconds := gracePeriod.Seconds()
+	candidates := make([]string, 0)
+	if err := pgxscan.Select(ctx, a.pool, &candidates, selectUnreferencedQuery, seconds); err != nil {
+		return nil, err
+	}
+
+	deleted := make([]string, 0, len(candidates))
+	for _, hash := range candidates {
+		var removed []string
+		err := RunInTx(ctx, a.pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, lockAssetQuery, assetLockClass, hash); err != nil {
+				return err
+			}
+			return pgxscan.Select(ctx, tx, &removed, deleteUnreferencedQuery, seconds, hash)
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("asset %s: %w", hash, err)
+		} else if len(removed) == 0 {
+			continue
+		}
+		deleted = append(deleted, hash)
+
+		err = RunInTx(ctx, a.pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, lockAssetQuery, assetLockClass, hash); err != nil {
+				return err
+			}
+			var uploaded bool
+			if err := pgxscan.Get(ctx, tx, &uploaded, existsQuery, hash); err != nil {
+				return err
+			} else if uploaded {
+				return nil
+			}
+			return remove(hash)
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("asset %s: file: %w", hash, err)
+		}
+	}
+
+	return deleted, nil
+}
+
+// referenceAssets records the assets the banner versions refer to. The key
+// on asset locks each of them until the transaction ends, so the collector
+// cannot remove one the versions are about to refer to; a missing one fails
+// with ErrInvalidReference.
+func referenceAssets(ctx context.Context, tx pgx.Tx, versions []versionAssets) error {
+	var rows [][]any
+	for _, version := range versions {
+		for _, hash := range version.hashes {
+			rows = append(rows, []any{version.bannerId, version.version, hash})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	_, err := tx.CopyFrom(ctx, pgx.Identifier{"banner_version_asset"}, []string{"banner_id", "version", "hash"}, pgx.CopyFromRows(rows))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return fmt.Errorf("%w: unknown assets: %s", ErrInvalidReference, pgErr.Detail)
+	}
+	return err
+}
+
+// versionAssets are the assets a banner version refers to.
+type versionAssets struct {
+	bannerId uint64
+	version  uint64
+	hashes   []string
+}
+
+// newVersionAssets finds the assets the content of a banner version refers to.
+func newVersionAssets(bannerId, version uint64, content json.RawMessage, localized map[string]json.RawMessage) versionAssets {
+	return versionAssets{bannerId: bannerId, version: version, hashes: asset.VersionReferences(content, localized)}
+}
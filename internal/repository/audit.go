@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"banner-service/internal/cursor"
+	"banner-service/internal/models"
+	"banner-service/internal/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type auditPosition struct {
+	EventId uint64 `json:"id"`
+}
+
+func selectBannerSnapshot(ctx context.Context, q pgxscan.Querier, bannerId uint64) (*models.Banner, error) {
+	const (
+		selectSnapshotQuery = `
+            select b.banner_id, bft.feature_id, array_agg(distinct bft.tag_id) as tag_ids,
+                   bv.content, bv.localized, bv.default_locale, b.is_active, b.priority, b.frequency_cap, bv.version,
+                   b.created_at, bv.updated_at
+            from banner b
+            join banner_version bv on b.banner_id = bv.banner_id and b.active_version = bv.version
+            join banner_feature_tag bft on b.banner_id = bft.banner_id
+            where b.banner_id = $1
+            group by b.banner_id, bft.feature_id, bv.content, bv.localized, bv.default_locale, b.is_active, b.priority,
+                     b.frequency_cap, bv.version, b.created_at, bv.updated_at`
+	)
+
+	var banner models.Banner
+	if err := pgxscan.Get(ctx, q, &banner, selectSnapshotQuery, bannerId); errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &banner, nil
+}
+
+func insertAuditEvent(ctx context.Context, tx pgx.Tx, action models.AuditAction, bannerId uint64, before, after *models.Banner) error {
+	const (
+		insertAuditEventQuery = `
+            insert into audit_event (actor, action, banner_id, before, after, request_id)
+            values ($1, $2, $3, $4, $5, $6)`
+	)
+
+	beforeJSON, err := snapshotJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshotJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, insertAuditEventQuery,
+		reqctx.GetUsername(ctx), action, bannerId, beforeJSON, afterJSON, reqctx.GetRequestId(ctx))
+
+	return err
+}
+
+func snapshotJSON(banner *models.Banner) (*string, error) {
+	if banner == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(banner)
+	if err != nil {
+		return nil, err
+	}
+	str := string(raw)
+	return &str, nil
+}
+
+func (b *BannerRepository) GetAuditEvents(ctx context.Context, filter *models.AuditFilter) (models.AuditPage, error) {
+	const (
+		selectAuditEventsQuery = `
+            select event_id, actor, action, banner_id, before, after, request_id, created_at
+            from audit_event
+            where ($1::text is null or actor = $1)
+              and ($2::bigint is null or banner_id = $2)
+              and ($3::text is null or action = $3)
+              and ($4::timestamp is null or created_at >= $4)
+              and ($5::timestamp is null or created_at < $5)
+              and ($6::bigint is null or event_id < $6)
+            order by event_id desc
+            limit $7`
+	)
+
+	var after *uint64
+	if filter.Cursor != "" {
+		var position auditPosition
+		if err := cursor.Decode(filter.Cursor, &position); err != nil {
+			return models.AuditPage{}, err
+		}
+		after = &position.EventId
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultAuditLimit
+	} else if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+
+	var action *string
+	if filter.Action != nil {
+		str := string(*filter.Action)
+		action = &str
+	}
+
+	events := make([]models.AuditEvent, 0)
+	if err := pgxscan.Select(ctx, b.pool, &events, selectAuditEventsQuery,
+		filter.Actor, filter.BannerId, action, filter.From, filter.To, after, limit); err != nil {
+		return models.AuditPage{}, err
+	}
+
+	page := models.AuditPage{Events: events}
+	if uint64(len(events)) == limit {
+		next, err := cursor.Encode(auditPosition{EventId: events[len(events)-1].EventId})
+		if err != nil {
+			return models.AuditPage{}, err
+		}
+		page.NextCursor = next
+	}
+
+	return page, nil
+}
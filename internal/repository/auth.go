@@ -5,6 +5,7 @@ import (
 	"context"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 )
@@ -24,7 +25,8 @@ func (au *AuthRepository) SignUp(ctx context.Context, user *models.User) error {
 		insertUserQuery = `INSERT INTO users(username, hash_password, role) VALUES($1, $2, $3);`
 	)
 
-	if _, err := au.pool.Exec(ctx, insertUserQuery, user.Username, user.Password, user.Role); errors.Is(err, pgx.ErrNoRows) {
+	var pgErr *pgconn.PgError
+	if _, err := au.pool.Exec(ctx, insertUserQuery, user.Username, user.Password, user.Role); errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
 		return ErrAlreadyExists
 	} else if err != nil {
 		return err
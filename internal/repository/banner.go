@@ -1,12 +1,23 @@
 package repository
 
 import (
+	"banner-service/internal/cursor"
 	"banner-service/internal/models"
+	"banner-service/internal/patch"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/georgysavva/scany/v2/pgxscan"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+const (
+	defaultBannerLimit = 100
+	maxBannerLimit     = 1000
 )
 
 type BannerRepository struct {
@@ -35,17 +46,58 @@ func RunInTx(ctx context.Context, pool *pgxpool.Pool, f func(tx pgx.Tx) error) e
 	return tx.Commit(ctx)
 }
 
-func (b *BannerRepository) GetBanner(ctx context.Context, tagId uint64, featureId uint64, isAdmin bool) (models.BannerContent, error) {
+// GetBanner returns the banner of the feature and the tag. Without one it walks
+// up the parents of the tag and then falls back to the default banner of the
+// feature, as long as that banner still belongs to the feature. The walk
+// stops at a tag it has already visited, so a cycle in the hierarchy cannot
+// loop it. The banners of excluded are passed over, so the walk goes on to the
+// next ancestor. The content is served in the first of locales the banner has,
+// localized or as its default locale, and by default otherwise.
+func (b *BannerRepository) GetBanner(ctx context.Context, tagId uint64, featureId uint64, locales []string, isAdmin bool, excluded []uint64) (models.BannerContent, error) {
 	const (
-		selectBannerQuery = `select bv.content, b.is_active
-           from banner_feature_tag bft
-           join banner b using (banner_id)
-           join banner_version bv on b.banner_id = bv.banner_id and b.active_version = bv.version
-           where bft.feature_id = $1 and bft.tag_id = $2 and not must_be_deleted`
+		selectBannerQuery = `
+            with recursive ancestor (tag_id, depth, path) as (
+                select $2::int, 0, array[$2::int]
+                union all
+                select t.parent_tag_id, a.depth + 1, a.path || t.parent_tag_id
+                from ancestor a
+                join tag t on t.tag_id = a.tag_id
+                where t.parent_tag_id is not null and t.parent_tag_id <> all(a.path)
+            )
+            select coalesce(m.localized -> l.locale, m.content) as content,
+                   coalesce(l.locale, m.default_locale) as locale,
+                   m.is_active, m.tag_id, m.banner_id, m.priority, m.frequency_cap, coalesce(m.depth, 0) as depth
+            from (
+                select bv.content, bv.localized, bv.default_locale, b.is_active, a.tag_id, b.banner_id, b.priority,
+                       b.frequency_cap, a.depth
+                from ancestor a
+                join banner_feature_tag bft on bft.tag_id = a.tag_id and bft.feature_id = $1
+                join banner b on b.banner_id = bft.banner_id and b.deleted_at is null
+                    and b.banner_id <> all(coalesce($4::bigint[], '{}'))
+                join banner_version bv on bv.banner_id = b.banner_id and bv.version = b.active_version
+                union all
+                select bv.content, bv.localized, bv.default_locale, b.is_active, 0, b.banner_id, b.priority,
+                       b.frequency_cap, null
+                from feature f
+                join banner b on b.banner_id = f.default_banner_id and b.deleted_at is null
+                    and b.banner_id <> all(coalesce($4::bigint[], '{}'))
+                join banner_version bv on bv.banner_id = b.banner_id and bv.version = b.active_version
+                where f.feature_id = $1
+                  and exists (select 1 from banner_feature_tag bft where bft.banner_id = b.banner_id and bft.feature_id = $1)
+            ) m
+            left join lateral (
+                select p.locale
+                from unnest($3::text[]) with ordinality p (locale, n)
+                where m.localized ? p.locale or m.default_locale = p.locale
+                order by p.n
+                limit 1
+            ) l on true
+            order by m.depth nulls last
+            limit 1`
 	)
 
 	var bannerContent models.BannerContent
-	if err := pgxscan.Get(ctx, b.pool, &bannerContent, selectBannerQuery, featureId, tagId); errors.Is(err, pgx.ErrNoRows) {
+	if err := pgxscan.Get(ctx, b.pool, &bannerContent, selectBannerQuery, featureId, tagId, locales, excluded); errors.Is(err, pgx.ErrNoRows) {
 		return models.BannerContent{}, ErrNotFound
 	} else if err != nil {
 		return models.BannerContent{}, err
@@ -59,12 +111,12 @@ func (b *BannerRepository) GetBanner(ctx context.Context, tagId uint64, featureI
 func (b *BannerRepository) GetListOfVersions(ctx context.Context, bannerId uint64) ([]models.Banner, error) {
 	const (
 		selectBannersQuery = `SELECT b.banner_id, bft.feature_id, array_agg(DISTINCT bft.tag_id) AS tag_ids,
-              bv.content, b.is_active, bv.version, b.created_at, bv.updated_at
+              bv.content, bv.localized, bv.default_locale, b.is_active, bv.version, b.created_at, bv.updated_at
          FROM banner_version bv
          JOIN banner b USING (banner_id)
          JOIN banner_feature_tag bft USING (banner_id)
-         WHERE banner_id = $1 and b.must_be_deleted = false
-         GROUP BY b.banner_id, bft.feature_id, bv.content, b.is_active, bv.version, b.created_at, bv.updated_at`
+         WHERE banner_id = $1 and b.deleted_at is null
+         GROUP BY b.banner_id, bft.feature_id, bv.content, bv.localized, bv.default_locale, b.is_active, bv.version, b.created_at, bv.updated_at`
 	)
 	var banners []models.Banner
 
@@ -77,134 +129,378 @@ func (b *BannerRepository) GetListOfVersions(ctx context.Context, bannerId uint6
 	return banners, nil
 }
 
-func (b *BannerRepository) ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) error {
+// GetBannerById returns the banner with its active content.
+func (b *BannerRepository) GetBannerById(ctx context.Context, bannerId uint64) (models.Banner, error) {
+	banner, err := selectBannerSnapshot(ctx, b.pool, bannerId)
+	if err != nil {
+		return models.Banner{}, err
+	}
+	return *banner, nil
+}
+
+// ChooseBannerVersion makes the version active and returns the feature-tag
+// pairs of the banner.
+func (b *BannerRepository) ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) ([]models.FeatureTag, error) {
 	const (
-		chooseVersionQuery = `update banner
-						 set active_version = $2
-						 where banner_id = $1`
+		chooseVersionQuery = `
+            update banner b
+            set active_version = bv.version, updated_at = bv.updated_at
+            from banner_version bv
+            where b.banner_id = $1 and bv.banner_id = b.banner_id and bv.version = $2`
 	)
 
-	res, err := b.pool.Exec(ctx, chooseVersionQuery, bannerId, version)
-	if res.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-	return err
+	var affected []models.FeatureTag
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		before, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(ctx, chooseVersionQuery, bannerId, version)
+		if err != nil {
+			return err
+		} else if res.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+
+		after, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
+			return err
+		}
+		affected = featureTags(after.FeatureId, after.TagIds)
+
+		return recordChange(ctx, tx, models.AuditChooseVersion, bannerId, before, after)
+	})
+
+	return affected, err
 }
 
-func (b *BannerRepository) GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) ([]models.Banner, error) {
+// bannerSortColumns whitelists the columns GetFilteredBanners can sort by.
+var bannerSortColumns = map[models.BannerSortField]string{
+	models.SortByBannerId:  "b.banner_id",
+	models.SortByCreatedAt: "b.created_at",
+	models.SortByUpdatedAt: "b.updated_at",
+}
+
+// bannerSortTimes are the values GetFilteredBanners keeps in the cursor. They
+// are read from the sort columns, not from the listed banners: updated_at of
+// a listed banner is the time of its active version, which is older than the
+// banner's own when only is_active changed.
+var bannerSortTimes = map[models.BannerSortField]string{
+	models.SortByBannerId:  "null::timestamp",
+	models.SortByCreatedAt: "b.created_at",
+	models.SortByUpdatedAt: "b.updated_at",
+}
+
+// filteredBanner is a banner of a page with the value it is sorted by.
+type filteredBanner struct {
+	models.Banner
+	SortTime *time.Time `db:"sort_time"`
+}
+
+type bannerPosition struct {
+	SortBy   models.BannerSortField `json:"s"`
+	Order    models.SortOrder       `json:"o"`
+	Time     *time.Time             `json:"t,omitempty"`
+	BannerId uint64                 `json:"id"`
+}
+
+// GetFilteredBanners returns a page of banners matching every condition of
+// the filter, ordered by filter.SortBy with banner_id as a tie-breaker. Pages
+// after the first are addressed by the keyset cursor of the previous page, so
+// deep pages cost the same as the first.
+func (b *BannerRepository) GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) (models.BannerPage, error) {
 	const (
 		selectFilteredBannersQuery = `
-   	        select b.banner_id, bft.feature_id, array_agg(distinct bft.tag_id) as tag_ids,
-                      bv.content, b.is_active, bv.version, b.created_at, bv.updated_at
-            from banner b
+            with page as
+                     (select b.banner_id, b.created_at, b.updated_at
+                      from banner b
+                      where %[1]s
+                      order by %[2]s %[3]s, b.banner_id %[3]s
+                      limit %[4]s offset %[5]s)
+            select b.banner_id, bft.feature_id, array_agg(distinct bft.tag_id) as tag_ids,
+                   bv.content, bv.localized, bv.default_locale, b.is_active, b.priority, b.frequency_cap, bv.version,
+                   b.created_at, bv.updated_at, %[6]s as sort_time
+            from page p
+            join banner b on b.banner_id = p.banner_id
             join banner_version bv on b.banner_id = bv.banner_id and b.active_version = bv.version
             join banner_feature_tag bft on b.banner_id = bft.banner_id
-            where bft.feature_id = $1 or bft.tag_id = $2 and b.must_be_deleted = false
-            group by b.banner_id, bft.feature_id, bv.content, b.is_active, bv.version, b.created_at, bv.updated_at
-            order by b.banner_id desc 
-            limit $3 offset $4`
+            group by b.banner_id, bft.feature_id, bv.content, bv.localized, bv.default_locale, b.is_active, b.priority,
+                     b.frequency_cap, bv.version, b.created_at, bv.updated_at
+            order by %[2]s %[3]s, b.banner_id %[3]s`
+
+		countFilteredBannersQuery = `
+            select count(*)
+            from banner b
+            where %s`
 	)
 
-	var banners []models.Banner
-	if err := pgxscan.Select(ctx, b.pool, &banners, selectFilteredBannersQuery, filter.FeatureId, filter.TagId, filter.Limit, filter.Offset); errors.Is(err, pgx.ErrNoRows) {
-		return nil, ErrNotFound
-	} else if err != nil {
-		return nil, err
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = models.SortByBannerId
+	}
+	column, ok := bannerSortColumns[sortBy]
+	if !ok {
+		return models.BannerPage{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalidFilter, sortBy)
+	}
+	order := filter.Order
+	if order == "" {
+		order = models.OrderDesc
+	} else if order != models.OrderAsc && order != models.OrderDesc {
+		return models.BannerPage{}, fmt.Errorf("%w: unknown sort order %q", ErrInvalidFilter, order)
 	}
 
-	return banners, nil
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultBannerLimit
+	} else if limit > maxBannerLimit {
+		limit = maxBannerLimit
+	}
+
+	q := bannerFilterQuery(filter)
+	// The count query shares the filter conditions but not the keyset ones.
+	countConditions, countArgs := q.String(), q.args
+
+	offset := filter.Offset
+	if filter.Cursor != "" {
+		var position bannerPosition
+		if err := cursor.Decode(filter.Cursor, &position); err != nil {
+			return models.BannerPage{}, err
+		}
+		if position.SortBy != sortBy || position.Order != order {
+			return models.BannerPage{}, cursor.ErrInvalidCursor
+		}
+
+		cmp := "<"
+		if order == models.OrderAsc {
+			cmp = ">"
+		}
+		// The cursor replaces the offset.
+		offset = 0
+		if sortBy == models.SortByBannerId {
+			q.where("b.banner_id "+cmp+" %s", position.BannerId)
+		} else {
+			if position.Time == nil {
+				return models.BannerPage{}, cursor.ErrInvalidCursor
+			}
+			q.where("("+column+", b.banner_id) "+cmp+" (%s, %s)", *position.Time, position.BannerId)
+		}
+	}
+
+	rows := make([]filteredBanner, 0)
+	query := fmt.Sprintf(selectFilteredBannersQuery, q.String(), column, order, q.arg(limit), q.arg(offset), bannerSortTimes[sortBy])
+	if err := pgxscan.Select(ctx, b.pool, &rows, query, q.args...); err != nil {
+		return models.BannerPage{}, contentPathError(ctx, err)
+	}
+
+	banners := make([]models.Banner, 0, len(rows))
+	for _, row := range rows {
+		banners = append(banners, row.Banner)
+	}
+
+	page := models.BannerPage{Banners: banners}
+	if uint64(len(rows)) == limit {
+		last := rows[len(rows)-1]
+		position := bannerPosition{SortBy: sortBy, Order: order, BannerId: last.BannerId, Time: last.SortTime}
+
+		next, err := cursor.Encode(position)
+		if err != nil {
+			return models.BannerPage{}, err
+		}
+		page.NextCursor = next
+	}
+
+	if filter.WithTotal {
+		var total uint64
+		if err := pgxscan.Get(ctx, b.pool, &total, fmt.Sprintf(countFilteredBannersQuery, countConditions), countArgs...); err != nil {
+			return models.BannerPage{}, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
 }
 
-func (b *BannerRepository) CreateBanner(ctx context.Context, banner *models.Banner) (uint64, error) {
+// CreateBanner creates the banner unless a schema of schemaVersions was
+// replaced since its content was checked.
+func (b *BannerRepository) CreateBanner(ctx context.Context, banner *models.Banner, schemaVersions models.SchemaVersions) (uint64, error) {
 	const (
-		createBannerQuery = `insert into banner (is_active) values ($1) returning banner_id`
+		createBannerQuery = `insert into banner (is_active, priority, frequency_cap) values ($1, $2, $3) returning banner_id`
 
 		addFeatureAndTagsQuery = `
             insert into banner_feature_tag (banner_id, tag_id, feature_id)
             select $1, r.tag, $3    
             from unnest($2::int[]) as r(tag)`
 
-		createVersionQuery = `insert into banner_version (banner_id, content) values ($1, $2)`
+		createVersionQuery = `
+            insert into banner_version (banner_id, content, localized, default_locale)
+            values ($1, $2, coalesce($3, '{}'), $4)
+            returning version`
 	)
 
 	var bannerId uint64
-	err := RunInTx(ctx, b.pool, func(tx pgx.Tx) error {
-		if err := pgxscan.Get(ctx, tx, &bannerId, createBannerQuery, banner.IsActive); errors.Is(err, pgx.ErrNoRows) {
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		if err := checkSchemaVersions(ctx, tx, schemaVersions); err != nil {
+			return err
+		}
+		if err := pgxscan.Get(ctx, tx, &bannerId, createBannerQuery, banner.IsActive, banner.Priority, banner.FrequencyCap); errors.Is(err, pgx.ErrNoRows) {
 			return ErrNotFound
 		} else if err != nil {
 			return err
 		}
 
 		if _, err := tx.Exec(ctx, addFeatureAndTagsQuery, bannerId, banner.TagIds, banner.FeatureId); err != nil {
+			return pairTakenError(err)
+		}
+
+		localized, err := localizedJSON(banner.Localized)
+		if err != nil {
+			return err
+		}
+		var version uint64
+		if err := pgxscan.Get(ctx, tx, &version, createVersionQuery, bannerId, string(banner.Content), localized, banner.DefaultLocale); err != nil {
+			return err
+		}
+		if err := referenceAssets(ctx, tx, []versionAssets{newVersionAssets(bannerId, version, banner.Content, banner.Localized)}); err != nil {
 			return err
 		}
 
-		if _, err := tx.Exec(ctx, createVersionQuery, bannerId, string(banner.Content)); err != nil {
+		after, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
 			return err
 		}
 
-		return nil
+		return recordChange(ctx, tx, models.AuditCreate, bannerId, nil, after)
 	})
 
 	return bannerId, err
 }
 
-// PartialUpdateBanner ToDo test this method
-func (b *BannerRepository) PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner) error {
+// PartialUpdateBanner applies the patch and returns the feature-tag pairs of
+// the banner before and after it. A patch of the feature or the content
+// checks the banner it leaves with check, if set, against the schema read in
+// the transaction, so a concurrent change cannot slip past the check.
+func (b *BannerRepository) PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner, check models.ContentCheck) ([]models.FeatureTag, error) {
 	const (
 		createNewVersionQuery = `
-		    insert into banner_version (banner_id, version, content, updated_at)
-		    select $1, (select max(version) + 1 from banner_version), coalesce($2, content), now()
+		    insert into banner_version (banner_id, version, content, localized, default_locale, updated_at)
+		    select $1, (select max(version) + 1 from banner_version), coalesce($2, content), coalesce($3, localized),
+		           coalesce($4, default_locale), now()
 		    from banner_version
 		    where banner_id = $1 and version = (select active_version from banner where banner_id=$1)
-		    returning version`
+		    returning version, content, localized`
+
+		lockBannerQuery = `select banner_id from banner where banner_id = $1 for update`
 
 		updateActiveVersionQuery = `
-            update banner set active_version = $2, is_active = $3
+            update banner
+            set active_version = $2, is_active = coalesce($3, is_active), priority = coalesce($4, priority),
+                frequency_cap = case when $5 then $6 else frequency_cap end, updated_at = now()
             where banner_id = $1`
 
 		deleteQuery = `
 		    delete from banner_feature_tag
             where banner_id = $1`
 
+		// The pairs of a banner marked as deleted stay out of the uniqueness.
 		addNewTagsQuery = `
-		    insert into banner_feature_tag (banner_id, tag_id, feature_id)
-		    select $1, unnest($2::int[]), $3`
+		    insert into banner_feature_tag (banner_id, tag_id, feature_id, deleted)
+		    select $1, unnest($2::int[]), $3, deleted_at is not null
+		    from banner
+		    where banner_id = $1`
 	)
 
-	err := RunInTx(ctx, b.pool, func(tx pgx.Tx) error {
-		var version uint64
+	var affected []models.FeatureTag
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		// A content patch is applied to the content it has read, so concurrent
+		// updates of the banner wait for this one.
+		if bannerPartial.ContentPatch != nil {
+			if _, err := tx.Exec(ctx, lockBannerQuery, bannerId); err != nil {
+				return err
+			}
+		}
+
+		before, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
+			return err
+		}
+
 		var content *string
 		if bannerPartial.Content != nil {
 			str := string(bannerPartial.Content)
 			content = &str
 		}
-		if err := pgxscan.Get(ctx, tx, &version, createNewVersionQuery, bannerId, content); errors.Is(err, pgx.ErrNoRows) {
+		if bannerPartial.ContentPatch != nil {
+			patched, err := patch.Apply(bannerPartial.ContentPatch.MediaType, before.Content, bannerPartial.ContentPatch.Document)
+			if err != nil {
+				return err
+			}
+			str := string(patched)
+			content = &str
+		}
+		localized, err := localizedJSON(bannerPartial.Localized)
+		if err != nil {
+			return err
+		}
+		var created struct {
+			Version   uint64                     `db:"version"`
+			Content   json.RawMessage            `db:"content"`
+			Localized map[string]json.RawMessage `db:"localized"`
+		}
+		if err := pgxscan.Get(ctx, tx, &created, createNewVersionQuery, bannerId, content,
+			localized, bannerPartial.DefaultLocale); errors.Is(err, pgx.ErrNoRows) {
 			return ErrNotFound
 		} else if err != nil {
 			return err
 		}
+		version := created.Version
+		if err := referenceAssets(ctx, tx, []versionAssets{newVersionAssets(bannerId, version, created.Content, created.Localized)}); err != nil {
+			return err
+		}
 
-		_, err := b.pool.Exec(ctx, updateActiveVersionQuery, bannerId, version, bannerPartial.IsActive)
+		// A cap with a zero limit is stored as no cap.
+		frequencyCap := bannerPartial.FrequencyCap
+		if frequencyCap != nil && frequencyCap.Limit == 0 {
+			frequencyCap = nil
+		}
+		_, err = tx.Exec(ctx, updateActiveVersionQuery, bannerId, version, bannerPartial.IsActive, bannerPartial.Priority,
+			bannerPartial.FrequencyCap != nil, frequencyCap)
 		if err != nil {
 			return err
 		}
 
 		if bannerPartial.TagIds != nil && bannerPartial.FeatureId != nil {
-			_, err = b.pool.Exec(ctx, deleteQuery, bannerId)
+			_, err = tx.Exec(ctx, deleteQuery, bannerId)
 			if err != nil {
 				return err
 			}
 
-			_, err = b.pool.Exec(ctx, addNewTagsQuery, bannerId, bannerPartial.TagIds, bannerPartial.FeatureId)
+			_, err = tx.Exec(ctx, addNewTagsQuery, bannerId, bannerPartial.TagIds, bannerPartial.FeatureId)
 			if err != nil {
+				return pairTakenError(err)
+			}
+		}
+
+		after, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
+			return err
+		}
+		if check != nil && (bannerPartial.FeatureId != nil || bannerPartial.Content != nil ||
+			bannerPartial.ContentPatch != nil || bannerPartial.Localized != nil) {
+			featureSchema, err := selectFeatureSchema(ctx, tx, after.FeatureId, 0)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return err
+			}
+			if err = check(featureSchema, after.Content, after.Localized); err != nil {
 				return err
 			}
 		}
-		return nil
+		affected = append(featureTags(before.FeatureId, before.TagIds), featureTags(after.FeatureId, after.TagIds)...)
+
+		return recordChange(ctx, tx, models.AuditUpdate, bannerId, before, after)
 	})
 
-	return err
+	return affected, err
 }
 
 func (b *BannerRepository) DeleteBanner(ctx context.Context, bannerId uint64) error {
@@ -222,7 +518,12 @@ func (b *BannerRepository) DeleteBanner(ctx context.Context, bannerId uint64) er
        	    where banner_id = $1`
 	)
 
-	err := RunInTx(ctx, b.pool, func(tx pgx.Tx) error {
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		before, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
+			return err
+		}
+
 		if res, err := tx.Exec(ctx, deleteBannerVersionQuery, bannerId); err != nil {
 			return err
 		} else if res.RowsAffected() == 0 {
@@ -237,56 +538,121 @@ func (b *BannerRepository) DeleteBanner(ctx context.Context, bannerId uint64) er
 			return err
 		}
 
-		return nil
+		return recordChange(ctx, tx, models.AuditDelete, bannerId, before, nil)
 	})
 
 	return err
 }
 
-func (b *BannerRepository) MarkBannersAsDeleted(ctx context.Context, featureId, tagId *uint64) error {
+// MarkBannersAsDeleted marks the banners of the feature and the tag, if given,
+// as deleted and returns the feature and tag pairs they served. At least one
+// of them is required, so a missing filter cannot mark every banner.
+func (b *BannerRepository) MarkBannersAsDeleted(ctx context.Context, featureId, tagId *uint64) ([]models.FeatureTag, error) {
+	if err := requireBannerFilter(featureId, tagId); err != nil {
+		return nil, err
+	}
+
 	const (
+		selectMarkedBannersQuery = `
+		select distinct banner_id
+		from banner_feature_tag
+		join banner using (banner_id)
+		where ($1::int is null or feature_id = $1) and ($2::int is null or tag_id = $2) and deleted_at is null`
+
 		markBannersAsDeletedQuery = `
 		update banner
-		set must_be_deleted = true
-		where banner_id in
-			  (select banner_id
-			   from banner_feature_tag
-			   where ($1::int = -1 or feature_id = $1) or ($2::int = -1 or tag_id = $2))`
+		set deleted_at = current_timestamp
+		where banner_id = any($1)`
 	)
 
-	_, err := b.pool.Exec(ctx, markBannersAsDeletedQuery, featureId, tagId)
+	var affected []models.FeatureTag
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		var bannerIds []uint64
+		if err := pgxscan.Select(ctx, tx, &bannerIds, selectMarkedBannersQuery, featureId, tagId); err != nil {
+			return err
+		}
 
+		for _, bannerId := range bannerIds {
+			before, err := selectBannerSnapshot(ctx, tx, bannerId)
+			if err != nil {
+				return err
+			}
+			if err = recordChange(ctx, tx, models.AuditMarkDeleted, bannerId, before, nil); err != nil {
+				return err
+			}
+			affected = append(affected, featureTags(before.FeatureId, before.TagIds)...)
+		}
+
+		if _, err := tx.Exec(ctx, markBannersAsDeletedQuery, bannerIds); err != nil {
+			return err
+		}
+		return setFeatureTagsDeleted(ctx, tx, bannerIds, true)
+	})
+
+	return affected, err
+}
+
+// requireBannerFilter rejects a bulk delete or restore with neither a feature
+// nor a tag.
+func requireBannerFilter(featureId, tagId *uint64) error {
+	if featureId == nil && tagId == nil {
+		return fmt.Errorf("%w: feature_id or tag_id is required", ErrInvalidFilter)
+	}
+	return nil
+}
+
+// setFeatureTagsDeleted takes the feature and tag pairs of banners marked as
+// deleted out of the pair uniqueness, so new banners can use the pairs during
+// the grace period, and puts them back when the banners are restored. A
+// restore fails with ErrAlreadyExists if another banner uses a pair by then.
+func setFeatureTagsDeleted(ctx context.Context, tx pgx.Tx, bannerIds []uint64, deleted bool) error {
+	const (
+		setDeletedQuery = `update banner_feature_tag set deleted = $2 where banner_id = any($1)`
+	)
+
+	_, err := tx.Exec(ctx, setDeletedQuery, bannerIds, deleted)
+	return pairTakenError(err)
+}
+
+// pairTakenError reports a feature and tag pair taken by another banner as
+// ErrAlreadyExists.
+func pairTakenError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return fmt.Errorf("%w: a feature and tag of the banner belong to another banner", ErrAlreadyExists)
+	}
 	return err
 }
 
 // DeleteMarkedBanners todo test this method
-func (b *BannerRepository) DeleteMarkedBanners(ctx context.Context) error {
+func (b *BannerRepository) DeleteMarkedBanners(ctx context.Context, gracePeriod time.Duration) error {
 	const (
-		deleteBannerVersionQuery = `delete from banner where must_be_deleted`
+		deleteBannerVersionQuery = `delete from banner where deleted_at < now() - $1 * interval '1 second'`
 
 		deleteBannerFeatureTagQuery = `
 		delete from banner_version
 		using banner
-		where must_be_deleted and banner.banner_id = banner_version.banner_id`
+		where deleted_at < now() - $1 * interval '1 second' and banner.banner_id = banner_version.banner_id`
 
 		deleteBannerQuery = `
 delete from banner_feature_tag
 								using banner
-								where must_be_deleted and banner.banner_id = banner_feature_tag.banner_id`
+								where deleted_at < now() - $1 * interval '1 second' and banner.banner_id = banner_feature_tag.banner_id`
 	)
 
+	seconds := gracePeriod.Seconds()
 	err := RunInTx(ctx, b.pool, func(tx pgx.Tx) error {
-		if res, err := tx.Exec(ctx, deleteBannerFeatureTagQuery); err != nil {
+		if res, err := tx.Exec(ctx, deleteBannerFeatureTagQuery, seconds); err != nil {
 			return err
 		} else if res.RowsAffected() == 0 {
 			return ErrNotFound
 		}
 
-		if _, err := tx.Exec(ctx, deleteBannerQuery); err != nil {
+		if _, err := tx.Exec(ctx, deleteBannerQuery, seconds); err != nil {
 			return err
 		}
 
-		if _, err := tx.Exec(ctx, deleteBannerVersionQuery); err != nil {
+		if _, err := tx.Exec(ctx, deleteBannerVersionQuery, seconds); err != nil {
 			return err
 		}
 
@@ -295,3 +661,117 @@ delete from banner_feature_tag
 
 	return err
 }
+
+func (b *BannerRepository) GetDeletedBanners(ctx context.Context) ([]models.DeletedBanner, error) {
+	const (
+		selectDeletedBannersQuery = `
+            select b.banner_id, bft.feature_id, array_agg(distinct bft.tag_id) as tag_ids,
+                   bv.content, b.is_active, bv.version, b.created_at, bv.updated_at, b.deleted_at
+            from banner b
+            join banner_version bv on b.banner_id = bv.banner_id and b.active_version = bv.version
+            join banner_feature_tag bft on b.banner_id = bft.banner_id
+            where b.deleted_at is not null
+            group by b.banner_id, bft.feature_id, bv.content, b.is_active, bv.version, b.created_at, bv.updated_at, b.deleted_at
+            order by b.deleted_at desc`
+	)
+
+	banners := make([]models.DeletedBanner, 0)
+	if err := pgxscan.Select(ctx, b.pool, &banners, selectDeletedBannersQuery); err != nil {
+		return nil, err
+	}
+
+	return banners, nil
+}
+
+// RestoreBanner restores a banner marked as deleted and returns the feature
+// and tag pairs it serves again.
+func (b *BannerRepository) RestoreBanner(ctx context.Context, bannerId uint64) ([]models.FeatureTag, error) {
+	const (
+		restoreBannerQuery = `
+		update banner
+		set deleted_at = null
+		where banner_id = $1 and deleted_at is not null`
+	)
+
+	var affected []models.FeatureTag
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		if res, err := tx.Exec(ctx, restoreBannerQuery, bannerId); err != nil {
+			return err
+		} else if res.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+		if err := setFeatureTagsDeleted(ctx, tx, []uint64{bannerId}, false); err != nil {
+			return err
+		}
+
+		after, err := selectBannerSnapshot(ctx, tx, bannerId)
+		if err != nil {
+			return err
+		}
+		affected = featureTags(after.FeatureId, after.TagIds)
+
+		return recordChange(ctx, tx, models.AuditRestore, bannerId, nil, after)
+	})
+
+	return affected, err
+}
+
+// RestoreBanners restores the banners of the feature and the tag, if given,
+// marked as deleted. It returns their ids and the feature and tag pairs they
+// serve again. At least one of them is required.
+func (b *BannerRepository) RestoreBanners(ctx context.Context, featureId, tagId *uint64) ([]uint64, []models.FeatureTag, error) {
+	if err := requireBannerFilter(featureId, tagId); err != nil {
+		return nil, nil, err
+	}
+
+	const (
+		restoreBannersQuery = `
+		update banner
+		set deleted_at = null
+		where deleted_at is not null and banner_id in
+			  (select banner_id
+			   from banner_feature_tag
+			   where ($1::int is null or feature_id = $1) and ($2::int is null or tag_id = $2))
+		returning banner_id`
+	)
+
+	bannerIds := make([]uint64, 0)
+	var affected []models.FeatureTag
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		if err := pgxscan.Select(ctx, tx, &bannerIds, restoreBannersQuery, featureId, tagId); err != nil {
+			return err
+		}
+		if err := setFeatureTagsDeleted(ctx, tx, bannerIds, false); err != nil {
+			return err
+		}
+
+		for _, bannerId := range bannerIds {
+			after, err := selectBannerSnapshot(ctx, tx, bannerId)
+			if err != nil {
+				return err
+			}
+			if err = recordChange(ctx, tx, models.AuditRestore, bannerId, nil, after); err != nil {
+				return err
+			}
+			affected = append(affected, featureTags(after.FeatureId, after.TagIds)...)
+		}
+
+		return nil
+	})
+
+	return bannerIds, affected, err
+}
+
+// localizedJSON encodes localized content for a jsonb parameter; nil stays
+// null.
+func localizedJSON(localized map[string]json.RawMessage) (*string, error) {
+	if localized == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(localized)
+	if err != nil {
+		return nil, err
+	}
+	str := string(raw)
+	return &str, nil
+}
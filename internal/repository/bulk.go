@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/schema"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/samber/lo"
+)
+
+// MaxBulkItems bounds the number of banners a bulk request may touch.
+const MaxBulkItems = 10000
+
+// errBulkRolledBack rolls back an atomic bulk request with failed items.
+var errBulkRolledBack = errors.New("bulk request rolled back")
+
+// bulkChange is the snapshot of a banner before and after a bulk item; after
+// is nil for deleted banners.
+type bulkChange struct {
+	before, after *models.Banner
+}
+
+// BulkUpdate applies the operations in one transaction, every item under its
+// own savepoint, so that a failed item does not affect the others unless the
+// request is atomic. It returns the feature and tag pairs of the changed
+// banners, before and after the change, when the transaction is committed.
+func (b *BannerRepository) BulkUpdate(ctx context.Context, req *models.BulkRequest) (models.BulkReport, []models.FeatureTag, error) {
+	const (
+		selectFilteredIdsQuery = `
+            select b.banner_id
+            from banner b
+            where %s
+            order by b.banner_id
+            limit %s`
+	)
+
+	var (
+		report   models.BulkReport
+		affected []models.FeatureTag
+	)
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		report = models.BulkReport{Atomic: req.Atomic, Items: make([]models.BulkItemResult, 0)}
+		affected = nil
+
+		for i, op := range req.Operations {
+			bannerIds := op.BannerIds
+			if len(bannerIds) == 0 && op.Filter != nil {
+				q := bannerFilterQuery(op.Filter)
+				query := fmt.Sprintf(selectFilteredIdsQuery, q.String(), q.arg(MaxBulkItems+1))
+				if err := pgxscan.Select(ctx, tx, &bannerIds, query, q.args...); err != nil {
+					return contentPathError(ctx, err)
+				}
+			}
+			if len(report.Items)+len(bannerIds) > MaxBulkItems {
+				return fmt.Errorf("%w: more than %d banners selected", ErrInvalidBulk, MaxBulkItems)
+			}
+
+			for _, bannerId := range bannerIds {
+				item := models.BulkItemResult{Operation: i, BannerId: bannerId, Status: models.BulkApplied}
+
+				var change *bulkChange
+				err := pgx.BeginFunc(ctx, tx, func(sp pgx.Tx) (err error) {
+					change, err = applyBulkItem(ctx, sp, op, bannerId, req.CheckContent)
+					return err
+				})
+
+				var (
+					pgErr        *pgconn.PgError
+					violationErr *schema.ViolationError
+				)
+				switch {
+				case errors.Is(err, ErrNotFound):
+					item.Status, item.Error = models.BulkFailed, "banner or version not found"
+				case errors.As(err, &violationErr):
+					item.Status, item.Error = models.BulkFailed, violationErr.Error()
+				case errors.As(err, &pgErr) && pgErr.Code == uniqueViolation:
+					item.Status, item.Error = models.BulkFailed, "feature and tag already belong to another banner"
+				case err != nil:
+					return err
+				case change == nil:
+					item.Status = models.BulkUnchanged
+				default:
+					for _, banner := range []*models.Banner{change.before, change.after} {
+						if banner != nil {
+							affected = append(affected, featureTags(banner.FeatureId, banner.TagIds)...)
+						}
+					}
+				}
+
+				if item.Status == models.BulkFailed {
+					report.Failed++
+				} else if item.Status == models.BulkApplied {
+					report.Applied++
+				}
+				report.Items = append(report.Items, item)
+			}
+		}
+
+		if req.Atomic && report.Failed > 0 {
+			return errBulkRolledBack
+		}
+		return nil
+	})
+
+	if errors.Is(err, errBulkRolledBack) {
+		for i := range report.Items {
+			if report.Items[i].Status == models.BulkApplied {
+				report.Items[i].Status = models.BulkRolledBack
+			}
+		}
+		report.Applied = 0
+		return report, nil, nil
+	} else if err != nil {
+		return models.BulkReport{}, nil, err
+	}
+
+	report.Committed = true
+	return report, affected, nil
+}
+
+// applyBulkItem applies the operation to a single banner and records the
+// change. It returns a nil change when the banner is already in the requested
+// state. A retag to another feature checks the content with check, if set,
+// against the schema of the feature read through tx: the pool may have no
+// connection to spare while the change lock is held.
+func applyBulkItem(ctx context.Context, tx pgx.Tx, op models.BulkOperation, bannerId uint64,
+	check models.ContentCheck) (*bulkChange, error) {
+	const (
+		// Locks the banner so that it is not marked as deleted or changed by a
+		// concurrent request until the item is applied.
+		selectAliveQuery = `select deleted_at is null from banner where banner_id = $1 for update`
+
+		setActiveQuery = `update banner set is_active = $2, updated_at = now() where banner_id = $1`
+
+		// Keeps banner.updated_at equal to the time of the active version.
+		chooseVersionQuery = `
+            update banner b
+            set active_version = bv.version, updated_at = bv.updated_at
+            from banner_version bv
+            where b.banner_id = $1 and bv.banner_id = b.banner_id and bv.version = $2`
+
+		deleteFeatureTagsQuery = `delete from banner_feature_tag where banner_id = $1`
+
+		addFeatureTagsQuery = `
+            insert into banner_feature_tag (banner_id, tag_id, feature_id)
+            select $1, unnest($2::int[]), $3`
+
+		markDeletedQuery = `update banner set deleted_at = current_timestamp where banner_id = $1`
+	)
+
+	var alive bool
+	if err := pgxscan.Get(ctx, tx, &alive, selectAliveQuery, bannerId); errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	} else if !alive {
+		return nil, ErrNotFound
+	}
+
+	before, err := selectBannerSnapshot(ctx, tx, bannerId)
+	if err != nil {
+		return nil, err
+	}
+
+	action := models.AuditUpdate
+	switch op.Action {
+	case models.BulkActivate, models.BulkDeactivate:
+		isActive := op.Action == models.BulkActivate
+		if before.IsActive == isActive {
+			return nil, nil
+		}
+		if _, err = tx.Exec(ctx, setActiveQuery, bannerId, isActive); err != nil {
+			return nil, err
+		}
+	case models.BulkSetVersion:
+		if before.Version == op.Version {
+			return nil, nil
+		}
+		res, err := tx.Exec(ctx, chooseVersionQuery, bannerId, op.Version)
+		if err != nil {
+			return nil, err
+		} else if res.RowsAffected() == 0 {
+			return nil, ErrNotFound
+		}
+		action = models.AuditChooseVersion
+	case models.BulkRetag:
+		featureId := before.FeatureId
+		if op.FeatureId != nil {
+			featureId = *op.FeatureId
+		}
+		tagIds := lo.Uniq(op.TagIds)
+		if featureId == before.FeatureId && len(tagIds) == len(before.TagIds) && lo.Every(before.TagIds, tagIds) {
+			return nil, nil
+		}
+		if check != nil && featureId != before.FeatureId {
+			featureSchema, err := selectFeatureSchema(ctx, tx, featureId, 0)
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				return nil, err
+			}
+			if err = check(featureSchema, before.Content, before.Localized); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = tx.Exec(ctx, deleteFeatureTagsQuery, bannerId); err != nil {
+			return nil, err
+		}
+		if _, err = tx.Exec(ctx, addFeatureTagsQuery, bannerId, tagIds, featureId); err != nil {
+			return nil, err
+		}
+	case models.BulkDelete:
+		if _, err = tx.Exec(ctx, markDeletedQuery, bannerId); err != nil {
+			return nil, err
+		}
+		if err = setFeatureTagsDeleted(ctx, tx, []uint64{bannerId}, true); err != nil {
+			return nil, err
+		}
+		if err = recordChange(ctx, tx, models.AuditMarkDeleted, bannerId, before, nil); err != nil {
+			return nil, err
+		}
+		return &bulkChange{before: before}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidBulk, op.Action)
+	}
+
+	after, err := selectBannerSnapshot(ctx, tx, bannerId)
+	if err != nil {
+		return nil, err
+	}
+	if err = recordChange(ctx, tx, action, bannerId, before, after); err != nil {
+		return nil, err
+	}
+
+	return &bulkChange{before: before, after: after}, nil
+}
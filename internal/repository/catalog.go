@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	defaultCatalogLimit = 100
+	maxCatalogLimit     = 1000
+)
+
+// catalogTable names the table, the id column and the nullable reference
+// column of features or tags, which share the rest of their layout. The lock
+// key identifies the advisory lock new ids of the table are picked under; it
+// spells the table name in ASCII.
+type catalogTable struct {
+	name, id, ref string
+	lockKey       int64
+}
+
+var (
+	featureTable = catalogTable{name: "feature", id: "feature_id", ref: "default_banner_id", lockKey: 0x66656174757265}
+	tagTable     = catalogTable{name: "tag", id: "tag_id", ref: "parent_tag_id", lockKey: 0x746167}
+)
+
+type CatalogRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCatalogRepository(p *pgxpool.Pool) *CatalogRepository {
+	return &CatalogRepository{
+		pool: p,
+	}
+}
+
+func (c *CatalogRepository) CreateFeature(ctx context.Context, feature *models.Feature) (uint64, error) {
+	return c.createEntry(ctx, featureTable, feature.FeatureId, feature.DefaultBannerId, &feature.CatalogEntry)
+}
+
+func (c *CatalogRepository) GetFeatures(ctx context.Context, filter *models.CatalogFilter) ([]models.Feature, error) {
+	return selectEntries[models.Feature](ctx, c.pool, featureTable, filter)
+}
+
+func (c *CatalogRepository) GetFeature(ctx context.Context, featureId uint64) (models.Feature, error) {
+	return selectEntry[models.Feature](ctx, c.pool, featureTable, featureId)
+}
+
+func (c *CatalogRepository) UpdateFeature(ctx context.Context, featureId uint64, patch *models.PatchFeature) error {
+	return c.updateEntry(ctx, featureTable, featureId, patch.DefaultBannerId, &patch.PatchCatalogEntry)
+}
+
+func (c *CatalogRepository) DeleteFeature(ctx context.Context, featureId uint64) error {
+	return c.deleteEntry(ctx, featureTable, featureId)
+}
+
+func (c *CatalogRepository) CreateTag(ctx context.Context, tag *models.Tag) (uint64, error) {
+	return c.createEntry(ctx, tagTable, tag.TagId, tag.ParentTagId, &tag.CatalogEntry)
+}
+
+func (c *CatalogRepository) GetTags(ctx context.Context, filter *models.CatalogFilter) ([]models.Tag, error) {
+	return selectEntries[models.Tag](ctx, c.pool, tagTable, filter)
+}
+
+func (c *CatalogRepository) GetTag(ctx context.Context, tagId uint64) (models.Tag, error) {
+	return selectEntry[models.Tag](ctx, c.pool, tagTable, tagId)
+}
+
+func (c *CatalogRepository) UpdateTag(ctx context.Context, tagId uint64, patch *models.PatchTag) error {
+	return c.updateEntry(ctx, tagTable, tagId, patch.ParentTagId, &patch.PatchCatalogEntry)
+}
+
+func (c *CatalogRepository) DeleteTag(ctx context.Context, tagId uint64) error {
+	return c.deleteEntry(ctx, tagTable, tagId)
+}
+
+// createEntry inserts an entry under the given id, or under the next id that
+// neither the catalog nor any banner uses yet.
+func (c *CatalogRepository) createEntry(ctx context.Context, table catalogTable, id uint64, ref *uint64, entry *models.CatalogEntry) (uint64, error) {
+	const (
+		createEntryQuery = `
+            insert into %[1]s (%[2]s, name, description, owner, archived, %[3]s)
+            values (coalesce($1, greatest((select max(%[2]s) from %[1]s), (select max(%[2]s) from banner_feature_tag), 0) + 1),
+                    $2, $3, $4, $5, $6)
+            returning %[2]s`
+
+		lockNextIdQuery = `select pg_advisory_xact_lock($1)`
+	)
+
+	var explicitId *uint64
+	if id != 0 {
+		explicitId = &id
+	}
+
+	err := RunInTx(ctx, c.pool, func(tx pgx.Tx) error {
+		// Concurrent inserts would pick the same next id, so they take turns.
+		if _, err := tx.Exec(ctx, lockNextIdQuery, table.lockKey); err != nil {
+			return err
+		}
+		if err := pgxscan.Get(ctx, tx, &id, fmt.Sprintf(createEntryQuery, table.name, table.id, table.ref),
+			explicitId, entry.Name, entry.Description, entry.Owner, entry.Archived, ref); err != nil {
+			return err
+		}
+		return checkDefaultBanner(ctx, tx, table, id, ref)
+	})
+	if err != nil {
+		return 0, catalogError(err, table)
+	}
+
+	return id, nil
+}
+
+func selectEntries[T any](ctx context.Context, pool *pgxpool.Pool, table catalogTable, filter *models.CatalogFilter) ([]T, error) {
+	const (
+		selectEntriesQuery = `
+            select %[2]s, %[3]s, name, description, owner, archived, created_at, updated_at
+            from %[1]s
+            where (cardinality($1::int[]) = 0 or %[2]s = any($1))
+              and ($2::boolean is null or archived = $2)
+            order by %[2]s
+            limit $3 offset $4`
+	)
+
+	ids := filter.Ids
+	if ids == nil {
+		ids = []uint64{}
+	}
+
+	limit := filter.Limit
+	if len(ids) > 0 {
+		limit = uint64(len(ids))
+	} else if limit == 0 {
+		limit = defaultCatalogLimit
+	} else if limit > maxCatalogLimit {
+		limit = maxCatalogLimit
+	}
+
+	entries := make([]T, 0)
+	if err := pgxscan.Select(ctx, pool, &entries, fmt.Sprintf(selectEntriesQuery, table.name, table.id, table.ref),
+		ids, filter.Archived, limit, filter.Offset); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func selectEntry[T any](ctx context.Context, pool *pgxpool.Pool, table catalogTable, id uint64) (T, error) {
+	entries, err := selectEntries[T](ctx, pool, table, &models.CatalogFilter{Ids: []uint64{id}})
+	if err != nil {
+		var zero T
+		return zero, err
+	} else if len(entries) == 0 {
+		var zero T
+		return zero, ErrNotFound
+	}
+
+	return entries[0], nil
+}
+
+// updateEntry applies the patch; a zero ref clears the reference column. A tag
+// cannot become a descendant of itself.
+func (c *CatalogRepository) updateEntry(ctx context.Context, table catalogTable, id uint64, ref *uint64, patch *models.PatchCatalogEntry) error {
+	const (
+		selectIsAncestorQuery = `
+            with recursive ancestor (tag_id, path) as (
+                select $2::int, array[$2::int]
+                union all
+                select t.parent_tag_id, a.path || t.parent_tag_id
+                from ancestor a
+                join tag t on t.tag_id = a.tag_id
+                where t.parent_tag_id is not null and t.parent_tag_id <> all(a.path)
+            )
+            select exists (select 1 from ancestor where tag_id = $1)`
+
+		updateEntryQuery = `
+            update %[1]s
+            set name = coalesce($2, name),
+                description = coalesce($3, description),
+                owner = coalesce($4, owner),
+                archived = coalesce($5, archived),
+                %[3]s = case when $6::bigint = 0 then null else coalesce($6, %[3]s) end,
+                updated_at = current_timestamp
+            where %[2]s = $1`
+	)
+
+	return RunInTx(ctx, c.pool, func(tx pgx.Tx) error {
+		if table == tagTable && ref != nil && *ref != 0 {
+			var isCycle bool
+			if err := pgxscan.Get(ctx, tx, &isCycle, selectIsAncestorQuery, id, *ref); err != nil {
+				return err
+			} else if isCycle {
+				return fmt.Errorf("%w: tag %d cannot be a parent of its ancestor %d", ErrInvalidReference, id, *ref)
+			}
+		}
+
+		res, err := tx.Exec(ctx, fmt.Sprintf(updateEntryQuery, table.name, table.id, table.ref),
+			id, patch.Name, patch.Description, patch.Owner, patch.Archived, ref)
+		if err != nil {
+			return catalogError(err, table)
+		} else if res.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+
+		return checkDefaultBanner(ctx, tx, table, id, ref)
+	})
+}
+
+// checkDefaultBanner rejects a default banner of a feature that belongs to
+// another feature. A banner that does not exist is left to the foreign key.
+func checkDefaultBanner(ctx context.Context, tx pgx.Tx, table catalogTable, featureId uint64, bannerId *uint64) error {
+	const (
+		selectOtherFeatureQuery = `
+            select exists (select 1 from banner_feature_tag where banner_id = $1 and feature_id <> $2)`
+	)
+
+	if table != featureTable || bannerId == nil || *bannerId == 0 {
+		return nil
+	}
+
+	var otherFeature bool
+	if err := pgxscan.Get(ctx, tx, &otherFeature, selectOtherFeatureQuery, *bannerId, featureId); err != nil {
+		return err
+	} else if otherFeature {
+		return fmt.Errorf("%w: banner %d does not belong to feature %d", ErrInvalidReference, *bannerId, featureId)
+	}
+	return nil
+}
+
+// deleteEntry removes an entry that no banner, including the ones marked as
+// deleted, refers to. Entries in use can only be archived.
+func (c *CatalogRepository) deleteEntry(ctx context.Context, table catalogTable, id uint64) error {
+	const (
+		selectInUseQuery = `select exists (select 1 from banner_feature_tag where %s = $1)`
+
+		deleteEntryQuery = `delete from %s where %s = $1`
+	)
+
+	return RunInTx(ctx, c.pool, func(tx pgx.Tx) error {
+		var inUse bool
+		if err := pgxscan.Get(ctx, tx, &inUse, fmt.Sprintf(selectInUseQuery, table.id), id); err != nil {
+			return err
+		} else if inUse {
+			return fmt.Errorf("%w: %s %d is used by banners, archive it instead", ErrInUse, table.name, id)
+		}
+
+		var pgErr *pgconn.PgError
+		res, err := tx.Exec(ctx, fmt.Sprintf(deleteEntryQuery, table.name, table.id), id)
+		if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+			return fmt.Errorf("%w: %s %d is referenced by other %[2]ss", ErrInUse, table.name, id)
+		} else if err != nil {
+			return err
+		} else if res.RowsAffected() == 0 {
+			return ErrNotFound
+		}
+
+		return nil
+	})
+}
+
+// catalogError maps constraint violations of a catalog write to domain errors.
+func catalogError(err error, table catalogTable) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case uniqueViolation:
+		return ErrAlreadyExists
+	case foreignKeyViolation, checkViolation:
+		return fmt.Errorf("%w: invalid %s of %s", ErrInvalidReference, table.ref, table.name)
+	default:
+		return err
+	}
+}
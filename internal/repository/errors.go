@@ -2,8 +2,33 @@ package repository
 
 import "errors"
 
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+	checkViolation      = "23514"
+	// syntaxError and invalidTextRepresentation are raised by casts of
+	// malformed input, such as a JSONPath.
+	syntaxError               = "42601"
+	invalidTextRepresentation = "22P02"
+)
+
 var (
 	ErrNotFound       = errors.New("record not found")
 	ErrAlreadyExists  = errors.New("record already exists")
 	ErrBannerInactive = errors.New("banner is inactive")
+	ErrNotModified    = errors.New("not modified")
+	ErrInvalidFilter  = errors.New("invalid filter")
+	ErrInvalidImport  = errors.New("invalid import")
+	ErrInvalidBulk    = errors.New("invalid bulk request")
+	ErrInUse          = errors.New("record is in use")
+	// ErrInvalidReference means a banner refers to an archived or, in strict
+	// mode, unknown feature or tag.
+	ErrInvalidReference = errors.New("invalid reference")
+	// ErrInvalidLocale means banner content is given for a locale the service
+	// does not serve.
+	ErrInvalidLocale = errors.New("invalid locale")
+	// ErrInvalidEvent means a reported banner event is malformed.
+	ErrInvalidEvent = errors.New("invalid event")
+	// ErrInvalidFrequencyCap means a banner cap has a limit but no window.
+	ErrInvalidFrequencyCap = errors.New("invalid frequency cap")
 )
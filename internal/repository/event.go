@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type EventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventRepository(p *pgxpool.Pool) *EventRepository {
+	return &EventRepository{
+		pool: p,
+	}
+}
+
+// InsertBannerEvents writes a batch of events in a single statement and
+// returns how many it wrote. Events of a banner version that does not exist
+// are dropped here rather than checked when they are reported, so a report
+// never waits for the database.
+func (e *EventRepository) InsertBannerEvents(ctx context.Context, events []models.BannerEvent) (int, error) {
+	const (
+		insertEventsQuery = `
+            insert into banner_event (kind, banner_id, version, tag_id, feature_id, occurred_at)
+            select ev.kind, ev.banner_id, ev.version, ev.tag_id, ev.feature_id, ev.occurred_at
+            from unnest($1::text[], $2::int[], $3::int[], $4::int[], $5::int[], $6::timestamp[])
+                     as ev(kind, banner_id, version, tag_id, feature_id, occurred_at)
+            where exists (select 1
+                          from banner_version bv
+                          where bv.banner_id = ev.banner_id
+                            and bv.version = ev.version)`
+	)
+
+	n := len(events)
+	kinds, occurredAt := make([]string, 0, n), make([]time.Time, 0, n)
+	bannerIds, versions := make([]uint64, 0, n), make([]uint64, 0, n)
+	tagIds, featureIds := make([]uint64, 0, n), make([]uint64, 0, n)
+	for _, event := range events {
+		kinds = append(kinds, string(event.Kind))
+		bannerIds = append(bannerIds, event.BannerId)
+		versions = append(versions, event.Version)
+		tagIds = append(tagIds, event.TagId)
+		featureIds = append(featureIds, event.FeatureId)
+		occurredAt = append(occurredAt, event.OccurredAt)
+	}
+
+	res, err := e.pool.Exec(ctx, insertEventsQuery, kinds, bannerIds, versions, tagIds, featureIds, occurredAt)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.RowsAffected()), nil
+}
+
+// GetBannerStats counts the events of the banner per version and per day.
+func (e *EventRepository) GetBannerStats(ctx context.Context, bannerId uint64, filter *models.StatsFilter) (models.BannerStats, error) {
+	const (
+		bannerExistsQuery = `select exists(select 1 from banner where banner_id = $1)`
+
+		versionStatsQuery = `
+            select version,
+                   count(*) filter (where kind = 'impression') as impressions,
+                   count(*) filter (where kind = 'click') as clicks
+            from banner_event
+            where banner_id = $1
+              and ($2::timestamp is null or occurred_at >= $2)
+              and ($3::timestamp is null or occurred_at < $3)
+            group by version
+            order by version`
+
+		dayStatsQuery = `
+            select to_char(date_trunc('day', occurred_at), 'YYYY-MM-DD') as day,
+                   count(*) filter (where kind = 'impression') as impressions,
+                   count(*) filter (where kind = 'click') as clicks
+            from banner_event
+            where banner_id = $1
+              and ($2::timestamp is null or occurred_at >= $2)
+              and ($3::timestamp is null or occurred_at < $3)
+            group by day
+            order by day`
+	)
+
+	var exists bool
+	if err := pgxscan.Get(ctx, e.pool, &exists, bannerExistsQuery, bannerId); err != nil {
+		return models.BannerStats{}, err
+	} else if !exists {
+		return models.BannerStats{}, ErrNotFound
+	}
+
+	stats := models.BannerStats{
+		BannerId: bannerId,
+		Versions: make([]models.VersionStats, 0),
+		Days:     make([]models.DayStats, 0),
+	}
+	if err := pgxscan.Select(ctx, e.pool, &stats.Versions, versionStatsQuery, bannerId, filter.From, filter.To); err != nil {
+		return models.BannerStats{}, err
+	}
+	if err := pgxscan.Select(ctx, e.pool, &stats.Days, dayStatsQuery, bannerId, filter.From, filter.To); err != nil {
+		return models.BannerStats{}, err
+	}
+
+	return stats, nil
+}
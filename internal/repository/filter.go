@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/reqctx"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/samber/lo"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// queryBuilder collects the conditions of a where clause together with their
+// arguments, numbering the placeholders in the order they are added.
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// where adds a condition. Every %s in format is replaced by the placeholder
+// of the matching argument.
+func (q *queryBuilder) where(format string, args ...any) {
+	placeholders := make([]any, len(args))
+	for i, arg := range args {
+		placeholders[i] = q.arg(arg)
+	}
+	q.conditions = append(q.conditions, fmt.Sprintf(format, placeholders...))
+}
+
+func (q *queryBuilder) arg(v any) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *queryBuilder) String() string {
+	return strings.Join(q.conditions, "\n              and ")
+}
+
+// bannerFilterQuery translates the filter into conditions on banner b. The
+// tag and feature conditions are served by the banner_feature_tag indexes,
+// the content ones by the GIN index on banner_version.content.
+func bannerFilterQuery(filter *models.FilterBanner) *queryBuilder {
+	q := &queryBuilder{}
+	q.where("b.deleted_at is null")
+
+	if len(filter.FeatureIds) > 0 {
+		q.where(`exists (select 1
+                      from banner_feature_tag bft
+                      where bft.banner_id = b.banner_id and bft.feature_id = any(%s::int[]))`, filter.FeatureIds)
+	}
+	if tagIds := lo.Uniq(filter.TagIds); len(tagIds) > 0 {
+		q.where(`b.banner_id in (select bft.banner_id
+                                 from banner_feature_tag bft
+                                 where bft.tag_id = any(%s::int[])
+                                 group by bft.banner_id
+                                 having count(distinct bft.tag_id) = %s)`, tagIds, len(tagIds))
+	}
+	if filter.IsActive != nil {
+		q.where("b.is_active = %s", *filter.IsActive)
+	}
+	if filter.CreatedFrom != nil {
+		q.where("b.created_at >= %s", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		q.where("b.created_at < %s", *filter.CreatedTo)
+	}
+	if filter.UpdatedFrom != nil {
+		q.where("b.updated_at >= %s", *filter.UpdatedFrom)
+	}
+	if filter.UpdatedTo != nil {
+		q.where("b.updated_at < %s", *filter.UpdatedTo)
+	}
+	if filter.HasDraft != nil {
+		draft := `exists (select 1
+                      from banner_version d
+                      where d.banner_id = b.banner_id and d.version > b.active_version)`
+		if !*filter.HasDraft {
+			draft = "not " + draft
+		}
+		q.where(draft)
+	}
+	// The content conditions select the matching versions first, which the
+	// GIN index can find, rather than checking the active version of every
+	// banner.
+	if len(filter.Content) > 0 {
+		q.where(`(b.banner_id, b.active_version) in (select c.banner_id, c.version
+                                                       from banner_version c
+                                                       where c.content @> %s::jsonb)`, string(filter.Content))
+	}
+	if filter.ContentPath != "" {
+		q.where(`(b.banner_id, b.active_version) in (select c.banner_id, c.version
+                                                       from banner_version c
+                                                       where c.content @? %s::jsonpath)`, filter.ContentPath)
+	}
+
+	return q
+}
+
+// contentPathError reports a malformed JSONPath of the filter, which Postgres
+// rejects as a syntax error, as a bad filter rather than a failed query. The
+// path is the only user input parsed by the filter queries. The client gets a
+// fixed message; the one of the driver is only logged.
+func contentPathError(ctx context.Context, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (pgErr.Code == syntaxError || pgErr.Code == invalidTextRepresentation) {
+		log.Printf("request %s: content_path: %v", reqctx.GetRequestId(ctx), err)
+		return fmt.Errorf("%w: invalid content path", ErrInvalidFilter)
+	}
+	return err
+}
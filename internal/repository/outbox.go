@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"strconv"
+	"time"
+)
+
+// changeLockKey identifies the advisory lock that serializes banner changes.
+// Outbox event ids are allocated under it, so they grow in commit order and a
+// reader that has seen an event has seen every event before it. The key
+// spells "banner" in ASCII.
+const changeLockKey = 0x62616e6e6572
+
+// runChangeTx runs f, which records banner changes, in a transaction that
+// holds the change lock. The lock is taken before any row lock, so changes
+// cannot deadlock on it.
+func runChangeTx(ctx context.Context, pool *pgxpool.Pool, f func(tx pgx.Tx) error) error {
+	const (
+		lockQuery = `select pg_advisory_xact_lock($1)`
+	)
+
+	return RunInTx(ctx, pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, lockQuery, changeLockKey); err != nil {
+			return err
+		}
+		return f(tx)
+	})
+}
+
+// recordChange stores the audit entry and the outbox event of a banner
+// mutation; it must run inside the mutation transaction, started with
+// runChangeTx.
+func recordChange(ctx context.Context, tx pgx.Tx, action models.AuditAction, bannerId uint64, before, after *models.Banner) error {
+	if err := insertAuditEvent(ctx, tx, action, bannerId, before, after); err != nil {
+		return err
+	}
+	return insertOutboxEvent(ctx, tx, eventTypeFor(action, before, after), bannerId, before, after)
+}
+
+func eventTypeFor(action models.AuditAction, before, after *models.Banner) models.BannerEventType {
+	switch action {
+	case models.AuditCreate:
+		return models.BannerCreated
+	case models.AuditChooseVersion:
+		return models.BannerVersionSwitched
+	case models.AuditDelete, models.AuditMarkDeleted:
+		return models.BannerDeleted
+	case models.AuditRestore:
+		return models.BannerRestored
+	}
+
+	if before != nil && after != nil && before.IsActive != after.IsActive {
+		if after.IsActive {
+			return models.BannerActivated
+		}
+		return models.BannerDeactivated
+	}
+	return models.BannerUpdated
+}
+
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, eventType models.BannerEventType, bannerId uint64, before, after *models.Banner) error {
+	const (
+		insertOutboxEventQuery = `
+            insert into outbox_event (banner_id, event_type, payload)
+            values ($1, $2, $3)
+            returning event_id`
+
+		notifyQuery = `select pg_notify($1, $2)`
+	)
+
+	payload, err := json.Marshal(models.BannerEventPayload{Before: before, After: after})
+	if err != nil {
+		return err
+	}
+
+	var eventId uint64
+	if err = pgxscan.Get(ctx, tx, &eventId, insertOutboxEventQuery, bannerId, eventType, string(payload)); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, notifyQuery, models.BannerEventsChannel, strconv.FormatUint(eventId, 10))
+	return err
+}
+
+// GetBannerNotification loads the feature and tag pairs the event touched,
+// before and after the change.
+func (b *BannerRepository) GetBannerNotification(ctx context.Context, eventId uint64) (models.BannerNotification, error) {
+	const (
+		selectNotificationQuery = `
+            select e.event_id, e.banner_id,
+                   array(select distinct (s ->> 'feature_id')::bigint
+                         from jsonb_array_elements(jsonb_build_array(e.payload -> 'before', e.payload -> 'after')) s
+                         where s ->> 'feature_id' is not null) as feature_ids,
+                   array(select distinct t::bigint
+                         from jsonb_array_elements(jsonb_build_array(e.payload -> 'before', e.payload -> 'after')) s,
+                              jsonb_array_elements_text(coalesce(s -> 'tag_ids', '[]')) t) as tag_ids
+            from outbox_event e
+            where e.event_id = $1`
+	)
+
+	var notification models.BannerNotification
+	if err := pgxscan.Get(ctx, b.pool, &notification, selectNotificationQuery, eventId); errors.Is(err, pgx.ErrNoRows) {
+		return models.BannerNotification{}, ErrNotFound
+	} else if err != nil {
+		return models.BannerNotification{}, err
+	}
+
+	return notification, nil
+}
+
+// GetLatestFeatureEventId returns the id of the newest event after
+// afterEventId that touched a banner of the feature, or 0 if there is none.
+// Any banner of the feature can change what a tag resolves to, through the
+// tag hierarchy or the default banner.
+func (b *BannerRepository) GetLatestFeatureEventId(ctx context.Context, featureId, afterEventId uint64) (uint64, error) {
+	const (
+		selectLatestEventIdQuery = `
+            select coalesce(max(event_id), 0)
+            from outbox_event
+            where event_id > $2
+              and ((payload -> 'after' ->> 'feature_id')::bigint = $1
+                or (payload -> 'before' ->> 'feature_id')::bigint = $1)`
+	)
+
+	var eventId uint64
+	if err := pgxscan.Get(ctx, b.pool, &eventId, selectLatestEventIdQuery, featureId, afterEventId); err != nil {
+		return 0, err
+	}
+
+	return eventId, nil
+}
+
+// ClaimOutboxEvents leases up to limit deliverable events. Only the oldest
+// pending event of every banner is eligible, which keeps delivery ordered
+// per banner.
+func (b *BannerRepository) ClaimOutboxEvents(ctx context.Context, limit uint64, lease time.Duration) ([]models.OutboxEvent, error) {
+	const (
+		claimOutboxEventsQuery = `
+            update outbox_event
+            set next_attempt_at = now() + $2 * interval '1 second'
+            where event_id in
+                  (select e.event_id
+                   from outbox_event e
+                   where e.status = 'pending'
+                     and e.next_attempt_at <= now()
+                     and not exists (select 1
+                                     from outbox_event p
+                                     where p.banner_id = e.banner_id
+                                       and p.status = 'pending'
+                                       and p.event_id < e.event_id)
+                   order by e.event_id
+                   limit $1
+                   for update skip locked)
+            returning event_id, banner_id, event_type, payload, attempts, created_at`
+	)
+
+	events := make([]models.OutboxEvent, 0)
+	if err := pgxscan.Select(ctx, b.pool, &events, claimOutboxEventsQuery, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (b *BannerRepository) MarkOutboxEventDelivered(ctx context.Context, eventId uint64) error {
+	const (
+		markDeliveredQuery = `
+            update outbox_event
+            set status = 'delivered', attempts = attempts + 1, delivered_at = now(), last_error = ''
+            where event_id = $1`
+	)
+
+	_, err := b.pool.Exec(ctx, markDeliveredQuery, eventId)
+	return err
+}
+
+// MarkOutboxEventFailed schedules the next attempt after backoff or moves the
+// event to the dead-letter state when dead is set.
+func (b *BannerRepository) MarkOutboxEventFailed(ctx context.Context, eventId uint64, lastError string, backoff time.Duration, dead bool) error {
+	const (
+		markFailedQuery = `
+            update outbox_event
+            set status = case when $4 then 'dead' else 'pending' end,
+                attempts = attempts + 1,
+                last_error = $2,
+                next_attempt_at = now() + $3 * interval '1 second'
+            where event_id = $1`
+	)
+
+	_, err := b.pool.Exec(ctx, markFailedQuery, eventId, lastError, backoff.Seconds(), dead)
+	return err
+}
+
+// DeleteFinishedOutboxEvents deletes, in batches, the events delivered or
+// dead for longer than retention and returns how many it deleted. Pending
+// events are kept however old they are.
+func (b *BannerRepository) DeleteFinishedOutboxEvents(ctx context.Context, retention time.Duration) (int64, error) {
+	const (
+		deleteFinishedQuery = `
+            delete from outbox_event
+            where event_id in
+                  (select event_id
+                   from outbox_event
+                   where status <> 'pending'
+                     and coalesce(delivered_at, next_attempt_at) < now() - $1 * interval '1 second'
+                   limit $2)`
+
+		deleteBatchSize = 1000
+	)
+
+	var deleted int64
+	for {
+		res, err := b.pool.Exec(ctx, deleteFinishedQuery, retention.Seconds(), deleteBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += res.RowsAffected()
+		if res.RowsAffected() < deleteBatchSize {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateFeatureSchema adds the next schema version of the feature; a nil
+// schema adds a version that turns validation off. Versions are added under
+// the change lock, so a banner change either sees the new version or commits
+// before it, and the row lock of the feature orders concurrent versions.
+func (c *CatalogRepository) CreateFeatureSchema(ctx context.Context, featureId uint64, schema json.RawMessage) (models.FeatureSchema, error) {
+	const (
+		lockFeatureQuery = `select feature_id from feature where feature_id = $1 for update`
+
+		insertSchemaQuery = `
+            insert into feature_schema (feature_id, version, schema, created_by)
+            values ($1, coalesce((select max(version) from feature_schema where feature_id = $1), 0) + 1, $2, $3)
+            returning feature_id, version, schema, created_by, created_at`
+	)
+
+	var created models.FeatureSchema
+	err := runChangeTx(ctx, c.pool, func(tx pgx.Tx) error {
+		var locked uint64
+		if err := pgxscan.Get(ctx, tx, &locked, lockFeatureQuery, featureId); errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: feature %d", ErrNotFound, featureId)
+		} else if err != nil {
+			return err
+		}
+		return pgxscan.Get(ctx, tx, &created, insertSchemaQuery, featureId, schema, reqctx.GetUsername(ctx))
+	})
+	if err != nil {
+		return models.FeatureSchema{}, err
+	}
+
+	return created, nil
+}
+
+// checkSchemaVersions fails with ErrAlreadyExists when a schema that content
+// of the request was checked against is no longer in force. It must run under
+// the change lock.
+func checkSchemaVersions(ctx context.Context, tx pgx.Tx, versions models.SchemaVersions) error {
+	const (
+		selectChangedQuery = `
+            select c.feature_id
+            from unnest($1::int[], $2::int[]) as c(feature_id, version)
+            where c.version <> coalesce((select max(s.version) from feature_schema s where s.feature_id = c.feature_id), 0)
+            order by c.feature_id
+            limit 1`
+	)
+
+	if len(versions) == 0 {
+		return nil
+	}
+	featureIds := make([]uint64, 0, len(versions))
+	checked := make([]uint64, 0, len(versions))
+	for featureId, version := range versions {
+		featureIds = append(featureIds, featureId)
+		checked = append(checked, version)
+	}
+
+	var changed uint64
+	if err := pgxscan.Get(ctx, tx, &changed, selectChangedQuery, featureIds, checked); errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: schema of feature %d was changed concurrently", ErrAlreadyExists, changed)
+}
+
+// GetFeatureSchema returns the given schema version of the feature, or the
+// latest one if version is zero.
+func (c *CatalogRepository) GetFeatureSchema(ctx context.Context, featureId, version uint64) (models.FeatureSchema, error) {
+	return selectFeatureSchema(ctx, c.pool, featureId, version)
+}
+
+func selectFeatureSchema(ctx context.Context, q pgxscan.Querier, featureId, version uint64) (models.FeatureSchema, error) {
+	const (
+		selectSchemaQuery = `
+            select feature_id, version, schema, created_by, created_at
+            from feature_schema
+            where feature_id = $1 and ($2 = 0 or version = $2)
+            order by version desc
+            limit 1`
+	)
+
+	var schema models.FeatureSchema
+	if err := pgxscan.Get(ctx, q, &schema, selectSchemaQuery, featureId, version); errors.Is(err, pgx.ErrNoRows) {
+		return models.FeatureSchema{}, ErrNotFound
+	} else if err != nil {
+		return models.FeatureSchema{}, err
+	}
+
+	return schema, nil
+}
+
+func (c *CatalogRepository) GetFeatureSchemas(ctx context.Context, featureId uint64) ([]models.FeatureSchema, error) {
+	const (
+		selectSchemasQuery = `
+            select feature_id, version, schema, created_by, created_at
+            from feature_schema
+            where feature_id = $1
+            order by version desc`
+	)
+
+	schemas := make([]models.FeatureSchema, 0)
+	if err := pgxscan.Select(ctx, c.pool, &schemas, selectSchemasQuery, featureId); err != nil {
+		return nil, err
+	}
+
+	return schemas, nil
+}
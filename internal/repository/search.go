@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// SearchBanners matches the query against the search_vector of banner
+// versions, which the database keeps in sync with their content. The matches
+// in the highlights are enclosed in models.HighlightStart and HighlightStop.
+func (b *BannerRepository) SearchBanners(ctx context.Context, search *models.BannerSearch) ([]models.BannerSearchResult, error) {
+	const (
+		searchBannersQuery = `
+            select b.banner_id, f.feature_id, f.tag_ids, bv.content, b.is_active, bv.version, b.created_at,
+                   bv.updated_at, bv.version = b.active_version as is_current,
+                   ts_rank(bv.search_vector, q.query)::float8 as rank,
+                   ts_headline('simple', bv.content, q.query, $5) as highlight
+            from websearch_to_tsquery('simple', $1) as q(query)
+            join banner_version bv on bv.search_vector @@ q.query
+            join banner b on b.banner_id = bv.banner_id
+            join lateral (select min(bft.feature_id) as feature_id, array_agg(distinct bft.tag_id) as tag_ids
+                          from banner_feature_tag bft
+                          where bft.banner_id = b.banner_id) f on true
+            where b.deleted_at is null
+              and ($2 or bv.version = b.active_version)
+            order by rank desc, b.banner_id desc, bv.version desc
+            limit $3 offset $4`
+	)
+
+	// The matches are marked with the sentinels rather than <mark>, which
+	// could not be told apart from markup in the content when it is escaped.
+	headlineOptions := fmt.Sprintf(`StartSel="%s", StopSel="%s", HighlightAll=true`, models.HighlightStart, models.HighlightStop)
+
+	results := make([]models.BannerSearchResult, 0)
+	if err := pgxscan.Select(ctx, b.pool, &results, searchBannersQuery,
+		search.Query, search.WithHistory, search.Limit, search.Offset, headlineOptions); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"github.com/georgysavva/scany/v2/pgxscan"
+)
+
+// GetRevision returns the id of the newest committed event. Event ids are
+// allocated in commit order (see runChangeTx), so no event committed later can
+// get a lower id and clients may resume from the revision safely.
+func (b *BannerRepository) GetRevision(ctx context.Context) (uint64, error) {
+	const (
+		selectRevisionQuery = `select coalesce(max(event_id), 0) from outbox_event`
+	)
+
+	var revision uint64
+	if err := pgxscan.Get(ctx, b.pool, &revision, selectRevisionQuery); err != nil {
+		return 0, err
+	}
+
+	return revision, nil
+}
+
+// GetLatestTagEventId returns the id of the newest event after afterEventId
+// that touched any banner of the tag, or 0 if there is none.
+func (b *BannerRepository) GetLatestTagEventId(ctx context.Context, tagId, afterEventId uint64) (uint64, error) {
+	const (
+		selectLatestTagEventIdQuery = `
+            select coalesce(max(event_id), 0)
+            from outbox_event
+            where event_id > $2
+              and (payload -> 'after' -> 'tag_ids' @> to_jsonb($1::bigint)
+                or payload -> 'before' -> 'tag_ids' @> to_jsonb($1::bigint))`
+	)
+
+	var eventId uint64
+	if err := pgxscan.Get(ctx, b.pool, &eventId, selectLatestTagEventIdQuery, tagId, afterEventId); err != nil {
+		return 0, err
+	}
+
+	return eventId, nil
+}
+
+func (b *BannerRepository) GetTagContents(ctx context.Context, tagId uint64) ([]models.FeatureContent, error) {
+	const (
+		selectTagContentsQuery = `
+            select bft.feature_id, bv.content
+            from banner_feature_tag bft
+            join banner b using (banner_id)
+            join banner_version bv on b.banner_id = bv.banner_id and b.active_version = bv.version
+            where bft.tag_id = $1 and b.is_active and b.deleted_at is null
+            order by bft.feature_id`
+	)
+
+	contents := make([]models.FeatureContent, 0)
+	if err := pgxscan.Select(ctx, b.pool, &contents, selectTagContentsQuery, tagId); err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"time"
+)
+
+// errDryRun rolls back a dry-run import once it has been applied.
+var errDryRun = errors.New("dry run")
+
+type exportRow struct {
+	BannerId      uint64               `db:"banner_id"`
+	FeatureId     uint64               `db:"feature_id"`
+	TagIds        []uint64             `db:"tag_ids"`
+	IsActive      bool                 `db:"is_active"`
+	Priority      int                  `db:"priority"`
+	FrequencyCap  *models.FrequencyCap `db:"frequency_cap"`
+	ActiveVersion uint64               `db:"active_version"`
+	CreatedAt     time.Time            `db:"created_at"`
+	Versions      []uint64             `db:"versions"`
+	Contents      []string             `db:"contents"`
+	Localized     []string             `db:"localized"`
+	Locales       []string             `db:"default_locales"`
+	UpdatedAts    []time.Time          `db:"updated_ats"`
+}
+
+// ExportBanners streams every banner that is not marked as deleted, with all
+// its versions, to fn in banner_id order.
+func (b *BannerRepository) ExportBanners(ctx context.Context, fn func(models.BannerExport) error) error {
+	const (
+		exportBannersQuery = `
+            select b.banner_id, f.feature_id, f.tag_ids, b.is_active, b.priority, b.frequency_cap, b.active_version,
+                   b.created_at, v.versions, v.contents, v.localized, v.default_locales, v.updated_ats
+            from banner b
+            join lateral (select min(bft.feature_id) as feature_id, array_agg(distinct bft.tag_id) as tag_ids
+                          from banner_feature_tag bft
+                          where bft.banner_id = b.banner_id) f on true
+            join lateral (select array_agg(bv.version order by bv.version) as versions,
+                                 array_agg(bv.content::text order by bv.version) as contents,
+                                 array_agg(bv.localized::text order by bv.version) as localized,
+                                 array_agg(bv.default_locale order by bv.version) as default_locales,
+                                 array_agg(bv.updated_at order by bv.version) as updated_ats
+                          from banner_version bv
+                          where bv.banner_id = b.banner_id) v on true
+            where b.deleted_at is null and f.feature_id is not null
+            order by b.banner_id`
+	)
+
+	rows, err := b.pool.Query(ctx, exportBannersQuery)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	scanner := pgxscan.NewRowScanner(rows)
+	for rows.Next() {
+		var row exportRow
+		if err = scanner.Scan(&row); err != nil {
+			return err
+		}
+
+		banner := models.BannerExport{
+			BannerId:      row.BannerId,
+			FeatureId:     row.FeatureId,
+			TagIds:        row.TagIds,
+			IsActive:      row.IsActive,
+			Priority:      row.Priority,
+			FrequencyCap:  row.FrequencyCap,
+			ActiveVersion: row.ActiveVersion,
+			CreatedAt:     row.CreatedAt,
+			Versions:      make([]models.BannerVersion, 0, len(row.Versions)),
+		}
+		for i, version := range row.Versions {
+			bannerVersion := models.BannerVersion{
+				Version:       version,
+				Content:       []byte(row.Contents[i]),
+				DefaultLocale: row.Locales[i],
+				UpdatedAt:     row.UpdatedAts[i],
+			}
+			if err = json.Unmarshal([]byte(row.Localized[i]), &bannerVersion.Localized); err != nil {
+				return err
+			}
+			if len(bannerVersion.Localized) == 0 {
+				bannerVersion.Localized = nil
+			}
+			banner.Versions = append(banner.Versions, bannerVersion)
+		}
+
+		if err = fn(banner); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// ImportBanners applies validated banners in a single transaction and returns
+// a result per banner, in order, together with the feature and tag pairs the
+// import changed. Banners whose feature and tag already belong to another
+// banner are reported as failed and skipped. A concurrent change that makes
+// the copy itself conflict rolls the whole chunk back with ErrAlreadyExists,
+// as does a schema of schemaVersions replaced since the banners were checked.
+func (b *BannerRepository) ImportBanners(ctx context.Context, banners []models.BannerExport, opts models.ImportOptions,
+	schemaVersions models.SchemaVersions) ([]models.ImportLineResult, []models.FeatureTag, error) {
+	const (
+		selectExistingQuery = `select banner_id from banner where banner_id = any($1)`
+
+		selectTakenPairsQuery = `
+            select bft.banner_id, bft.feature_id, bft.tag_id
+            from banner_feature_tag bft
+            join unnest($1::int[], $2::int[]) as p(feature_id, tag_id)
+                 on p.feature_id = bft.feature_id and p.tag_id = bft.tag_id
+            where not bft.deleted`
+
+		allocateIdsQuery = `
+            select nextval(pg_get_serial_sequence('banner', 'banner_id'))
+            from generate_series(1, $1)`
+
+		// A replaced banner keeps its row, so the features using it as their
+		// default keep it; its pairs and references are written anew.
+		deleteFeatureTagsQuery   = `delete from banner_feature_tag where banner_id = any($1)`
+		deleteVersionAssetsQuery = `delete from banner_version_asset where banner_id = any($1)`
+
+		// Banners imported under their own id must not be handed out again.
+		syncSequenceQuery = `
+            select setval(pg_get_serial_sequence('banner', 'banner_id'),
+                          greatest((select max(banner_id) from banner), (select last_value from banner_banner_id_seq)))`
+	)
+
+	results := make([]models.ImportLineResult, len(banners))
+	var affected []models.FeatureTag
+
+	err := runChangeTx(ctx, b.pool, func(tx pgx.Tx) error {
+		if err := checkSchemaVersions(ctx, tx, schemaVersions); err != nil {
+			return err
+		}
+
+		existing := make(map[uint64]bool)
+		if opts.Mode == models.ImportUpsert {
+			ids := make([]uint64, 0, len(banners))
+			for _, banner := range banners {
+				if banner.BannerId != 0 {
+					ids = append(ids, banner.BannerId)
+				}
+			}
+
+			var existingIds []uint64
+			if err := pgxscan.Select(ctx, tx, &existingIds, selectExistingQuery, ids); err != nil {
+				return err
+			}
+			for _, id := range existingIds {
+				existing[id] = true
+			}
+		}
+
+		taken, err := selectTakenPairs(ctx, tx, selectTakenPairsQuery, banners)
+		if err != nil {
+			return err
+		}
+
+		var newCount int64
+		apply := make([]bool, len(banners))
+		for i, banner := range banners {
+			replaced := opts.Mode == models.ImportUpsert && existing[banner.BannerId]
+			if owner, pair, ok := takenPair(taken, banner); ok && !(replaced && owner == banner.BannerId) {
+				results[i] = models.ImportLineResult{
+					Status: models.ImportFailed,
+					Error:  fmt.Sprintf("feature %d and tag %d already belong to banner %d", pair.FeatureId, pair.TagId, owner),
+				}
+				continue
+			}
+
+			apply[i] = true
+			if replaced {
+				results[i] = models.ImportLineResult{Status: models.ImportUpdated, BannerId: banner.BannerId}
+				continue
+			}
+			results[i] = models.ImportLineResult{Status: models.ImportCreated}
+			if opts.Mode == models.ImportUpsert && banner.BannerId != 0 {
+				results[i].BannerId = banner.BannerId
+			} else {
+				newCount++
+			}
+		}
+
+		// A dry run stops before new banners get ids: a sequence is not rolled
+		// back, so allocating them would leave a gap for every line.
+		if opts.DryRun {
+			return errDryRun
+		}
+
+		var newIds []uint64
+		if newCount > 0 {
+			if err = pgxscan.Select(ctx, tx, &newIds, allocateIdsQuery, newCount); err != nil {
+				return err
+			}
+		}
+
+		befores := make(map[uint64]*models.Banner)
+		replacedIds := make([]uint64, 0)
+		explicitIds := false
+		for i := range banners {
+			if !apply[i] {
+				continue
+			}
+			switch {
+			case results[i].Status == models.ImportUpdated:
+				before, err := selectBannerSnapshot(ctx, tx, results[i].BannerId)
+				if err != nil {
+					return err
+				}
+				befores[results[i].BannerId] = before
+				replacedIds = append(replacedIds, results[i].BannerId)
+			case results[i].BannerId != 0:
+				explicitIds = true
+			default:
+				results[i].BannerId, newIds = newIds[0], newIds[1:]
+			}
+		}
+
+		if len(replacedIds) > 0 {
+			for _, query := range []string{deleteFeatureTagsQuery, deleteVersionAssetsQuery} {
+				if _, err = tx.Exec(ctx, query, replacedIds); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err = copyBanners(ctx, tx, banners, results, apply); err != nil {
+			return err
+		}
+
+		if explicitIds {
+			if _, err = tx.Exec(ctx, syncSequenceQuery); err != nil {
+				return err
+			}
+		}
+
+		for i, banner := range banners {
+			if !apply[i] {
+				continue
+			}
+
+			after := importedSnapshot(results[i].BannerId, banner)
+			before := befores[results[i].BannerId]
+			action := models.AuditCreate
+			if before != nil {
+				action = models.AuditUpdate
+				affected = append(affected, featureTags(before.FeatureId, before.TagIds)...)
+			}
+			affected = append(affected, featureTags(after.FeatureId, after.TagIds)...)
+
+			if err = recordChange(ctx, tx, action, results[i].BannerId, before, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.Is(err, errDryRun) {
+		return results, nil, nil
+	} else if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return nil, nil, fmt.Errorf("%w: %s", ErrAlreadyExists, pgErr.Message)
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	return results, affected, nil
+}
+
+func selectTakenPairs(ctx context.Context, tx pgx.Tx, query string, banners []models.BannerExport) (map[models.FeatureTag]uint64, error) {
+	var featureIds, tagIds []uint64
+	for _, banner := range banners {
+		for _, tagId := range banner.TagIds {
+			featureIds = append(featureIds, banner.FeatureId)
+			tagIds = append(tagIds, tagId)
+		}
+	}
+
+	var rows []struct {
+		BannerId  uint64 `db:"banner_id"`
+		FeatureId uint64 `db:"feature_id"`
+		TagId     uint64 `db:"tag_id"`
+	}
+	if err := pgxscan.Select(ctx, tx, &rows, query, featureIds, tagIds); err != nil {
+		return nil, err
+	}
+
+	taken := make(map[models.FeatureTag]uint64, len(rows))
+	for _, row := range rows {
+		taken[models.FeatureTag{FeatureId: row.FeatureId, TagId: row.TagId}] = row.BannerId
+	}
+	return taken, nil
+}
+
+// takenPair returns the first feature and tag pair of the banner that is
+// already stored, and the banner owning it.
+func takenPair(taken map[models.FeatureTag]uint64, banner models.BannerExport) (uint64, models.FeatureTag, bool) {
+	for _, tagId := range banner.TagIds {
+		pair := models.FeatureTag{FeatureId: banner.FeatureId, TagId: tagId}
+		if owner, ok := taken[pair]; ok {
+			return owner, pair, true
+		}
+	}
+	return 0, models.FeatureTag{}, false
+}
+
+// copyBanners writes the applied banners. New banners are copied; banners an
+// upsert replaces are updated in place, their versions overwritten and the
+// versions missing from the import removed.
+func copyBanners(ctx context.Context, tx pgx.Tx, banners []models.BannerExport, results []models.ImportLineResult, apply []bool) error {
+	const (
+		updateBannerQuery = `
+            update banner
+            set is_active = $2, priority = $3, frequency_cap = $4, active_version = $5, created_at = $6,
+                updated_at = $7, deleted_at = null
+            where banner_id = $1`
+
+		upsertVersionQuery = `
+            insert into banner_version (banner_id, version, content, localized, default_locale, updated_at)
+            values ($1, $2, $3, $4, $5, $6)
+            on conflict (banner_id, version) do update
+            set content = excluded.content, localized = excluded.localized,
+                default_locale = excluded.default_locale, updated_at = excluded.updated_at`
+
+		deleteStaleVersionsQuery = `delete from banner_version where banner_id = $1 and version <> all($2::int[])`
+	)
+
+	var bannerRows, versionRows, featureTagRows [][]any
+	var references []versionAssets
+	for i, banner := range banners {
+		if !apply[i] {
+			continue
+		}
+		bannerId := results[i].BannerId
+		replaced := results[i].Status == models.ImportUpdated
+
+		bannerRow := []any{
+			bannerId, banner.IsActive, banner.Priority, banner.FrequencyCap, banner.ActiveVersion, banner.CreatedAt,
+			banner.Active().UpdatedAt,
+		}
+		if replaced {
+			if _, err := tx.Exec(ctx, updateBannerQuery, bannerRow...); err != nil {
+				return err
+			}
+		} else {
+			bannerRows = append(bannerRows, bannerRow)
+		}
+
+		versions := make([]uint64, 0, len(banner.Versions))
+		for _, version := range banner.Versions {
+			localized, err := localizedJSON(version.Localized)
+			if err != nil {
+				return err
+			}
+			localizedText := "{}"
+			if localized != nil {
+				localizedText = *localized
+			}
+			versionRow := []any{
+				bannerId, version.Version, string(version.Content), localizedText, version.DefaultLocale, version.UpdatedAt,
+			}
+			if replaced {
+				if _, err = tx.Exec(ctx, upsertVersionQuery, versionRow...); err != nil {
+					return err
+				}
+			} else {
+				versionRows = append(versionRows, versionRow)
+			}
+			versions = append(versions, version.Version)
+			references = append(references, newVersionAssets(bannerId, version.Version, version.Content, version.Localized))
+		}
+		if replaced {
+			if _, err := tx.Exec(ctx, deleteStaleVersionsQuery, bannerId, versions); err != nil {
+				return err
+			}
+		}
+		for _, tagId := range banner.TagIds {
+			featureTagRows = append(featureTagRows, []any{bannerId, tagId, banner.FeatureId})
+		}
+	}
+
+	copies := []struct {
+		table   string
+		columns []string
+		rows    [][]any
+	}{
+		{"banner", []string{"banner_id", "is_active", "priority", "frequency_cap", "active_version", "created_at", "updated_at"}, bannerRows},
+		{"banner_version", []string{"banner_id", "version", "content", "localized", "default_locale", "updated_at"}, versionRows},
+		{"banner_feature_tag", []string{"banner_id", "tag_id", "feature_id"}, featureTagRows},
+	}
+	for _, c := range copies {
+		if len(c.rows) == 0 {
+			continue
+		}
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{c.table}, c.columns, pgx.CopyFromRows(c.rows)); err != nil {
+			return err
+		}
+	}
+	return referenceAssets(ctx, tx, references)
+}
+
+func importedSnapshot(bannerId uint64, banner models.BannerExport) *models.Banner {
+	active := banner.Active()
+	return &models.Banner{
+		BannerId:      bannerId,
+		FeatureId:     banner.FeatureId,
+		TagIds:        banner.TagIds,
+		Content:       active.Content,
+		Localized:     active.Localized,
+		DefaultLocale: active.DefaultLocale,
+		IsActive:      banner.IsActive,
+		Priority:      banner.Priority,
+		FrequencyCap:  banner.FrequencyCap,
+		Version:       active.Version,
+		CreatedAt:     banner.CreatedAt,
+		UpdatedAt:     active.UpdatedAt,
+	}
+}
+
+func featureTags(featureId uint64, tagIds []uint64) []models.FeatureTag {
+	pairs := make([]models.FeatureTag, 0, len(tagIds))
+	for _, tagId := range tagIds {
+		pairs = append(pairs, models.FeatureTag{FeatureId: featureId, TagId: tagId})
+	}
+	return pairs
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/georgysavva/scany/v2/pgxscan"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+const (
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 1000
+)
+
+type WebhookRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookRepository(p *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		pool: p,
+	}
+}
+
+func (wr *WebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (uint64, error) {
+	const (
+		createSubscriptionQuery = `
+            insert into webhook_subscription (url, secret, feature_id, tag_id, event_types, is_active)
+            values ($1, $2, $3, $4, $5, $6)
+            returning subscription_id`
+	)
+
+	eventTypes := sub.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	var subscriptionId uint64
+	if err := pgxscan.Get(ctx, wr.pool, &subscriptionId, createSubscriptionQuery,
+		sub.URL, sub.Secret, sub.FeatureId, sub.TagId, eventTypes, sub.IsActive); err != nil {
+		return 0, err
+	}
+
+	return subscriptionId, nil
+}
+
+func (wr *WebhookRepository) GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	const (
+		selectSubscriptionsQuery = `
+            select subscription_id, url, feature_id, tag_id, event_types, is_active, created_at
+            from webhook_subscription
+            order by subscription_id`
+	)
+
+	subs := make([]models.WebhookSubscription, 0)
+	if err := pgxscan.Select(ctx, wr.pool, &subs, selectSubscriptionsQuery); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (wr *WebhookRepository) GetSubscription(ctx context.Context, subscriptionId uint64) (models.WebhookSubscription, error) {
+	const (
+		selectSubscriptionQuery = `
+            select subscription_id, url, feature_id, tag_id, event_types, is_active, created_at
+            from webhook_subscription
+            where subscription_id = $1`
+	)
+
+	var sub models.WebhookSubscription
+	if err := pgxscan.Get(ctx, wr.pool, &sub, selectSubscriptionQuery, subscriptionId); errors.Is(err, pgx.ErrNoRows) {
+		return models.WebhookSubscription{}, ErrNotFound
+	} else if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	return sub, nil
+}
+
+func (wr *WebhookRepository) UpdateSubscription(ctx context.Context, subscriptionId uint64, patch *models.PatchWebhookSubscription) error {
+	const (
+		updateSubscriptionQuery = `
+            update webhook_subscription
+            set url = coalesce($2, url),
+                feature_id = case when $7 then $3 else feature_id end,
+                tag_id = case when $8 then $4 else tag_id end,
+                event_types = coalesce($5, event_types),
+                is_active = coalesce($6, is_active)
+            where subscription_id = $1`
+	)
+
+	res, err := wr.pool.Exec(ctx, updateSubscriptionQuery, subscriptionId, patch.URL, patch.FeatureId, patch.TagId,
+		patch.EventTypes, patch.IsActive, patch.HasFeatureId, patch.HasTagId)
+	if err != nil {
+		return err
+	} else if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) DeleteSubscription(ctx context.Context, subscriptionId uint64) error {
+	const (
+		deleteSubscriptionQuery = `delete from webhook_subscription where subscription_id = $1`
+	)
+
+	res, err := wr.pool.Exec(ctx, deleteSubscriptionQuery, subscriptionId)
+	if err != nil {
+		return err
+	} else if res.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (wr *WebhookRepository) GetDeliveries(ctx context.Context, filter *models.DeliveryFilter) ([]models.WebhookDelivery, error) {
+	const (
+		selectDeliveriesQuery = `
+            select delivery_id, subscription_id, event_id, event_type, payload, status, attempts,
+                   response_status, last_error, next_attempt_at, created_at, delivered_at
+            from webhook_delivery
+            where subscription_id = $1 and ($2::text is null or status = $2)
+            order by delivery_id desc
+            limit $3`
+	)
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultDeliveryLimit
+	} else if limit > maxDeliveryLimit {
+		limit = maxDeliveryLimit
+	}
+
+	var status *string
+	if filter.Status != nil {
+		str := string(*filter.Status)
+		status = &str
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	if err := pgxscan.Select(ctx, wr.pool, &deliveries, selectDeliveriesQuery, filter.SubscriptionId, status, limit); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// EnqueueDeliveries fans a banner event out to every active subscription whose
+// filters match the banner before or after the change.
+func (wr *WebhookRepository) EnqueueDeliveries(ctx context.Context, event models.OutboxEvent) error {
+	const (
+		enqueueDeliveriesQuery = `
+            insert into webhook_delivery (subscription_id, event_id, event_type, payload)
+            select s.subscription_id, $1, $2, $3
+            from webhook_subscription s
+            where s.is_active
+              and (s.feature_id is null or s.feature_id = any($4))
+              and (s.tag_id is null or s.tag_id = any($5))
+              and (cardinality(s.event_types) = 0 or $2 = any(s.event_types))
+            on conflict (subscription_id, event_id) do nothing`
+	)
+
+	var payload models.BannerEventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+
+	featureIds := make([]uint64, 0, 2)
+	tagIds := make([]uint64, 0)
+	for _, banner := range []*models.Banner{payload.Before, payload.After} {
+		if banner == nil {
+			continue
+		}
+		featureIds = append(featureIds, banner.FeatureId)
+		tagIds = append(tagIds, banner.TagIds...)
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = wr.pool.Exec(ctx, enqueueDeliveriesQuery, event.EventId, event.Type, string(body), featureIds, tagIds)
+	return err
+}
+
+func (wr *WebhookRepository) ClaimDeliveries(ctx context.Context, limit uint64, lease time.Duration) ([]models.PendingDelivery, error) {
+	const (
+		claimDeliveriesQuery = `
+            update webhook_delivery d
+            set next_attempt_at = now() + $2 * interval '1 second'
+            from webhook_subscription s
+            where s.subscription_id = d.subscription_id
+              and d.delivery_id in
+                  (select delivery_id
+                   from webhook_delivery
+                   where status = 'pending' and next_attempt_at <= now()
+                   order by delivery_id
+                   limit $1
+                   for update skip locked)
+            returning d.delivery_id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status,
+                      d.attempts, d.response_status, d.last_error, d.next_attempt_at, d.created_at,
+                      d.delivered_at, s.url, s.secret`
+	)
+
+	deliveries := make([]models.PendingDelivery, 0)
+	if err := pgxscan.Select(ctx, wr.pool, &deliveries, claimDeliveriesQuery, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (wr *WebhookRepository) MarkDeliveryDelivered(ctx context.Context, deliveryId uint64, responseStatus int) error {
+	const (
+		markDeliveredQuery = `
+            update webhook_delivery
+            set status = 'delivered', attempts = attempts + 1, response_status = $2,
+                last_error = '', delivered_at = now()
+            where delivery_id = $1`
+	)
+
+	_, err := wr.pool.Exec(ctx, markDeliveredQuery, deliveryId, responseStatus)
+	return err
+}
+
+func (wr *WebhookRepository) MarkDeliveryFailed(ctx context.Context, deliveryId uint64, responseStatus *int, lastError string, backoff time.Duration, dead bool) error {
+	const (
+		markFailedQuery = `
+            update webhook_delivery
+            set status = case when $5 then 'dead' else 'pending' end,
+                attempts = attempts + 1,
+                response_status = $2,
+                last_error = $3,
+                next_attempt_at = now() + $4 * interval '1 second'
+            where delivery_id = $1`
+	)
+
+	_, err := wr.pool.Exec(ctx, markFailedQuery, deliveryId, responseStatus, lastError, backoff.Seconds(), dead)
+	return err
+}
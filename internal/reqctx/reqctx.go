@@ -0,0 +1,52 @@
+package reqctx
+
+import "context"
+
+type usernameKey struct{}
+
+type requestIdKey struct{}
+
+type claimsKey struct{}
+
+type shutdownKey struct{}
+
+func SetUsername(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, usernameKey{}, username)
+}
+
+func GetUsername(ctx context.Context) string {
+	username, _ := ctx.Value(usernameKey{}).(string)
+	return username
+}
+
+func SetRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func GetRequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+func SetClaims(ctx context.Context, claims map[string]string) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func GetClaims(ctx context.Context) map[string]string {
+	claims, _ := ctx.Value(claimsKey{}).(map[string]string)
+	return claims
+}
+
+// SetShutdown marks the base context of a server with a channel that is closed
+// when the server starts shutting down.
+func SetShutdown(ctx context.Context, shutdown <-chan struct{}) context.Context {
+	return context.WithValue(ctx, shutdownKey{}, shutdown)
+}
+
+// GetShutdown returns the channel of SetShutdown. Streams return when it is
+// closed instead of holding the server until the drain deadline; without a
+// server it is nil, which never fires.
+func GetShutdown(ctx context.Context) <-chan struct{} {
+	shutdown, _ := ctx.Value(shutdownKey{}).(<-chan struct{})
+	return shutdown
+}
@@ -0,0 +1,109 @@
+package schema
+
+import (
+	"banner-service/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"io"
+	"sort"
+)
+
+// Draft is the only dialect accepted in "$schema"; schemas without it are
+// read as this draft too.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+const resourceURL = "feature.schema.json"
+
+var ErrInvalidSchema = errors.New("invalid schema")
+
+// ViolationError is returned when content does not conform to the schema of
+// its feature. Locale is set when localized content is at fault.
+type ViolationError struct {
+	FeatureId     uint64
+	SchemaVersion uint64
+	Locale        string
+	Violations    []models.SchemaViolation
+}
+
+func (e *ViolationError) Error() string {
+	if e.Locale != "" {
+		return fmt.Sprintf("content in %s does not conform to version %d of the schema of feature %d",
+			e.Locale, e.SchemaVersion, e.FeatureId)
+	}
+	return fmt.Sprintf("content does not conform to version %d of the schema of feature %d",
+		e.SchemaVersion, e.FeatureId)
+}
+
+// Schema is a compiled JSON Schema, safe for concurrent use.
+type Schema struct {
+	schema *jsonschema.Schema
+}
+
+// Compile parses a draft 2020-12 schema. References to other documents are
+// not resolved, so a schema is self-contained. Formats are asserted.
+func Compile(raw json.RawMessage) (*Schema, error) {
+	var header struct {
+		Schema *string `json:"$schema"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("%w: schema must be a JSON object: %v", ErrInvalidSchema, err)
+	} else if header.Schema != nil && *header.Schema != Draft {
+		return nil, fmt.Errorf("%w: unsupported $schema %q, only %s is accepted", ErrInvalidSchema, *header.Schema, Draft)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external reference %s is not allowed", s)
+	}
+	if err := compiler.AddResource(resourceURL, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	compiled, err := compiler.Compile(resourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	return &Schema{schema: compiled}, nil
+}
+
+// Validate checks the content and returns every violation, ordered by path.
+// Invalid JSON is reported as a violation of the whole document.
+func (s *Schema) Validate(content json.RawMessage) []models.SchemaViolation {
+	var document any
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	if err := dec.Decode(&document); err != nil {
+		return []models.SchemaViolation{{Path: "", Message: fmt.Sprintf("invalid JSON: %v", err)}}
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if err := s.schema.Validate(document); err == nil {
+		return nil
+	} else if !errors.As(err, &validationErr) {
+		return []models.SchemaViolation{{Path: "", Message: err.Error()}}
+	}
+
+	violations := make([]models.SchemaViolation, 0)
+	collectViolations(validationErr, &violations)
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations
+}
+
+// collectViolations keeps the leaves of the error tree: the inner nodes only
+// say that a subschema failed.
+func collectViolations(err *jsonschema.ValidationError, violations *[]models.SchemaViolation) {
+	if len(err.Causes) == 0 {
+		*violations = append(*violations, models.SchemaViolation{Path: err.InstanceLocation, Message: err.Message})
+		return
+	}
+	for _, cause := range err.Causes {
+		collectViolations(cause, violations)
+	}
+}
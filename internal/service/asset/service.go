@@ -0,0 +1,109 @@
+package asset
+
+import (
+	"banner-service/internal/asset"
+	"banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"os"
+)
+
+// sniffLen is how much of a file MIME type detection looks at.
+const sniffLen = 512
+
+type Repository interface {
+	CreateAsset(ctx context.Context, asset *models.Asset, place func() error) (models.Asset, bool, error)
+	GetAsset(ctx context.Context, hash string) (models.Asset, error)
+}
+
+type Deps struct {
+	AssetRepo Repository
+	Storage   asset.Storage
+	// MaxSize limits the size of a file in bytes.
+	MaxSize int64
+	// MimeTypes are the types of files that may be uploaded, detected from
+	// the content rather than taken from the request.
+	MimeTypes []string
+}
+
+type Service struct {
+	Deps
+}
+
+func NewService(d Deps) *Service {
+	return &Service{
+		Deps: d,
+	}
+}
+
+var _ http.AssetManagement = (*Service)(nil)
+
+// UploadAsset stores the file and records it. The file is written aside and
+// put in place while the record is written, under the lock the asset
+// collector takes too. Uploading a file that is already stored returns the
+// existing asset with created set to false.
+func (s *Service) UploadAsset(ctx context.Context, r io.Reader) (models.Asset, bool, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return models.Asset{}, false, err
+	}
+	head = head[:n]
+
+	mimeType, _, err := mime.ParseMediaType(nethttp.DetectContentType(head))
+	if err != nil {
+		return models.Asset{}, false, err
+	}
+	if n == 0 || !lo.Contains(s.MimeTypes, mimeType) {
+		return models.Asset{}, false, fmt.Errorf("%w: %s is not one of %v", asset.ErrUnsupportedType, mimeType, s.MimeTypes)
+	}
+
+	staged, err := s.Storage.Stage(ctx, asset.LimitReader(io.MultiReader(bytes.NewReader(head), r), s.MaxSize))
+	if err != nil {
+		return models.Asset{}, false, err
+	}
+	defer s.Storage.Discard(staged)
+
+	stored, created, err := s.AssetRepo.CreateAsset(ctx, &models.Asset{Hash: staged.Hash, MimeType: mimeType, Size: staged.Size}, func() error {
+		return s.Storage.Place(ctx, staged)
+	})
+	if err != nil {
+		return models.Asset{}, false, err
+	}
+	stored.URL = URL(stored.Hash)
+	return stored, created, nil
+}
+
+// OpenAsset returns the asset and its content, which the caller closes.
+func (s *Service) OpenAsset(ctx context.Context, hash string) (models.Asset, io.ReadCloser, error) {
+	if !asset.ValidHash(hash) {
+		return models.Asset{}, nil, repository.ErrNotFound
+	}
+
+	stored, err := s.AssetRepo.GetAsset(ctx, hash)
+	if err != nil {
+		return models.Asset{}, nil, err
+	}
+	content, err := s.Storage.Open(ctx, hash)
+	if errors.Is(err, os.ErrNotExist) {
+		return models.Asset{}, nil, fmt.Errorf("%w: file of asset %s", repository.ErrNotFound, hash)
+	} else if err != nil {
+		return models.Asset{}, nil, err
+	}
+
+	stored.URL = URL(stored.Hash)
+	return stored, content, nil
+}
+
+// URL is the path banner content refers to the asset by.
+func URL(hash string) string {
+	return "/assets/" + hash
+}
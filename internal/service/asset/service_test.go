@@ -0,0 +1,91 @@
+package asset
+
+import (
+	"banner-service/internal/asset"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+type memoryRepository map[string]models.Asset
+
+func (m memoryRepository) CreateAsset(_ context.Context, a *models.Asset, place func() error) (models.Asset, bool, error) {
+	if err := place(); err != nil {
+		return models.Asset{}, false, err
+	}
+	if existing, ok := m[a.Hash]; ok {
+		return existing, false, nil
+	}
+	m[a.Hash] = *a
+	return *a, true, nil
+}
+
+func (m memoryRepository) GetAsset(_ context.Context, hash string) (models.Asset, error) {
+	if a, ok := m[hash]; ok {
+		return a, nil
+	}
+	return models.Asset{}, repository.ErrNotFound
+}
+
+// png is the smallest content sniffed as image/png.
+var png = append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 56)...)
+
+func newTestService(t *testing.T, maxSize int64) *Service {
+	t.Helper()
+
+	storage, err := asset.NewFileStorage(t.TempDir())
+	require.NoError(t, err)
+	return NewService(Deps{
+		AssetRepo: memoryRepository{},
+		Storage:   storage,
+		MaxSize:   maxSize,
+		MimeTypes: []string{"image/png"},
+	})
+}
+
+func TestUploadAsset(t *testing.T) {
+	s := newTestService(t, int64(len(png)))
+	ctx := context.Background()
+
+	uploaded, created, err := s.UploadAsset(ctx, bytes.NewReader(png))
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "image/png", uploaded.MimeType)
+	assert.Equal(t, int64(len(png)), uploaded.Size)
+	assert.Equal(t, "/assets/"+uploaded.Hash, uploaded.URL)
+
+	again, created, err := s.UploadAsset(ctx, bytes.NewReader(png))
+	require.NoError(t, err)
+	assert.False(t, created, "the same file is stored once")
+	assert.Equal(t, uploaded.Hash, again.Hash)
+
+	stored, content, err := s.OpenAsset(ctx, uploaded.Hash)
+	require.NoError(t, err)
+	defer content.Close()
+	got, err := io.ReadAll(content)
+	require.NoError(t, err)
+	assert.Equal(t, png, got)
+	assert.Equal(t, uploaded.Hash, stored.Hash)
+}
+
+func TestUploadAssetLimits(t *testing.T) {
+	s := newTestService(t, int64(len(png)))
+	ctx := context.Background()
+
+	_, _, err := s.UploadAsset(ctx, bytes.NewReader(append(png, 0)))
+	assert.ErrorIs(t, err, asset.ErrTooLarge)
+
+	_, _, err = s.UploadAsset(ctx, bytes.NewReader([]byte("<html><script>alert(1)</script></html>")))
+	assert.ErrorIs(t, err, asset.ErrUnsupportedType)
+
+	_, _, err = s.UploadAsset(ctx, bytes.NewReader(nil))
+	assert.ErrorIs(t, err, asset.ErrUnsupportedType)
+
+	_, _, err = s.OpenAsset(ctx, "../secret")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,35 @@
+package banner
+
+import (
+	"banner-service/internal/asset"
+	"banner-service/internal/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Assets looks up the uploaded files banner content refers to.
+type Assets interface {
+	GetMissingAssets(ctx context.Context, hashes []string) ([]string, error)
+}
+
+// checkAssets rejects content that refers to assets that were never uploaded
+// or have been collected. Without an asset store every reference is accepted.
+// The repository records the references in the same transaction as the
+// content, which keeps the collector from removing an asset checked here.
+func (s *Service) checkAssets(ctx context.Context, content json.RawMessage, localized map[string]json.RawMessage) error {
+	missing, err := s.missingAssets(ctx, asset.VersionReferences(content, localized))
+	if err != nil {
+		return err
+	} else if len(missing) > 0 {
+		return fmt.Errorf("%w: unknown assets %v", repository.ErrInvalidReference, missing)
+	}
+	return nil
+}
+
+func (s *Service) missingAssets(ctx context.Context, hashes []string) ([]string, error) {
+	if s.Assets == nil || len(hashes) == 0 {
+		return nil, nil
+	}
+	return s.Assets.GetMissingAssets(ctx, hashes)
+}
@@ -0,0 +1,49 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"encoding/json"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+type memoryAssets map[string]bool
+
+func (m memoryAssets) GetMissingAssets(_ context.Context, hashes []string) ([]string, error) {
+	return lo.Filter(hashes, func(hash string, _ int) bool { return !m[hash] }), nil
+}
+
+func TestCreateBannerAssets(t *testing.T) {
+	uploaded := strings.Repeat("a", 64)
+	missing := strings.Repeat("b", 64)
+	repo := &createRepository{}
+	s := NewService(Deps{BannerRepo: repo, Assets: memoryAssets{uploaded: true}, Locales: []string{"en"}})
+
+	_, err := s.CreateBanner(context.Background(), &models.Banner{
+		FeatureId: 1,
+		TagIds:    []uint64{1},
+		Content:   json.RawMessage(`{"image": "https://banners.example.com/assets/` + uploaded + `"}`),
+		Localized: map[string]json.RawMessage{"en": json.RawMessage(`{"image": "/assets/` + missing + `"}`)},
+	})
+	require.ErrorIs(t, err, repository.ErrInvalidReference)
+	assert.Contains(t, err.Error(), missing)
+	assert.Empty(t, repo.banners)
+
+	validation, err := s.ValidateContent(context.Background(), 1, json.RawMessage(`{"image": "/assets/`+missing+`"}`))
+	require.NoError(t, err)
+	assert.False(t, validation.Valid)
+	require.Len(t, validation.Violations, 1)
+
+	_, err = s.CreateBanner(context.Background(), &models.Banner{
+		FeatureId: 1,
+		TagIds:    []uint64{1},
+		Content:   json.RawMessage(`{"image": "/assets/` + uploaded + `"}`),
+	})
+	require.NoError(t, err)
+	assert.Len(t, repo.banners, 1)
+}
@@ -0,0 +1,72 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"fmt"
+	"github.com/samber/lo"
+)
+
+// BulkUpdate applies the operations and evicts the cached banners of every
+// feature and tag pair they changed. A retag to another feature fails the
+// banners whose content does not conform to the schema of that feature.
+func (s *Service) BulkUpdate(ctx context.Context, req *models.BulkRequest) (models.BulkReport, error) {
+	if err := validateBulkRequest(req); err != nil {
+		return models.BulkReport{}, err
+	}
+	for _, op := range req.Operations {
+		if op.Action != models.BulkRetag {
+			continue
+		}
+		if err := s.checkReferences(ctx, op.FeatureId, op.TagIds); err != nil {
+			return models.BulkReport{}, err
+		}
+	}
+
+	if s.Schemas != nil {
+		req.CheckContent = s.checkTxContent
+	}
+
+	report, affected, err := s.BannerRepo.BulkUpdate(ctx, req)
+	if err != nil {
+		return models.BulkReport{}, err
+	}
+
+	s.evict(affected)
+	return report, nil
+}
+
+func validateBulkRequest(req *models.BulkRequest) error {
+	if len(req.Operations) == 0 {
+		return fmt.Errorf("%w: no operations", repository.ErrInvalidBulk)
+	}
+
+	var ids int
+	for i, op := range req.Operations {
+		if !lo.Contains(models.BulkActions, op.Action) {
+			return fmt.Errorf("%w: operation %d: unknown action %q", repository.ErrInvalidBulk, i, op.Action)
+		}
+		if (len(op.BannerIds) == 0) == (op.Filter == nil) {
+			return fmt.Errorf("%w: operation %d: exactly one of banner_ids and filter is required", repository.ErrInvalidBulk, i)
+		}
+		if op.Action == models.BulkSetVersion && op.Version == 0 {
+			return fmt.Errorf("%w: operation %d: version is required", repository.ErrInvalidBulk, i)
+		}
+		if op.Action == models.BulkRetag {
+			if len(op.TagIds) == 0 || lo.Contains(op.TagIds, 0) {
+				return fmt.Errorf("%w: operation %d: tag_ids must be positive and not empty", repository.ErrInvalidBulk, i)
+			}
+			if op.FeatureId != nil && *op.FeatureId == 0 {
+				return fmt.Errorf("%w: operation %d: feature_id must be positive", repository.ErrInvalidBulk, i)
+			}
+		}
+
+		ids += len(op.BannerIds)
+	}
+	if ids > repository.MaxBulkItems {
+		return fmt.Errorf("%w: more than %d banners selected", repository.ErrInvalidBulk, repository.MaxBulkItems)
+	}
+
+	return nil
+}
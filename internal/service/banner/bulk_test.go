@@ -0,0 +1,78 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// bulkRepository reports every banner of the request as applied and the pairs
+// it was given as affected.
+type bulkRepository struct {
+	Repository
+	affected []models.FeatureTag
+	calls    int
+}
+
+func (r *bulkRepository) BulkUpdate(_ context.Context, req *models.BulkRequest) (models.BulkReport, []models.FeatureTag, error) {
+	r.calls++
+	report := models.BulkReport{Atomic: req.Atomic, Committed: true}
+	for i, op := range req.Operations {
+		for _, bannerId := range op.BannerIds {
+			report.Items = append(report.Items, models.BulkItemResult{Operation: i, BannerId: bannerId, Status: models.BulkApplied})
+			report.Applied++
+		}
+	}
+	return report, r.affected, nil
+}
+
+func TestBulkUpdate(t *testing.T) {
+	key := models.BannerCacheKey{FeatureId: 1, TagId: 2, Locale: "en"}
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	cache.Set(key, models.BannerContent{Content: "{}"}, ttlcache.DefaultTTL)
+	repo := &bulkRepository{affected: []models.FeatureTag{{FeatureId: 1, TagId: 2}}}
+	s := NewService(Deps{BannerRepo: repo, Cache: cache, Locales: []string{"ru", "en"}})
+
+	report, err := s.BulkUpdate(context.Background(), &models.BulkRequest{Operations: []models.BulkOperation{
+		{Action: models.BulkDeactivate, BannerIds: []uint64{1, 2}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), report.Applied)
+	assert.False(t, cache.Has(key), "changed keys are evicted")
+}
+
+func TestBulkUpdateValidation(t *testing.T) {
+	zero := uint64(0)
+	tests := []struct {
+		name string
+		op   models.BulkOperation
+	}{
+		{name: "unknown action", op: models.BulkOperation{Action: "archive", BannerIds: []uint64{1}}},
+		{name: "no selection", op: models.BulkOperation{Action: models.BulkActivate}},
+		{
+			name: "ids and filter",
+			op:   models.BulkOperation{Action: models.BulkActivate, BannerIds: []uint64{1}, Filter: &models.FilterBanner{}},
+		},
+		{name: "no version", op: models.BulkOperation{Action: models.BulkSetVersion, BannerIds: []uint64{1}}},
+		{name: "no tags", op: models.BulkOperation{Action: models.BulkRetag, BannerIds: []uint64{1}}},
+		{
+			name: "zero feature",
+			op:   models.BulkOperation{Action: models.BulkRetag, BannerIds: []uint64{1}, TagIds: []uint64{1}, FeatureId: &zero},
+		},
+		{name: "too many banners", op: models.BulkOperation{Action: models.BulkDelete, BannerIds: make([]uint64, repository.MaxBulkItems+1)}},
+	}
+
+	repo := &bulkRepository{}
+	s := NewService(Deps{BannerRepo: repo})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.BulkUpdate(context.Background(), &models.BulkRequest{Operations: []models.BulkOperation{tt.op}})
+			assert.ErrorIs(t, err, repository.ErrInvalidBulk)
+		})
+	}
+	assert.Zero(t, repo.calls)
+}
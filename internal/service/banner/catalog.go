@@ -0,0 +1,102 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"fmt"
+	"github.com/samber/lo"
+)
+
+// Catalog looks up the features and tags banners refer to.
+type Catalog interface {
+	GetFeatures(ctx context.Context, filter *models.CatalogFilter) ([]models.Feature, error)
+	GetTags(ctx context.Context, filter *models.CatalogFilter) ([]models.Tag, error)
+}
+
+// checkReferences rejects archived features and tags, and in strict mode the
+// ones that are not in the catalog. Without a catalog every id is accepted.
+func (s *Service) checkReferences(ctx context.Context, featureId *uint64, tagIds []uint64) error {
+	if s.Catalog == nil {
+		return nil
+	}
+
+	if featureId != nil {
+		features, err := s.Catalog.GetFeatures(ctx, &models.CatalogFilter{Ids: []uint64{*featureId}})
+		if err != nil {
+			return err
+		}
+		if len(features) == 0 && s.StrictReferences {
+			return fmt.Errorf("%w: feature %d is not registered", repository.ErrInvalidReference, *featureId)
+		} else if len(features) > 0 && features[0].Archived {
+			return fmt.Errorf("%w: feature %d is archived", repository.ErrInvalidReference, *featureId)
+		}
+	}
+
+	if len(tagIds) > 0 {
+		tags, err := s.Catalog.GetTags(ctx, &models.CatalogFilter{Ids: lo.Uniq(tagIds)})
+		if err != nil {
+			return err
+		}
+		known := lo.KeyBy(tags, func(tag models.Tag) uint64 { return tag.TagId })
+		for _, tagId := range tagIds {
+			tag, ok := known[tagId]
+			if !ok && s.StrictReferences {
+				return fmt.Errorf("%w: tag %d is not registered", repository.ErrInvalidReference, tagId)
+			} else if ok && tag.Archived {
+				return fmt.Errorf("%w: tag %d is archived", repository.ErrInvalidReference, tagId)
+			}
+		}
+	}
+
+	return nil
+}
+
+// expandBanners sets the feature and tag names requested by expand. Ids that
+// are not in the catalog are left out.
+func (s *Service) expandBanners(ctx context.Context, banners []models.Banner, expand []models.BannerExpansion) error {
+	if s.Catalog == nil || len(banners) == 0 {
+		return nil
+	}
+
+	if lo.Contains(expand, models.ExpandFeature) {
+		featureIds := lo.Uniq(lo.Map(banners, func(banner models.Banner, _ int) uint64 { return banner.FeatureId }))
+		features, err := s.Catalog.GetFeatures(ctx, &models.CatalogFilter{Ids: featureIds})
+		if err != nil {
+			return err
+		}
+		refs := lo.SliceToMap(features, func(feature models.Feature) (uint64, models.CatalogRef) {
+			return feature.FeatureId, catalogRef(feature.FeatureId, feature.CatalogEntry)
+		})
+		for i := range banners {
+			if ref, ok := refs[banners[i].FeatureId]; ok {
+				banners[i].Feature = &ref
+			}
+		}
+	}
+
+	if lo.Contains(expand, models.ExpandTags) {
+		tagIds := lo.Uniq(lo.FlatMap(banners, func(banner models.Banner, _ int) []uint64 { return banner.TagIds }))
+		tags, err := s.Catalog.GetTags(ctx, &models.CatalogFilter{Ids: tagIds})
+		if err != nil {
+			return err
+		}
+		refs := lo.SliceToMap(tags, func(tag models.Tag) (uint64, models.CatalogRef) {
+			return tag.TagId, catalogRef(tag.TagId, tag.CatalogEntry)
+		})
+		for i := range banners {
+			banners[i].Tags = make([]models.CatalogRef, 0, len(banners[i].TagIds))
+			for _, tagId := range banners[i].TagIds {
+				if ref, ok := refs[tagId]; ok {
+					banners[i].Tags = append(banners[i].Tags, ref)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func catalogRef(id uint64, entry models.CatalogEntry) models.CatalogRef {
+	return models.CatalogRef{Id: id, Name: entry.Name, Archived: entry.Archived}
+}
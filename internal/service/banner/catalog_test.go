@@ -0,0 +1,144 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// memoryCatalog serves the features and tags it holds by id.
+type memoryCatalog struct {
+	features []models.Feature
+	tags     []models.Tag
+}
+
+func (c *memoryCatalog) GetFeatures(_ context.Context, filter *models.CatalogFilter) ([]models.Feature, error) {
+	return lo.Filter(c.features, func(feature models.Feature, _ int) bool {
+		return lo.Contains(filter.Ids, feature.FeatureId)
+	}), nil
+}
+
+func (c *memoryCatalog) GetTags(_ context.Context, filter *models.CatalogFilter) ([]models.Tag, error) {
+	return lo.Filter(c.tags, func(tag models.Tag, _ int) bool {
+		return lo.Contains(filter.Ids, tag.TagId)
+	}), nil
+}
+
+// createRepository accepts every banner.
+type createRepository struct {
+	Repository
+	banners  []models.Banner
+	filtered []models.Banner
+}
+
+func (r *createRepository) CreateBanner(_ context.Context, banner *models.Banner, _ models.SchemaVersions) (uint64, error) {
+	r.banners = append(r.banners, *banner)
+	return uint64(len(r.banners)), nil
+}
+
+func (r *createRepository) GetFilteredBanners(_ context.Context, _ *models.FilterBanner) (models.BannerPage, error) {
+	return models.BannerPage{Banners: r.filtered}, nil
+}
+
+func newCatalog() *memoryCatalog {
+	return &memoryCatalog{
+		features: []models.Feature{
+			{FeatureId: 1, CatalogEntry: models.CatalogEntry{Name: "onboarding"}},
+			{FeatureId: 2, CatalogEntry: models.CatalogEntry{Name: "legacy", Archived: true}},
+		},
+		tags: []models.Tag{
+			{TagId: 1, CatalogEntry: models.CatalogEntry{Name: "new users"}},
+			{TagId: 2, CatalogEntry: models.CatalogEntry{Name: "old users", Archived: true}},
+		},
+	}
+}
+
+func TestCreateBannerReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		strict    bool
+		featureId uint64
+		tagIds    []uint64
+		valid     bool
+	}{
+		{name: "registered", featureId: 1, tagIds: []uint64{1}, valid: true},
+		{name: "unknown ids without strict mode", featureId: 3, tagIds: []uint64{1, 3}, valid: true},
+		{name: "unknown feature in strict mode", strict: true, featureId: 3, tagIds: []uint64{1}},
+		{name: "unknown tag in strict mode", strict: true, featureId: 1, tagIds: []uint64{1, 3}},
+		{name: "archived feature", featureId: 2, tagIds: []uint64{1}},
+		{name: "archived tag", featureId: 1, tagIds: []uint64{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &createRepository{}
+			s := NewService(Deps{BannerRepo: repo, Catalog: newCatalog(), StrictReferences: tt.strict})
+
+			_, err := s.CreateBanner(context.Background(), &models.Banner{FeatureId: tt.featureId, TagIds: tt.tagIds})
+			if tt.valid {
+				require.NoError(t, err)
+				assert.Len(t, repo.banners, 1)
+			} else {
+				assert.ErrorIs(t, err, repository.ErrInvalidReference)
+				assert.Empty(t, repo.banners)
+			}
+		})
+	}
+}
+
+func TestGetFilteredBannersExpand(t *testing.T) {
+	repo := &createRepository{filtered: []models.Banner{
+		{BannerId: 1, FeatureId: 1, TagIds: []uint64{1, 3}},
+		{BannerId: 2, FeatureId: 3, TagIds: []uint64{2}},
+	}}
+	s := NewService(Deps{BannerRepo: repo, Catalog: newCatalog()})
+
+	page, err := s.GetFilteredBanners(context.Background(), &models.FilterBanner{
+		Expand: []models.BannerExpansion{models.ExpandFeature, models.ExpandTags},
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Banners, 2)
+
+	assert.Equal(t, &models.CatalogRef{Id: 1, Name: "onboarding"}, page.Banners[0].Feature)
+	assert.Equal(t, []models.CatalogRef{{Id: 1, Name: "new users"}}, page.Banners[0].Tags, "unknown tags are left out")
+	assert.Nil(t, page.Banners[1].Feature)
+	assert.Equal(t, []models.CatalogRef{{Id: 2, Name: "old users", Archived: true}}, page.Banners[1].Tags)
+}
+
+func TestGetBannerFallbackIsCached(t *testing.T) {
+	repo := &resolveRepository{banners: map[uint64]models.BannerContent{
+		2: {Content: `{"title": "parent"}`, IsActive: true, TagId: 1, BannerId: 1, Depth: 1},
+	}}
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	s := NewService(Deps{BannerRepo: repo, Cache: cache})
+
+	for i := 0; i < 2; i++ {
+		content, err := s.GetBanner(context.Background(), []uint64{2}, 1, models.Client, false, models.TemplateVars{}, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), content.TagId)
+	}
+
+	assert.Equal(t, 1, repo.calls)
+	assert.True(t, cache.Has(models.BannerCacheKey{FeatureId: 1, TagId: 2}), "the fallback is cached under the requested tag")
+}
+
+func TestEvictDropsFallbacksOfFeature(t *testing.T) {
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	s := NewService(Deps{Cache: cache, Locales: []string{"en"}})
+
+	// The banner of tag 1 is cached as the fallback of its child tag 2.
+	fallback := models.BannerCacheKey{FeatureId: 1, TagId: 2, Locale: "en"}
+	other := models.BannerCacheKey{FeatureId: 2, TagId: 2}
+	cache.Set(fallback, models.BannerContent{TagId: 1}, ttlcache.DefaultTTL)
+	cache.Set(other, models.BannerContent{TagId: 2}, ttlcache.DefaultTTL)
+
+	s.evict([]models.FeatureTag{{FeatureId: 1, TagId: 1}})
+
+	assert.False(t, cache.Has(fallback), "the fallback is evicted with the banner it falls back to")
+	assert.True(t, cache.Has(other), "other features are kept")
+}
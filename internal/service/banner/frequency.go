@@ -0,0 +1,36 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"fmt"
+)
+
+// userClaim is the claim of the token that identifies the user a frequency cap
+// counts serves for.
+const userClaim = "sub"
+
+// checkFrequencyCap rejects a cap with a limit but no window. A cap with a
+// zero limit means no cap.
+func checkFrequencyCap(frequencyCap *models.FrequencyCap) error {
+	if frequencyCap != nil && frequencyCap.Limit > 0 && frequencyCap.WindowSeconds == 0 {
+		return fmt.Errorf("%w: window_seconds must be positive", repository.ErrInvalidFrequencyCap)
+	}
+	return nil
+}
+
+// takeServe counts a serve of the banner to the user and reports whether the
+// banner may be served. Banners without a cap and admins are not counted. A
+// capped banner is never served to a request without a user, whose serves
+// cannot be counted.
+func (s *Service) takeServe(ctx context.Context, content models.BannerContent, user string, role models.UserRole) (bool, error) {
+	frequencyCap := content.FrequencyCap
+	if s.Caps == nil || frequencyCap == nil || frequencyCap.Limit == 0 || role == models.Admin {
+		return true, nil
+	} else if user == "" {
+		return false, nil
+	}
+	key := fmt.Sprintf("%d/%s", content.BannerId, user)
+	return s.Caps.Take(ctx, key, frequencyCap.Limit, frequencyCap.Window())
+}
@@ -0,0 +1,36 @@
+package banner
+
+import (
+	"banner-service/internal/locale"
+	"banner-service/internal/repository"
+	"encoding/json"
+	"fmt"
+	"github.com/samber/lo"
+)
+
+// checkLocales requires localized content and the default locale to use the
+// supported locales, and normalizes both the keys of localized and the default
+// locale, so "EN" is stored and served as "en".
+func (s *Service) checkLocales(localized map[string]json.RawMessage, defaultLocale *string) error {
+	for _, name := range sortedLocales(localized) {
+		normalized := locale.Normalize(name)
+		if !lo.Contains(s.Locales, normalized) {
+			return fmt.Errorf("%w: %q is not one of the supported locales %v", repository.ErrInvalidLocale, name, s.Locales)
+		}
+		if normalized == name {
+			continue
+		}
+		if _, ok := localized[normalized]; ok {
+			return fmt.Errorf("%w: %q is given more than once", repository.ErrInvalidLocale, normalized)
+		}
+		localized[normalized] = localized[name]
+		delete(localized, name)
+	}
+	if defaultLocale != nil && *defaultLocale != "" {
+		*defaultLocale = locale.Normalize(*defaultLocale)
+		if !lo.Contains(s.Locales, *defaultLocale) {
+			return fmt.Errorf("%w: %q is not one of the supported locales %v", repository.ErrInvalidLocale, *defaultLocale, s.Locales)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package banner
+
+import (
+	"banner-service/internal/asset"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"banner-service/internal/schema"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"sort"
+)
+
+// Schemas looks up the JSON Schema versions of features.
+type Schemas interface {
+	GetFeatureSchema(ctx context.Context, featureId, version uint64) (models.FeatureSchema, error)
+}
+
+type schemaKey struct {
+	featureId, version uint64
+}
+
+// featureSchema returns the given schema version of the feature, the latest
+// one if version is zero, compiled. The compiled schema is nil when the
+// feature has no schema or the version turns validation off. Versions never
+// change, so compiled ones are kept for the lifetime of the service.
+func (s *Service) featureSchema(ctx context.Context, featureId, version uint64) (models.FeatureSchema, *schema.Schema, error) {
+	if s.Schemas == nil {
+		return models.FeatureSchema{}, nil, nil
+	}
+
+	stored, err := s.Schemas.GetFeatureSchema(ctx, featureId, version)
+	if errors.Is(err, repository.ErrNotFound) && version == 0 {
+		return models.FeatureSchema{}, nil, nil
+	} else if err != nil {
+		return models.FeatureSchema{}, nil, err
+	}
+
+	compiled, err := s.compileSchema(stored)
+	if err != nil {
+		return models.FeatureSchema{}, nil, err
+	}
+	return stored, compiled, nil
+}
+
+// compileSchema compiles a schema version, or returns nil for a version
+// without a schema.
+func (s *Service) compileSchema(stored models.FeatureSchema) (*schema.Schema, error) {
+	if stored.Schema == nil {
+		return nil, nil
+	}
+
+	key := schemaKey{featureId: stored.FeatureId, version: stored.Version}
+	if compiled, ok := s.compiledSchemas.Load(key); ok {
+		return compiled.(*schema.Schema), nil
+	}
+	compiled, err := schema.Compile(stored.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("schema version %d of feature %d: %w", stored.Version, stored.FeatureId, err)
+	}
+	s.compiledSchemas.Store(key, compiled)
+	return compiled, nil
+}
+
+// checkLocalizedContent checks the default and every localized content, and
+// the assets they refer to.
+func (s *Service) checkLocalizedContent(ctx context.Context, featureId uint64, content json.RawMessage, localized map[string]json.RawMessage,
+	versions models.SchemaVersions) error {
+	if err := s.checkSchemaContent(ctx, featureId, content, localized, versions); err != nil {
+		return err
+	}
+	return s.checkAssets(ctx, content, localized)
+}
+
+// checkSchemaContent checks the default and every localized content against
+// the schema of the feature in force. The version checked against is added to
+// versions for the repository; the first one added for the feature is kept,
+// so content checked against different versions conflicts.
+func (s *Service) checkSchemaContent(ctx context.Context, featureId uint64, content json.RawMessage, localized map[string]json.RawMessage,
+	versions models.SchemaVersions) error {
+	stored, compiled, err := s.featureSchema(ctx, featureId, 0)
+	if err != nil {
+		return err
+	}
+	if s.Schemas != nil {
+		if _, ok := versions[featureId]; !ok {
+			versions[featureId] = stored.Version
+		}
+	}
+	return validateContent(featureId, stored.Version, compiled, content, localized)
+}
+
+// checkTxContent checks the default and every localized content against a
+// schema the repository read in its transaction.
+func (s *Service) checkTxContent(featureSchema models.FeatureSchema, content json.RawMessage, localized map[string]json.RawMessage) error {
+	compiled, err := s.compileSchema(featureSchema)
+	if err != nil {
+		return err
+	}
+	return validateContent(featureSchema.FeatureId, featureSchema.Version, compiled, content, localized)
+}
+
+// validateContent rejects the default or a localized content that does not
+// conform to compiled, if set.
+func validateContent(featureId, version uint64, compiled *schema.Schema, content json.RawMessage, localized map[string]json.RawMessage) error {
+	if compiled == nil {
+		return nil
+	}
+
+	if violations := compiled.Validate(content); len(violations) > 0 {
+		return &schema.ViolationError{FeatureId: featureId, SchemaVersion: version, Violations: violations}
+	}
+	for _, locale := range sortedLocales(localized) {
+		if violations := compiled.Validate(localized[locale]); len(violations) > 0 {
+			return &schema.ViolationError{FeatureId: featureId, SchemaVersion: version, Locale: locale, Violations: violations}
+		}
+	}
+	return nil
+}
+
+// ValidateContent checks content against the schema of the feature in force,
+// and the assets it refers to, without saving anything.
+func (s *Service) ValidateContent(ctx context.Context, featureId uint64, content json.RawMessage) (models.ContentValidation, error) {
+	stored, compiled, err := s.featureSchema(ctx, featureId, 0)
+	if err != nil {
+		return models.ContentValidation{}, err
+	}
+
+	validation := models.ContentValidation{Valid: true, Violations: make([]models.SchemaViolation, 0)}
+	if compiled != nil {
+		validation.SchemaVersion = stored.Version
+		validation.Violations = append(validation.Violations, compiled.Validate(content)...)
+	}
+
+	missing, err := s.missingAssets(ctx, asset.References(content))
+	if err != nil {
+		return models.ContentValidation{}, err
+	}
+	for _, hash := range missing {
+		validation.Violations = append(validation.Violations, models.SchemaViolation{Message: fmt.Sprintf("asset %s does not exist", hash)})
+	}
+
+	validation.Valid = len(validation.Violations) == 0
+	return validation, nil
+}
+
+// GetSchemaReport checks the active content of every banner of the feature
+// against a schema version, the latest one if version is zero.
+func (s *Service) GetSchemaReport(ctx context.Context, featureId, version uint64) (models.SchemaReport, error) {
+	stored, compiled, err := s.featureSchema(ctx, featureId, version)
+	if err != nil {
+		return models.SchemaReport{}, err
+	} else if compiled == nil {
+		return models.SchemaReport{}, fmt.Errorf("%w: feature %d has no schema in force", repository.ErrNotFound, featureId)
+	}
+
+	report := models.SchemaReport{
+		FeatureId:     featureId,
+		SchemaVersion: stored.Version,
+		Banners:       make([]models.NonConformingBanner, 0),
+	}
+	filter := models.FilterBanner{FeatureIds: []uint64{featureId}, Limit: 1000}
+	for {
+		page, err := s.BannerRepo.GetFilteredBanners(ctx, &filter)
+		if err != nil {
+			return models.SchemaReport{}, err
+		}
+
+		for _, banner := range page.Banners {
+			report.Checked++
+			if violations := compiled.Validate(banner.Content); len(violations) > 0 {
+				report.Banners = append(report.Banners, models.NonConformingBanner{
+					BannerId:   banner.BannerId,
+					Version:    banner.Version,
+					Violations: violations,
+				})
+			}
+			for _, locale := range sortedLocales(banner.Localized) {
+				if violations := compiled.Validate(banner.Localized[locale]); len(violations) > 0 {
+					report.Banners = append(report.Banners, models.NonConformingBanner{
+						BannerId:   banner.BannerId,
+						Version:    banner.Version,
+						Locale:     locale,
+						Violations: violations,
+					})
+				}
+			}
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	return report, nil
+}
+
+func sortedLocales(localized map[string]json.RawMessage) []string {
+	locales := lo.Keys(localized)
+	sort.Strings(locales)
+	return locales
+}
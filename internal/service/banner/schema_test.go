@@ -0,0 +1,293 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	contentpatch "banner-service/internal/patch"
+	"banner-service/internal/repository"
+	"banner-service/internal/schema"
+	"context"
+	"encoding/json"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// memorySchemas holds the schema versions of features, oldest first.
+type memorySchemas map[uint64][]models.FeatureSchema
+
+func (m memorySchemas) GetFeatureSchema(_ context.Context, featureId, version uint64) (models.FeatureSchema, error) {
+	versions := m[featureId]
+	if len(versions) == 0 {
+		return models.FeatureSchema{}, repository.ErrNotFound
+	} else if version == 0 {
+		return versions[len(versions)-1], nil
+	}
+	for _, featureSchema := range versions {
+		if featureSchema.Version == version {
+			return featureSchema, nil
+		}
+	}
+	return models.FeatureSchema{}, repository.ErrNotFound
+}
+
+// patchRepository holds a single banner and patches it the way the repository
+// does: the banner the patch leaves is checked against the latest schema
+// version of its feature.
+type patchRepository struct {
+	createRepository
+	schemas memorySchemas
+	current models.Banner
+	patched bool
+}
+
+func (r *patchRepository) PartialUpdateBanner(ctx context.Context, _ uint64, patch *models.PatchBanner, check models.ContentCheck) ([]models.FeatureTag, error) {
+	after := r.current
+	if patch.FeatureId != nil && patch.TagIds != nil {
+		after.FeatureId = *patch.FeatureId
+	}
+	if patch.Content != nil {
+		after.Content = patch.Content
+	}
+	if patch.ContentPatch != nil {
+		content, err := contentpatch.Apply(patch.ContentPatch.MediaType, r.current.Content, patch.ContentPatch.Document)
+		if err != nil {
+			return nil, err
+		}
+		after.Content = content
+	}
+	if patch.Localized != nil {
+		after.Localized = patch.Localized
+	}
+	if check != nil {
+		featureSchema, _ := r.schemas.GetFeatureSchema(ctx, after.FeatureId, 0)
+		if err := check(featureSchema, after.Content, after.Localized); err != nil {
+			return nil, err
+		}
+	}
+
+	r.current = after
+	r.patched = true
+	return nil, nil
+}
+
+const titleSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["title"],
+	"properties": {
+		"title": {"type": "string", "minLength": 1},
+		"url": {"type": "string", "format": "uri"}
+	}
+}`
+
+// versionRepository records the schema versions the service checked the
+// content it is given against.
+type versionRepository struct {
+	createRepository
+	versions models.SchemaVersions
+}
+
+func (r *versionRepository) CreateBanner(ctx context.Context, banner *models.Banner, schemaVersions models.SchemaVersions) (uint64, error) {
+	r.versions = schemaVersions
+	return r.createRepository.CreateBanner(ctx, banner, schemaVersions)
+}
+
+// retagRepository retags a banner with the given content, checked against the
+// latest schema version of the feature, as read in the transaction.
+type retagRepository struct {
+	Repository
+	schemas memorySchemas
+	content json.RawMessage
+	err     error
+}
+
+func (r *retagRepository) BulkUpdate(ctx context.Context, req *models.BulkRequest) (models.BulkReport, []models.FeatureTag, error) {
+	op := req.Operations[0]
+	if req.CheckContent != nil {
+		featureSchema, _ := r.schemas.GetFeatureSchema(ctx, *op.FeatureId, 0)
+		r.err = req.CheckContent(featureSchema, r.content, nil)
+	}
+	return models.BulkReport{Committed: true}, nil, nil
+}
+
+func newSchemas() memorySchemas {
+	return memorySchemas{
+		1: {
+			{FeatureId: 1, Version: 1, Schema: json.RawMessage(`{"type": "object"}`)},
+			{FeatureId: 1, Version: 2, Schema: json.RawMessage(titleSchema)},
+		},
+		2: {
+			{FeatureId: 2, Version: 1, Schema: json.RawMessage(titleSchema)},
+			{FeatureId: 2, Version: 2},
+		},
+	}
+}
+
+func TestCreateBannerSchema(t *testing.T) {
+	tests := []struct {
+		name       string
+		featureId  uint64
+		content    string
+		violations []models.SchemaViolation
+	}{
+		{name: "conforming", featureId: 1, content: `{"title": "sale", "url": "https://example.com"}`},
+		{
+			name:      "violations of the latest version",
+			featureId: 1,
+			content:   `{"title": "", "url": "not a uri"}`,
+			violations: []models.SchemaViolation{
+				{Path: "/title", Message: "length must be >= 1, but got 0"},
+				{Path: "/url", Message: "'not a uri' is not valid 'uri'"},
+			},
+		},
+		{name: "feature without schema", featureId: 3, content: `{}`},
+		{name: "schema removed", featureId: 2, content: `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &createRepository{}
+			s := NewService(Deps{BannerRepo: repo, Schemas: newSchemas()})
+
+			_, err := s.CreateBanner(context.Background(), &models.Banner{
+				FeatureId: tt.featureId,
+				TagIds:    []uint64{1},
+				Content:   json.RawMessage(tt.content),
+			})
+
+			if tt.violations == nil {
+				require.NoError(t, err)
+				assert.Len(t, repo.banners, 1)
+				return
+			}
+			var violationErr *schema.ViolationError
+			require.ErrorAs(t, err, &violationErr)
+			assert.Equal(t, uint64(2), violationErr.SchemaVersion)
+			assert.Equal(t, tt.violations, violationErr.Violations)
+			assert.Empty(t, repo.banners)
+		})
+	}
+}
+
+func TestPartialUpdateBannerSchema(t *testing.T) {
+	repo := &patchRepository{schemas: newSchemas(), current: models.Banner{BannerId: 1, FeatureId: 3, Content: json.RawMessage(`{"text": "no title"}`)}}
+	s := NewService(Deps{BannerRepo: repo, Schemas: repo.schemas})
+
+	// Moving the banner to a feature with a schema checks the content it keeps.
+	err := s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{FeatureId: lo.ToPtr(uint64(1)), TagIds: []uint64{1}})
+	var violationErr *schema.ViolationError
+	require.ErrorAs(t, err, &violationErr)
+	assert.False(t, repo.patched)
+
+	err = s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{
+		FeatureId: lo.ToPtr(uint64(1)),
+		TagIds:    []uint64{1},
+		Content:   json.RawMessage(`{"title": "sale"}`),
+	})
+	require.NoError(t, err)
+	assert.True(t, repo.patched)
+}
+
+func TestPartialUpdateBannerContentPatch(t *testing.T) {
+	repo := &patchRepository{schemas: newSchemas(), current: models.Banner{BannerId: 1, FeatureId: 1, Content: json.RawMessage(`{"title": "sale"}`)}}
+	s := NewService(Deps{BannerRepo: repo, Schemas: repo.schemas})
+
+	// The patched content, not the patch document, is checked.
+	err := s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{ContentPatch: &models.ContentPatch{
+		MediaType: contentpatch.MergePatch,
+		Document:  json.RawMessage(`{"title": null, "text": "no title"}`),
+	}})
+	var violationErr *schema.ViolationError
+	require.ErrorAs(t, err, &violationErr)
+	assert.False(t, repo.patched)
+
+	err = s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{ContentPatch: &models.ContentPatch{
+		MediaType: contentpatch.JSONPatch,
+		Document:  json.RawMessage(`[{"op": "test", "path": "/title", "value": "old"}]`),
+	}})
+	assert.ErrorIs(t, err, contentpatch.ErrTestFailed)
+	assert.False(t, repo.patched)
+
+	err = s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{ContentPatch: &models.ContentPatch{
+		MediaType: contentpatch.JSONPatch,
+		Document:  json.RawMessage(`[{"op": "test", "path": "/title", "value": "sale"}, {"op": "add", "path": "/url", "value": "https://example.com"}]`),
+	}})
+	require.NoError(t, err)
+	assert.True(t, repo.patched)
+}
+
+func TestPartialUpdateBannerChecksPatchedBanner(t *testing.T) {
+	repo := &patchRepository{schemas: newSchemas(), current: models.Banner{BannerId: 1, FeatureId: 1, Content: json.RawMessage(`{"title": "sale"}`)}}
+	s := NewService(Deps{BannerRepo: repo, Schemas: repo.schemas})
+
+	// A concurrent update leaves content the patch does not fix; the result of
+	// applying the patch to it is what is checked.
+	repo.current.Content = json.RawMessage(`{"text": "no title"}`)
+	err := s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{ContentPatch: &models.ContentPatch{
+		MediaType: contentpatch.MergePatch,
+		Document:  json.RawMessage(`{"url": "https://example.com"}`),
+	}})
+	var violationErr *schema.ViolationError
+	require.ErrorAs(t, err, &violationErr)
+	assert.False(t, repo.patched)
+}
+
+func TestGetSchemaReport(t *testing.T) {
+	repo := &createRepository{filtered: []models.Banner{
+		{BannerId: 1, FeatureId: 1, Version: 3, Content: json.RawMessage(`{"title": "sale"}`)},
+		{BannerId: 2, FeatureId: 1, Version: 1, Content: json.RawMessage(`{"text": "no title"}`)},
+	}}
+	s := NewService(Deps{BannerRepo: repo, Schemas: newSchemas()})
+
+	report, err := s.GetSchemaReport(context.Background(), 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), report.SchemaVersion)
+	assert.Equal(t, uint64(2), report.Checked)
+	require.Len(t, report.Banners, 1)
+	assert.Equal(t, uint64(2), report.Banners[0].BannerId)
+
+	report, err = s.GetSchemaReport(context.Background(), 1, 1)
+	require.NoError(t, err)
+	assert.Empty(t, report.Banners)
+
+	_, err = s.GetSchemaReport(context.Background(), 2, 0)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestCreateBannerCollectsSchemaVersions(t *testing.T) {
+	repo := &versionRepository{}
+	s := NewService(Deps{BannerRepo: repo, Schemas: newSchemas()})
+
+	_, err := s.CreateBanner(context.Background(), &models.Banner{
+		FeatureId: 1,
+		TagIds:    []uint64{1},
+		Content:   json.RawMessage(`{"title": "sale"}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersions{1: 2}, repo.versions, "the repository rejects the banner if version 2 is replaced")
+
+	_, err = s.CreateBanner(context.Background(), &models.Banner{FeatureId: 3, TagIds: []uint64{1}, Content: json.RawMessage(`{}`)})
+	require.NoError(t, err)
+	assert.Equal(t, models.SchemaVersions{3: 0}, repo.versions, "a feature without a schema must stay without one")
+}
+
+func TestBulkRetagChecksContent(t *testing.T) {
+	repo := &retagRepository{schemas: newSchemas(), content: json.RawMessage(`{"text": "no title"}`)}
+	s := NewService(Deps{BannerRepo: repo, Schemas: newSchemas()})
+
+	_, err := s.BulkUpdate(context.Background(), &models.BulkRequest{Operations: []models.BulkOperation{
+		{Action: models.BulkRetag, BannerIds: []uint64{1}, TagIds: []uint64{1}, FeatureId: lo.ToPtr(uint64(1))},
+	}})
+	require.NoError(t, err)
+	var violationErr *schema.ViolationError
+	require.ErrorAs(t, repo.err, &violationErr)
+	assert.Equal(t, uint64(1), violationErr.FeatureId)
+
+	repo.content = json.RawMessage(`{"title": "sale"}`)
+	_, err = s.BulkUpdate(context.Background(), &models.BulkRequest{Operations: []models.BulkOperation{
+		{Action: models.BulkRetag, BannerIds: []uint64{1}, TagIds: []uint64{1}, FeatureId: lo.ToPtr(uint64(1))},
+	}})
+	require.NoError(t, err)
+	assert.NoError(t, repo.err)
+}
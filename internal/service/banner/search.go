@@ -0,0 +1,253 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// highlightTags turns the escaped highlight sentinels into markup.
+var highlightTags = strings.NewReplacer(models.HighlightStart, "<mark>", models.HighlightStop, "</mark>")
+
+// Searcher is implemented by repositories with native full-text search.
+// Repositories without it are searched by scanning their banners.
+type Searcher interface {
+	SearchBanners(ctx context.Context, search *models.BannerSearch) ([]models.BannerSearchResult, error)
+}
+
+func (s *Service) SearchBanners(ctx context.Context, search *models.BannerSearch) ([]models.BannerSearchResult, error) {
+	if search.Limit == 0 {
+		search.Limit = defaultSearchLimit
+	} else if search.Limit > maxSearchLimit {
+		search.Limit = maxSearchLimit
+	}
+
+	var (
+		results []models.BannerSearchResult
+		err     error
+	)
+	if searcher, ok := s.BannerRepo.(Searcher); ok {
+		results, err = searcher.SearchBanners(ctx, search)
+	} else {
+		results, err = scanSearch(ctx, s.BannerRepo, search)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		if results[i].Highlight, err = renderHighlight(results[i].Highlight); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// renderHighlight escapes the string values of a highlight as HTML, so that
+// markup in the content is shown as text, and then marks the matches.
+func renderHighlight(highlight json.RawMessage) (json.RawMessage, error) {
+	var content any
+	dec := json.NewDecoder(bytes.NewReader(highlight))
+	dec.UseNumber()
+	if err := dec.Decode(&content); err != nil {
+		return nil, err
+	}
+
+	rendered := mapStrings(content, func(s string) string {
+		return highlightTags.Replace(html.EscapeString(s))
+	})
+	return encodeContent(rendered)
+}
+
+func encodeContent(content any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(content); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// scanSearch is the fallback search: it reads every banner (and every version
+// when history is requested) and requires all words of the query to occur in
+// the string values of the content, localized content included.
+func scanSearch(ctx context.Context, repo Repository, search *models.BannerSearch) ([]models.BannerSearchResult, error) {
+	terms := searchTerms(search.Query)
+	results := make([]models.BannerSearchResult, 0)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	filter := models.FilterBanner{Limit: 1000}
+	for {
+		page, err := repo.GetFilteredBanners(ctx, &filter)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, banner := range page.Banners {
+			versions := []models.Banner{banner}
+			if search.WithHistory {
+				if versions, err = repo.GetListOfVersions(ctx, banner.BannerId); err != nil {
+					return nil, err
+				}
+			}
+
+			for _, version := range versions {
+				result, ok := matchBanner(version, terms)
+				if !ok {
+					continue
+				}
+				result.IsCurrent = version.Version == banner.Version
+				results = append(results, result)
+			}
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if results[i].BannerId != results[j].BannerId {
+			return results[i].BannerId > results[j].BannerId
+		}
+		return results[i].Version > results[j].Version
+	})
+
+	if search.Offset >= uint64(len(results)) {
+		return results[:0], nil
+	}
+	results = results[search.Offset:]
+	if uint64(len(results)) > search.Limit {
+		results = results[:search.Limit]
+	}
+	return results, nil
+}
+
+// matchBanner ranks the banner by the share of its words that match the query
+// and highlights the matches of the default content.
+func matchBanner(banner models.Banner, terms map[string]struct{}) (models.BannerSearchResult, bool) {
+	content, err := decodeContent(banner.Content)
+	if err != nil {
+		return models.BannerSearchResult{}, false
+	}
+
+	found := make(map[string]struct{}, len(terms))
+	var words, hits int
+	mark := func(s string) string {
+		var b strings.Builder
+		last := 0
+		for _, w := range wordBounds(s) {
+			word := strings.ToLower(s[w[0]:w[1]])
+			words++
+			if _, ok := terms[word]; !ok {
+				continue
+			}
+			hits++
+			found[word] = struct{}{}
+			b.WriteString(s[last:w[0]])
+			b.WriteString(models.HighlightStart)
+			b.WriteString(s[w[0]:w[1]])
+			b.WriteString(models.HighlightStop)
+			last = w[1]
+		}
+		b.WriteString(s[last:])
+		return b.String()
+	}
+	highlighted := mapStrings(content, mark)
+	for _, locale := range sortedLocales(banner.Localized) {
+		localized, err := decodeContent(banner.Localized[locale])
+		if err != nil {
+			return models.BannerSearchResult{}, false
+		}
+		mapStrings(localized, mark)
+	}
+	if len(found) != len(terms) {
+		return models.BannerSearchResult{}, false
+	}
+
+	highlight, err := encodeContent(highlighted)
+	if err != nil {
+		return models.BannerSearchResult{}, false
+	}
+
+	return models.BannerSearchResult{
+		Banner:    banner,
+		Rank:      float64(hits) / (1 + math.Log(float64(words))),
+		Highlight: highlight,
+	}, true
+}
+
+func decodeContent(content json.RawMessage) (any, error) {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func searchTerms(query string) map[string]struct{} {
+	terms := make(map[string]struct{})
+	for _, w := range wordBounds(query) {
+		terms[strings.ToLower(query[w[0]:w[1]])] = struct{}{}
+	}
+	return terms
+}
+
+// wordBounds returns the [start, end) byte offsets of the letter and digit
+// runs of s.
+func wordBounds(s string) [][2]int {
+	var bounds [][2]int
+	start := -1
+	for i, r := range s {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			bounds = append(bounds, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		bounds = append(bounds, [2]int{start, len(s)})
+	}
+	return bounds
+}
+
+// mapStrings applies f to every string value of a decoded JSON document.
+// Object keys are left as they are.
+func mapStrings(v any, f func(string) string) any {
+	switch v := v.(type) {
+	case string:
+		return f(v)
+	case []any:
+		for i := range v {
+			v[i] = mapStrings(v[i], f)
+		}
+		return v
+	case map[string]any:
+		for k := range v {
+			v[k] = mapStrings(v[k], f)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,131 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// scanRepository serves the banners the fallback search reads; the other
+// Repository methods are not used by it.
+type scanRepository struct {
+	Repository
+	banners  []models.Banner
+	versions map[uint64][]models.Banner
+}
+
+func (r *scanRepository) GetFilteredBanners(_ context.Context, _ *models.FilterBanner) (models.BannerPage, error) {
+	return models.BannerPage{Banners: r.banners}, nil
+}
+
+func (r *scanRepository) GetListOfVersions(_ context.Context, bannerId uint64) ([]models.Banner, error) {
+	return r.versions[bannerId], nil
+}
+
+func TestScanSearch(t *testing.T) {
+	summerSale := models.Banner{BannerId: 1, Version: 2, Content: json.RawMessage(`{"title": "Summer sale", "price": 100}`)}
+	russian := models.Banner{BannerId: 2, Version: 1, Content: json.RawMessage(`{"title": "Летняя распродажа", "items": ["летняя коллекция"]}`),
+		Localized: map[string]json.RawMessage{"kk": json.RawMessage(`{"title": "Жазғы жеңілдік"}`)}}
+	repo := &scanRepository{
+		banners: []models.Banner{summerSale, russian},
+		versions: map[uint64][]models.Banner{
+			1: {summerSale, {BannerId: 1, Version: 1, Content: json.RawMessage(`{"title": "Summer sale draft"}`)}},
+			2: {russian},
+		},
+	}
+	s := NewService(Deps{BannerRepo: repo})
+
+	tests := []struct {
+		name     string
+		search   models.BannerSearch
+		versions [][2]uint64
+	}{
+		{name: "all words must match", search: models.BannerSearch{Query: "summer winter"}},
+		{name: "case insensitive", search: models.BannerSearch{Query: "SUMMER"}, versions: [][2]uint64{{1, 2}}},
+		{name: "non-latin words", search: models.BannerSearch{Query: "летняя"}, versions: [][2]uint64{{2, 1}}},
+		{name: "localized content", search: models.BannerSearch{Query: "жеңілдік"}, versions: [][2]uint64{{2, 1}}},
+		{name: "words of different locales", search: models.BannerSearch{Query: "жеңілдік коллекция"}, versions: [][2]uint64{{2, 1}}},
+		{name: "numbers are not strings", search: models.BannerSearch{Query: "100"}},
+		{
+			name:     "history",
+			search:   models.BannerSearch{Query: "sale", WithHistory: true},
+			versions: [][2]uint64{{1, 2}, {1, 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := s.SearchBanners(context.Background(), &tt.search)
+			require.NoError(t, err)
+
+			versions := make([][2]uint64, 0, len(results))
+			for _, result := range results {
+				versions = append(versions, [2]uint64{result.BannerId, result.Version})
+			}
+			assert.ElementsMatch(t, tt.versions, versions)
+		})
+	}
+}
+
+func TestScanSearchHighlight(t *testing.T) {
+	repo := &scanRepository{banners: []models.Banner{
+		{BannerId: 1, Version: 1, Content: json.RawMessage(`{"title": "Летняя распродажа", "price": 100}`)},
+	}}
+
+	results, err := NewService(Deps{BannerRepo: repo}).SearchBanners(context.Background(), &models.BannerSearch{Query: "распродажа"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.True(t, results[0].IsCurrent)
+	assert.Positive(t, results[0].Rank)
+	assert.JSONEq(t, `{"title": "Летняя <mark>распродажа</mark>", "price": 100}`, string(results[0].Highlight))
+}
+
+func TestScanSearchHighlightEscapesMarkup(t *testing.T) {
+	repo := &scanRepository{banners: []models.Banner{
+		{BannerId: 1, Version: 1, Content: json.RawMessage(`{"title": "<b>Summer</b> sale & more"}`)},
+	}}
+
+	results, err := NewService(Deps{BannerRepo: repo}).SearchBanners(context.Background(), &models.BannerSearch{Query: "sale"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.JSONEq(t, `{"title": "&lt;b&gt;Summer&lt;/b&gt; <mark>sale</mark> &amp; more"}`, string(results[0].Highlight))
+}
+
+// searchRepository has native search and returns the highlights the way the
+// database marks them.
+type searchRepository struct {
+	Repository
+	results []models.BannerSearchResult
+	search  *models.BannerSearch
+}
+
+func (r *searchRepository) SearchBanners(_ context.Context, search *models.BannerSearch) ([]models.BannerSearchResult, error) {
+	r.search = search
+	return r.results, nil
+}
+
+func TestNativeSearchHighlight(t *testing.T) {
+	highlight, err := json.Marshal(map[string]any{
+		"title": `<img src=x onerror="alert(1)"> ` + models.HighlightStart + "sale" + models.HighlightStop,
+		"items": []string{models.HighlightStart + "Sale" + models.HighlightStop + " <mark>"},
+		"price": 100,
+	})
+	require.NoError(t, err)
+	repo := &searchRepository{results: []models.BannerSearchResult{{Banner: models.Banner{BannerId: 1}, Highlight: highlight}}}
+
+	results, err := NewService(Deps{BannerRepo: repo}).SearchBanners(context.Background(), &models.BannerSearch{Query: "sale", Limit: 1000})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, uint64(maxSearchLimit), repo.search.Limit)
+	assert.JSONEq(t, `{
+		"title": "&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>sale</mark>",
+		"items": ["<mark>Sale</mark> &lt;mark&gt;"],
+		"price": 100
+	}`, string(results[0].Highlight))
+}
@@ -2,31 +2,79 @@ package banner
 
 import (
 	"banner-service/internal/controller/http"
+	"banner-service/internal/frequency"
+	"banner-service/internal/locale"
 	"banner-service/internal/models"
 	"banner-service/internal/repository"
+	"banner-service/internal/template"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/jellydator/ttlcache/v3"
+	"github.com/samber/lo"
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Repository interface {
-	GetBanner(ctx context.Context, tagId, featureId uint64, isAdmin bool) (models.BannerContent, error)
+	GetBanner(ctx context.Context, tagId, featureId uint64, locales []string, isAdmin bool, excluded []uint64) (models.BannerContent, error)
 	GetListOfVersions(ctx context.Context, bannerId uint64) ([]models.Banner, error)
-	ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) error
-	GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) ([]models.Banner, error)
-	CreateBanner(ctx context.Context, banner *models.Banner) (uint64, error)
-	PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner) error
+	GetBannerById(ctx context.Context, bannerId uint64) (models.Banner, error)
+	ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) ([]models.FeatureTag, error)
+	GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) (models.BannerPage, error)
+	CreateBanner(ctx context.Context, banner *models.Banner, schemaVersions models.SchemaVersions) (uint64, error)
+	PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner, check models.ContentCheck) ([]models.FeatureTag, error)
 	DeleteBanner(ctx context.Context, id uint64) error
-	MarkBannersAsDeleted(ctx context.Context, featureId, tagId *uint64) error
+	MarkBannersAsDeleted(ctx context.Context, featureId, tagId *uint64) ([]models.FeatureTag, error)
+	GetAuditEvents(ctx context.Context, filter *models.AuditFilter) (models.AuditPage, error)
+	GetDeletedBanners(ctx context.Context) ([]models.DeletedBanner, error)
+	RestoreBanner(ctx context.Context, bannerId uint64) ([]models.FeatureTag, error)
+	RestoreBanners(ctx context.Context, featureId, tagId *uint64) ([]uint64, []models.FeatureTag, error)
+	GetLatestFeatureEventId(ctx context.Context, featureId, afterEventId uint64) (uint64, error)
+	GetRevision(ctx context.Context) (uint64, error)
+	GetLatestTagEventId(ctx context.Context, tagId, afterEventId uint64) (uint64, error)
+	GetTagContents(ctx context.Context, tagId uint64) ([]models.FeatureContent, error)
+	ExportBanners(ctx context.Context, fn func(models.BannerExport) error) error
+	ImportBanners(ctx context.Context, banners []models.BannerExport, opts models.ImportOptions, schemaVersions models.SchemaVersions) ([]models.ImportLineResult, []models.FeatureTag, error)
+	BulkUpdate(ctx context.Context, req *models.BulkRequest) (models.BulkReport, []models.FeatureTag, error)
+}
+
+type Notifier interface {
+	SubscribeFeature(featureId uint64) (<-chan uint64, func())
+	SubscribeTag(tagId uint64) (<-chan uint64, func())
 }
 
 type Deps struct {
-	BannerRepo Repository
-	Cache      *ttlcache.Cache[models.FeatureTag, models.BannerContent]
+	BannerRepo        Repository
+	Cache             *ttlcache.Cache[models.BannerCacheKey, models.BannerContent]
+	Notifier          Notifier
+	Catalog           Catalog
+	Schemas           Schemas
+	Assets            Assets
+	DeleteGracePeriod time.Duration
+	SnapshotWait      time.Duration
+	// Caps counts the serves of banners with a frequency cap; without it
+	// banners are served uncapped.
+	Caps frequency.Store
+	// StrictReferences requires banners to refer only to features and tags
+	// registered in the catalog.
+	StrictReferences bool
+	// Locales are the locales banner content can be localized to, as
+	// negotiated by GetBanner.
+	Locales []string
+	// StrictTemplates fails rendering when a template variable without a
+	// default is missing instead of rendering it empty, for every request;
+	// without it a request can still ask for strict rendering.
+	StrictTemplates bool
 }
 
 type Service struct {
 	Deps
+	compiledSchemas sync.Map
 }
 
 func NewService(d Deps) *Service {
@@ -37,26 +85,196 @@ func NewService(d Deps) *Service {
 
 var _ http.BannerManagement = (*Service)(nil)
 
-func (s *Service) GetBanner(ctx context.Context, tagId uint64, featureId uint64, role models.UserRole, useLastRevision bool) (string, error) {
+// evict drops the cached banners of the features of the pairs. A banner is
+// also cached under the tags it is a fallback for, as the banner of a parent
+// tag or the default banner of the feature, so every key of the feature goes.
+func (s *Service) evict(pairs []models.FeatureTag) {
+	if len(pairs) == 0 {
+		return
+	}
+	features := lo.SliceToMap(pairs, func(pair models.FeatureTag) (uint64, struct{}) {
+		return pair.FeatureId, struct{}{}
+	})
+	for _, key := range s.Cache.Keys() {
+		if _, ok := features[key.FeatureId]; ok {
+			s.Cache.Delete(key)
+		}
+	}
+}
+
+// EvictFeature drops the cached banners of the feature, for a change of its
+// default banner.
+func (s *Service) EvictFeature(featureId uint64) {
+	s.evict([]models.FeatureTag{{FeatureId: featureId}})
+}
+
+// EvictAll drops every cached banner, for a change of the tag tree: the keys
+// do not tell which tags fall back to the moved one.
+func (s *Service) EvictAll() {
+	s.Cache.DeleteAll()
+}
+
+// MaxUserTags bounds the number of tags of a single user banner request.
+const MaxUserTags = 32
+
+// GetBanner returns the banner for the user's tags. Every tag is resolved on
+// its own, to its banner, the banner of its nearest ancestor or the default
+// banner of the feature, and the best of them is served: a banner found by a
+// tag beats the default one, then the higher priority wins, then the nearer
+// tag, then the lower banner id. Inactive banners are skipped for users and
+// reported only if nothing else matched. A banner whose frequency cap is
+// reached for the user (the sub claim of vars) gives way to the next best one:
+// the tags are resolved again past the capped banners, so a tag falls back to
+// the banner of its ancestor or the default banner of the feature. These
+// lookups skip the cache. Without any banner left it is a not found error.
+//
+// The content is served in the supported locale the languages (in the
+// Accept-Language syntax) prefer most among those the banner has, and by
+// default if it has none of them. Templated content is rendered with vars after the lookup, so the
+// cache holds the raw template shared by all users.
+func (s *Service) GetBanner(ctx context.Context, tagIds []uint64, featureId uint64, role models.UserRole, useLastRevision bool, vars models.TemplateVars, languages string) (models.BannerContent, error) {
+	locales := locale.Rank(languages, s.Locales)
+	var capped []uint64
+	for {
+		candidates, err := s.rankBanners(ctx, tagIds, featureId, locales, role, useLastRevision, capped)
+		if len(capped) > 0 && (errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrBannerInactive)) {
+			return models.BannerContent{}, fmt.Errorf("%w: frequency cap reached", repository.ErrNotFound)
+		} else if err != nil {
+			return models.BannerContent{}, err
+		}
+
+		// Only the best banner is tried: past a capped one a tag may fall back
+		// to a banner that outranks the rest of this ranking.
+		content, served, err := s.serve(ctx, candidates[0], vars, role)
+		if err != nil {
+			return models.BannerContent{}, err
+		} else if served {
+			return content, nil
+		}
+		capped = append(capped, candidates[0].BannerId)
+	}
+}
+
+// serve renders the content of the banner and then counts the serve against
+// its frequency cap, so content that fails to render is not counted. It
+// reports false if the cap is reached.
+func (s *Service) serve(ctx context.Context, content models.BannerContent, vars models.TemplateVars, role models.UserRole) (models.BannerContent, bool, error) {
+	rendered, err := s.render(content.Content, vars)
+	if err != nil {
+		return models.BannerContent{}, false, err
+	}
+	if served, err := s.takeServe(ctx, content, vars.User[userClaim], role); err != nil || !served {
+		return models.BannerContent{}, false, err
+	}
+	content.Content = rendered
+	return content, true, nil
+}
+
+// render substitutes the template variables of banner content. Every path
+// that serves content to users renders it; the cache keeps the templates.
+func (s *Service) render(content string, vars models.TemplateVars) (string, error) {
+	rendered, err := template.Render(json.RawMessage(content), vars, s.StrictTemplates || vars.Strict)
+	if err != nil {
+		return "", err
+	}
+	return string(rendered), nil
+}
+
+// resolveBanner picks the banner of GetBanner with its content unrendered,
+// regardless of frequency caps.
+func (s *Service) resolveBanner(ctx context.Context, tagIds []uint64, featureId uint64, locales []string, role models.UserRole, useLastRevision bool) (models.BannerContent, error) {
+	candidates, err := s.rankBanners(ctx, tagIds, featureId, locales, role, useLastRevision, nil)
+	if err != nil {
+		return models.BannerContent{}, err
+	}
+	return candidates[0], nil
+}
+
+// rankBanners resolves every tag past the excluded banners and returns the
+// distinct banners found, the best first. It fails if no tag has a banner.
+func (s *Service) rankBanners(ctx context.Context, tagIds []uint64, featureId uint64, locales []string, role models.UserRole, useLastRevision bool, excluded []uint64) ([]models.BannerContent, error) {
+	tagIds = lo.Uniq(tagIds)
+	if len(tagIds) > MaxUserTags {
+		return nil, fmt.Errorf("%w: more than %d tags", repository.ErrInvalidFilter, MaxUserTags)
+	}
+
+	var (
+		candidates []models.BannerContent
+		inactive   bool
+	)
+	for _, tagId := range tagIds {
+		content, err := s.getTagBanner(ctx, tagId, featureId, locales, role, useLastRevision, excluded)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		} else if errors.Is(err, repository.ErrBannerInactive) {
+			inactive = true
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, content)
+	}
+
+	if len(candidates) == 0 {
+		if inactive {
+			return nil, repository.ErrBannerInactive
+		}
+		return nil, repository.ErrNotFound
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return outranks(candidates[i], candidates[j])
+	})
+	return lo.UniqBy(candidates, func(content models.BannerContent) uint64 {
+		return content.BannerId
+	}), nil
+}
+
+// getTagBanner resolves a single tag. The result is cached under the requested
+// feature, tag and ranked locales, so a fallback costs a single database query
+// per TTL. A lookup past excluded banners is neither read from nor written to
+// the cache.
+// Changes to a banner or to the default banner of a feature evict the whole
+// feature, fallbacks included, and a change of a tag's parent evicts it all.
+func (s *Service) getTagBanner(ctx context.Context, tagId uint64, featureId uint64, locales []string, role models.UserRole, useLastRevision bool, excluded []uint64) (models.BannerContent, error) {
+	if len(excluded) > 0 {
+		return s.BannerRepo.GetBanner(ctx, tagId, featureId, locales, role == models.Admin, excluded)
+	}
+
+	key := models.BannerCacheKey{FeatureId: featureId, TagId: tagId, Locale: strings.Join(locales, ",")}
 	if !useLastRevision {
-		if banner := s.Cache.Get(models.FeatureTag{FeatureId: featureId, TagId: tagId}); banner != nil {
+		if banner := s.Cache.Get(key); banner != nil {
 			if banner.Value().IsActive || role == models.Admin {
 				log.Println("get banner from cache", banner.Value())
-				return banner.Value().Content, nil
+				return banner.Value(), nil
 			} else {
-				return "", repository.ErrBannerInactive
+				return models.BannerContent{}, repository.ErrBannerInactive
 			}
 		}
 	}
 
-	content, err := s.BannerRepo.GetBanner(ctx, tagId, featureId, role == models.Admin)
+	content, err := s.BannerRepo.GetBanner(ctx, tagId, featureId, locales, role == models.Admin, nil)
 	if err != nil {
-		return "", err
+		return models.BannerContent{}, err
 	}
 
-	s.Cache.Set(models.FeatureTag{FeatureId: featureId, TagId: tagId}, content, ttlcache.DefaultTTL)
+	s.Cache.Set(key, content, ttlcache.DefaultTTL)
 	log.Println("get banner from db", content)
-	return content.Content, nil
+	return content, nil
+}
+
+// outranks reports whether banner a should be served instead of banner b.
+func outranks(a, b models.BannerContent) bool {
+	if isDefaultA, isDefaultB := a.TagId == 0, b.TagId == 0; isDefaultA != isDefaultB {
+		return isDefaultB
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if a.Depth != b.Depth {
+		return a.Depth < b.Depth
+	}
+	return a.BannerId < b.BannerId
 }
 
 func (s *Service) GetListOfVersions(ctx context.Context, bannerId uint64) ([]models.Banner, error) {
@@ -68,32 +286,70 @@ func (s *Service) GetListOfVersions(ctx context.Context, bannerId uint64) ([]mod
 }
 
 func (s *Service) ChooseBannerVersion(ctx context.Context, bannerId uint64, version uint64) error {
-	if err := s.BannerRepo.ChooseBannerVersion(ctx, bannerId, version); err != nil {
+	affected, err := s.BannerRepo.ChooseBannerVersion(ctx, bannerId, version)
+	if err != nil {
 		return err
 	}
+	s.evict(affected)
 	return nil
 }
 
-func (s *Service) GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) ([]models.Banner, error) {
-	if banners, err := s.BannerRepo.GetFilteredBanners(ctx, filter); err != nil {
-		return nil, err
-	} else {
-		return banners, nil
+func (s *Service) GetFilteredBanners(ctx context.Context, filter *models.FilterBanner) (models.BannerPage, error) {
+	page, err := s.BannerRepo.GetFilteredBanners(ctx, filter)
+	if err != nil {
+		return models.BannerPage{}, err
+	}
+	if err = s.expandBanners(ctx, page.Banners, filter.Expand); err != nil {
+		return models.BannerPage{}, err
 	}
+	return page, nil
 }
 
 func (s *Service) CreateBanner(ctx context.Context, banner *models.Banner) (uint64, error) {
-	bannerId, err := s.BannerRepo.CreateBanner(ctx, banner)
+	versions := make(models.SchemaVersions)
+	if err := s.checkReferences(ctx, &banner.FeatureId, banner.TagIds); err != nil {
+		return 0, err
+	}
+	if err := s.checkLocales(banner.Localized, &banner.DefaultLocale); err != nil {
+		return 0, err
+	}
+	if err := checkFrequencyCap(banner.FrequencyCap); err != nil {
+		return 0, err
+	} else if banner.FrequencyCap != nil && banner.FrequencyCap.Limit == 0 {
+		banner.FrequencyCap = nil
+	}
+	if err := s.checkLocalizedContent(ctx, banner.FeatureId, banner.Content, banner.Localized, versions); err != nil {
+		return 0, err
+	}
+	bannerId, err := s.BannerRepo.CreateBanner(ctx, banner, versions)
 	if err != nil {
 		return 0, err
 	}
 	return bannerId, nil
 }
 
+// PartialUpdateBanner applies the patch. The banner it leaves is checked
+// against the schema of its feature by the repository, in the transaction that
+// applies the patch; the assets it refers to are checked there as well.
 func (s *Service) PartialUpdateBanner(ctx context.Context, bannerId uint64, bannerPartial *models.PatchBanner) error {
-	if err := s.BannerRepo.PartialUpdateBanner(ctx, bannerId, bannerPartial); err != nil {
+	if err := s.checkReferences(ctx, bannerPartial.FeatureId, bannerPartial.TagIds); err != nil {
+		return err
+	}
+	if err := s.checkLocales(bannerPartial.Localized, bannerPartial.DefaultLocale); err != nil {
+		return err
+	}
+	if err := checkFrequencyCap(bannerPartial.FrequencyCap); err != nil {
 		return err
 	}
+	var check models.ContentCheck
+	if s.Schemas != nil {
+		check = s.checkTxContent
+	}
+	affected, err := s.BannerRepo.PartialUpdateBanner(ctx, bannerId, bannerPartial, check)
+	if err != nil {
+		return err
+	}
+	s.evict(affected)
 	return nil
 }
 
@@ -104,9 +360,50 @@ func (s *Service) DeleteBanner(ctx context.Context, bannerId uint64) error {
 	return nil
 }
 
+// MarkBannerAsDeleted marks the banners as deleted and evicts them from the
+// cache, so users stop getting them right away.
 func (s *Service) MarkBannerAsDeleted(ctx context.Context, featureId, tagId *uint64) error {
-	if err := s.BannerRepo.MarkBannersAsDeleted(ctx, featureId, tagId); err != nil {
+	affected, err := s.BannerRepo.MarkBannersAsDeleted(ctx, featureId, tagId)
+	if err != nil {
 		return err
 	}
+	s.evict(affected)
 	return nil
 }
+
+func (s *Service) GetAuditEvents(ctx context.Context, filter *models.AuditFilter) (models.AuditPage, error) {
+	page, err := s.BannerRepo.GetAuditEvents(ctx, filter)
+	if err != nil {
+		return models.AuditPage{}, err
+	}
+	return page, nil
+}
+
+func (s *Service) GetDeletedBanners(ctx context.Context) ([]models.DeletedBanner, error) {
+	banners, err := s.BannerRepo.GetDeletedBanners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range banners {
+		banners[i].PurgeAt = banners[i].DeletedAt.Add(s.DeleteGracePeriod)
+	}
+	return banners, nil
+}
+
+func (s *Service) RestoreBanner(ctx context.Context, bannerId uint64) error {
+	affected, err := s.BannerRepo.RestoreBanner(ctx, bannerId)
+	if err != nil {
+		return err
+	}
+	s.evict(affected)
+	return nil
+}
+
+func (s *Service) RestoreBanners(ctx context.Context, featureId, tagId *uint64) ([]uint64, error) {
+	bannerIds, affected, err := s.BannerRepo.RestoreBanners(ctx, featureId, tagId)
+	if err != nil {
+		return nil, err
+	}
+	s.evict(affected)
+	return bannerIds, nil
+}
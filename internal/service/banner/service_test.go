@@ -0,0 +1,382 @@
+package banner
+
+import (
+	"banner-service/internal/frequency"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"banner-service/internal/template"
+	"context"
+	"encoding/json"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// resolveRepository serves the banners it holds by tag and counts the
+// lookups. A banner is localized to the locales listed for its tag, besides
+// the default locale in its Locale.
+type resolveRepository struct {
+	Repository
+	banners map[uint64]models.BannerContent
+	// fallbacks are the banners of the ancestors of a tag and the default
+	// banner of the feature, nearest first, for lookups past excluded banners.
+	fallbacks map[uint64][]models.BannerContent
+	localized map[uint64][]string
+	calls     int
+}
+
+func (r *resolveRepository) GetBanner(_ context.Context, tagId, _ uint64, locales []string, isAdmin bool, excluded []uint64) (models.BannerContent, error) {
+	r.calls++
+	banner, ok := r.banners[tagId]
+	for i := 0; ok && lo.Contains(excluded, banner.BannerId); i++ {
+		ok = i < len(r.fallbacks[tagId])
+		if ok {
+			banner = r.fallbacks[tagId][i]
+		}
+	}
+	if !ok {
+		return models.BannerContent{}, repository.ErrNotFound
+	} else if !banner.IsActive && !isAdmin {
+		return models.BannerContent{}, repository.ErrBannerInactive
+	}
+	for _, locale := range locales {
+		if locale == banner.Locale || lo.Contains(r.localized[tagId], locale) {
+			banner.Locale = locale
+			break
+		}
+	}
+	return banner, nil
+}
+
+func TestGetBannerRendersAfterCache(t *testing.T) {
+	repo := &resolveRepository{banners: map[uint64]models.BannerContent{
+		1: {Content: `{"title": "Hi, {{user.name|guest}}"}`, IsActive: true, TagId: 1, BannerId: 1},
+	}}
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	s := NewService(Deps{BannerRepo: repo, Cache: cache})
+
+	for _, name := range []string{"Ann", "Bob"} {
+		vars := models.TemplateVars{User: map[string]string{"name": name}}
+		content, err := s.GetBanner(context.Background(), []uint64{1}, 1, models.Client, false, vars, "")
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"title": "Hi, `+name+`"}`, content.Content)
+	}
+
+	assert.Equal(t, 1, repo.calls)
+	cached := cache.Get(models.BannerCacheKey{FeatureId: 1, TagId: 1})
+	require.NotNil(t, cached)
+	assert.Equal(t, `{"title": "Hi, {{user.name|guest}}"}`, cached.Value().Content, "the cache holds the raw template")
+}
+
+func TestGetBannerCachesPerLocale(t *testing.T) {
+	repo := &resolveRepository{
+		banners: map[uint64]models.BannerContent{
+			1: {Content: `{"title": "hello"}`, IsActive: true, TagId: 1, BannerId: 1},
+		},
+		localized: map[uint64][]string{1: {"ru", "en", "kk"}},
+	}
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	s := NewService(Deps{BannerRepo: repo, Cache: cache, Locales: []string{"ru", "en", "kk"}})
+
+	for _, tt := range []struct {
+		languages string
+		locale    string
+	}{
+		{"en-US, ru;q=0.5", "en"},
+		{"kk;q=0.2, ru;q=0.9", "ru"},
+		{"de", ""},
+		{"en-US, ru;q=0.5", "en"},
+	} {
+		content, err := s.GetBanner(context.Background(), []uint64{1}, 1, models.Client, false, models.TemplateVars{}, tt.languages)
+		require.NoError(t, err)
+		assert.Equal(t, tt.locale, content.Locale, tt.languages)
+	}
+
+	assert.Equal(t, 3, repo.calls, "each list of accepted locales is fetched once")
+	assert.True(t, cache.Has(models.BannerCacheKey{FeatureId: 1, TagId: 1, Locale: "en,ru"}))
+	assert.True(t, cache.Has(models.BannerCacheKey{FeatureId: 1, TagId: 1}), "unmatched languages share the default key")
+}
+
+func TestGetBannerNegotiatesAvailableLocales(t *testing.T) {
+	repo := &resolveRepository{
+		banners: map[uint64]models.BannerContent{
+			1: {Content: `{"title": "hello"}`, IsActive: true, TagId: 1, BannerId: 1, Locale: "en"},
+		},
+		localized: map[uint64][]string{1: {"ru"}},
+	}
+	s := NewService(Deps{BannerRepo: repo, Cache: ttlcache.New[models.BannerCacheKey, models.BannerContent](), Locales: []string{"ru", "en", "kk"}})
+
+	for _, tt := range []struct {
+		languages string
+		locale    string
+	}{
+		{"kk, ru;q=0.5", "ru"},
+		{"kk, en;q=0.5, ru;q=0.1", "en"},
+		{"kk", "en"},
+	} {
+		content, err := s.GetBanner(context.Background(), []uint64{1}, 1, models.Client, false, models.TemplateVars{}, tt.languages)
+		require.NoError(t, err)
+		assert.Equal(t, tt.locale, content.Locale, tt.languages)
+	}
+}
+
+func TestCheckLocalesNormalizesKeys(t *testing.T) {
+	s := NewService(Deps{Locales: []string{"ru", "en", "kk"}})
+
+	localized := map[string]json.RawMessage{"EN": json.RawMessage(`{}`), "kk": json.RawMessage(`{}`)}
+	defaultLocale := "RU"
+	require.NoError(t, s.checkLocales(localized, &defaultLocale))
+	assert.Equal(t, []string{"en", "kk"}, sortedLocales(localized))
+	assert.Equal(t, "ru", defaultLocale)
+
+	duplicated := map[string]json.RawMessage{"EN": json.RawMessage(`{}`), "en": json.RawMessage(`{}`)}
+	assert.ErrorIs(t, s.checkLocales(duplicated, nil), repository.ErrInvalidLocale)
+
+	assert.ErrorIs(t, s.checkLocales(map[string]json.RawMessage{"de": json.RawMessage(`{}`)}, nil), repository.ErrInvalidLocale)
+}
+
+func TestGetBannerPriority(t *testing.T) {
+	banners := map[uint64]models.BannerContent{
+		1: {Content: "default", IsActive: true, TagId: 0, BannerId: 1, Priority: 100},
+		2: {Content: "low", IsActive: true, TagId: 2, BannerId: 2, Priority: 1},
+		3: {Content: "high", IsActive: true, TagId: 3, BannerId: 5, Priority: 5},
+		4: {Content: "high inherited", IsActive: true, TagId: 3, BannerId: 5, Priority: 5, Depth: 1},
+		5: {Content: "high, lower id", IsActive: true, TagId: 5, BannerId: 4, Priority: 5, Depth: 1},
+		6: {Content: "inactive", IsActive: false, TagId: 6, BannerId: 6, Priority: 10},
+	}
+
+	tests := []struct {
+		name    string
+		tagIds  []uint64
+		content string
+		err     error
+	}{
+		{name: "tag banner beats the default", tagIds: []uint64{1, 2}, content: "low"},
+		{name: "higher priority wins", tagIds: []uint64{2, 3}, content: "high"},
+		{name: "nearer tag wins", tagIds: []uint64{4, 3}, content: "high"},
+		{name: "lower banner id wins", tagIds: []uint64{4, 5}, content: "high, lower id"},
+		{name: "order of tags does not matter", tagIds: []uint64{5, 4}, content: "high, lower id"},
+		{name: "inactive banners are skipped", tagIds: []uint64{6, 2}, content: "low"},
+		{name: "only inactive banners", tagIds: []uint64{6, 7}, err: repository.ErrBannerInactive},
+		{name: "nothing found", tagIds: []uint64{7, 8}, err: repository.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &resolveRepository{banners: banners}
+			s := NewService(Deps{BannerRepo: repo, Cache: ttlcache.New[models.BannerCacheKey, models.BannerContent]()})
+
+			content, err := s.GetBanner(context.Background(), tt.tagIds, 1, models.Client, false, models.TemplateVars{}, "")
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, content.Content)
+		})
+	}
+}
+
+func TestGetBannerFrequencyCap(t *testing.T) {
+	repo := &resolveRepository{banners: map[uint64]models.BannerContent{
+		1: {Content: `{"title": "popup"}`, IsActive: true, TagId: 1, BannerId: 1, Priority: 10,
+			FrequencyCap: &models.FrequencyCap{Limit: 2, WindowSeconds: 86400}},
+		2: {Content: `{"title": "regular"}`, IsActive: true, TagId: 2, BannerId: 2},
+	}}
+	s := NewService(Deps{
+		BannerRepo: repo,
+		Cache:      ttlcache.New[models.BannerCacheKey, models.BannerContent](),
+		Caps:       frequency.NewMemoryStore(),
+	})
+	ctx := context.Background()
+	get := func(user string, tagIds ...uint64) (models.BannerContent, error) {
+		vars := models.TemplateVars{User: map[string]string{"sub": user}}
+		return s.GetBanner(ctx, tagIds, 1, models.Client, false, vars, "")
+	}
+
+	for i := 0; i < 2; i++ {
+		content, err := get("alice", 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), content.BannerId)
+	}
+
+	content, err := get("alice", 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), content.BannerId, "a capped banner gives way to the next one")
+
+	_, err = get("alice", 1)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "a capped banner without a fallback is not served")
+
+	content, err = get("bob", 1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), content.BannerId, "serves are counted per user")
+
+	for i := 0; i < 3; i++ {
+		content, err = s.GetBanner(ctx, []uint64{1}, 1, models.Admin, false, models.TemplateVars{}, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), content.BannerId, "admins are not capped")
+	}
+}
+
+func TestGetBannerFrequencyCapFallsBack(t *testing.T) {
+	capOf := func(limit uint64) *models.FrequencyCap {
+		return &models.FrequencyCap{Limit: limit, WindowSeconds: 86400}
+	}
+	repo := &resolveRepository{
+		banners: map[uint64]models.BannerContent{
+			1: {Content: `{"title": "tag"}`, IsActive: true, TagId: 1, BannerId: 1, FrequencyCap: capOf(1)},
+		},
+		fallbacks: map[uint64][]models.BannerContent{1: {
+			{Content: `{"title": "parent"}`, IsActive: true, TagId: 2, BannerId: 2, Depth: 1, FrequencyCap: capOf(1)},
+			{Content: `{"title": "default"}`, IsActive: true, BannerId: 3},
+		}},
+	}
+	s := NewService(Deps{
+		BannerRepo: repo,
+		Cache:      ttlcache.New[models.BannerCacheKey, models.BannerContent](),
+		Caps:       frequency.NewMemoryStore(),
+	})
+	get := func(vars models.TemplateVars) uint64 {
+		t.Helper()
+		content, err := s.GetBanner(context.Background(), []uint64{1}, 1, models.Client, false, vars, "")
+		require.NoError(t, err)
+		return content.BannerId
+	}
+	alice := models.TemplateVars{User: map[string]string{"sub": "alice"}}
+
+	assert.Equal(t, uint64(1), get(alice))
+	assert.Equal(t, uint64(2), get(alice), "a capped banner gives way to the banner of the parent tag")
+	assert.Equal(t, uint64(3), get(alice), "and then to the default banner of the feature")
+	assert.Equal(t, uint64(3), get(alice))
+
+	assert.Equal(t, uint64(3), get(models.TemplateVars{}), "serves without a user cannot be counted")
+}
+
+func TestGetBannerFrequencyCapRanksFallbacks(t *testing.T) {
+	repo := &resolveRepository{
+		banners: map[uint64]models.BannerContent{
+			1: {Content: `{"title": "tag"}`, IsActive: true, TagId: 1, BannerId: 1, Priority: 10,
+				FrequencyCap: &models.FrequencyCap{Limit: 1, WindowSeconds: 86400}},
+			3: {Content: `{"title": "other tag"}`, IsActive: true, TagId: 3, BannerId: 3},
+		},
+		fallbacks: map[uint64][]models.BannerContent{1: {
+			{Content: `{"title": "parent"}`, IsActive: true, TagId: 2, BannerId: 2, Priority: 5, Depth: 1},
+		}},
+	}
+	s := NewService(Deps{
+		BannerRepo: repo,
+		Cache:      ttlcache.New[models.BannerCacheKey, models.BannerContent](),
+		Caps:       frequency.NewMemoryStore(),
+	})
+	alice := models.TemplateVars{User: map[string]string{"sub": "alice"}}
+
+	content, err := s.GetBanner(context.Background(), []uint64{1, 3}, 1, models.Client, false, alice, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), content.BannerId)
+
+	content, err = s.GetBanner(context.Background(), []uint64{1, 3}, 1, models.Client, false, alice, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), content.BannerId, "the banner of the parent tag outranks the one of the other tag")
+}
+
+func TestGetBannerFrequencyCapCountsRenderedServes(t *testing.T) {
+	repo := &resolveRepository{banners: map[uint64]models.BannerContent{
+		1: {Content: `{"title": "Hi, {{user.name}}"}`, IsActive: true, TagId: 1, BannerId: 1,
+			FrequencyCap: &models.FrequencyCap{Limit: 1, WindowSeconds: 86400}},
+	}}
+	s := NewService(Deps{
+		BannerRepo: repo,
+		Cache:      ttlcache.New[models.BannerCacheKey, models.BannerContent](),
+		Caps:       frequency.NewMemoryStore(),
+	})
+	ctx := context.Background()
+
+	_, err := s.GetBanner(ctx, []uint64{1}, 1, models.Client, false,
+		models.TemplateVars{User: map[string]string{"sub": "alice"}, Strict: true}, "")
+	assert.ErrorIs(t, err, template.ErrMissingVariable)
+
+	content, err := s.GetBanner(ctx, []uint64{1}, 1, models.Client, false,
+		models.TemplateVars{User: map[string]string{"sub": "alice", "name": "Alice"}}, "")
+	require.NoError(t, err, "a failed render does not use up the cap")
+	assert.JSONEq(t, `{"title": "Hi, Alice"}`, content.Content)
+}
+
+func TestGetBannerTooManyTags(t *testing.T) {
+	s := NewService(Deps{BannerRepo: &resolveRepository{}})
+
+	_, err := s.GetBanner(context.Background(), make([]uint64, 0), 1, models.Client, false, models.TemplateVars{}, "")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	tagIds := make([]uint64, MaxUserTags+1)
+	for i := range tagIds {
+		tagIds[i] = uint64(i + 1)
+	}
+	_, err = s.GetBanner(context.Background(), tagIds, 1, models.Client, false, models.TemplateVars{}, "")
+	assert.ErrorIs(t, err, repository.ErrInvalidFilter)
+}
+
+// deleteRepository marks, restores, patches and switches the version of the
+// banner of the pairs it holds.
+type deleteRepository struct {
+	Repository
+	pairs []models.FeatureTag
+}
+
+func (r *deleteRepository) MarkBannersAsDeleted(_ context.Context, _, _ *uint64) ([]models.FeatureTag, error) {
+	return r.pairs, nil
+}
+
+func (r *deleteRepository) RestoreBanners(_ context.Context, _, _ *uint64) ([]uint64, []models.FeatureTag, error) {
+	return []uint64{1}, r.pairs, nil
+}
+
+func (r *deleteRepository) PartialUpdateBanner(_ context.Context, _ uint64, _ *models.PatchBanner, _ models.ContentCheck) ([]models.FeatureTag, error) {
+	return r.pairs, nil
+}
+
+func (r *deleteRepository) ChooseBannerVersion(_ context.Context, _, _ uint64) ([]models.FeatureTag, error) {
+	return r.pairs, nil
+}
+
+func TestBannerChangesEvictCache(t *testing.T) {
+	pairs := []models.FeatureTag{{FeatureId: 1, TagId: 1}, {FeatureId: 1, TagId: 2}}
+	other := models.BannerCacheKey{FeatureId: 2, TagId: 1}
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	s := NewService(Deps{BannerRepo: &deleteRepository{pairs: pairs}, Cache: cache, Locales: []string{"ru", "en"}})
+
+	fill := func() {
+		for _, pair := range pairs {
+			cache.Set(models.BannerCacheKey{FeatureId: pair.FeatureId, TagId: pair.TagId}, models.BannerContent{}, ttlcache.DefaultTTL)
+			cache.Set(models.BannerCacheKey{FeatureId: pair.FeatureId, TagId: pair.TagId, Locale: "en"}, models.BannerContent{}, ttlcache.DefaultTTL)
+		}
+		cache.Set(other, models.BannerContent{}, ttlcache.DefaultTTL)
+	}
+	featureId := uint64(1)
+
+	fill()
+	require.NoError(t, s.MarkBannerAsDeleted(context.Background(), &featureId, nil))
+	assert.Equal(t, []models.BannerCacheKey{other}, cache.Keys(), "the deleted banners are evicted")
+
+	fill()
+	_, err := s.RestoreBanners(context.Background(), &featureId, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []models.BannerCacheKey{other}, cache.Keys(), "the restored banners are evicted")
+
+	fill()
+	require.NoError(t, s.PartialUpdateBanner(context.Background(), 1, &models.PatchBanner{IsActive: lo.ToPtr(false)}))
+	assert.Equal(t, []models.BannerCacheKey{other}, cache.Keys(), "the patched banner is evicted")
+
+	fill()
+	require.NoError(t, s.ChooseBannerVersion(context.Background(), 1, 1))
+	assert.Equal(t, []models.BannerCacheKey{other}, cache.Keys(), "the banner with another version is evicted")
+
+	fill()
+	s.EvictFeature(1)
+	assert.Equal(t, []models.BannerCacheKey{other}, cache.Keys(), "a change of the default banner evicts the feature")
+
+	fill()
+	s.EvictAll()
+	assert.Empty(t, cache.Keys(), "a change of the tag tree evicts everything")
+}
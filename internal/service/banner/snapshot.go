@@ -0,0 +1,67 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"banner-service/internal/reqctx"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// GetSnapshot returns every active banner of the tag keyed by feature,
+// rendered with vars. When since is set and nothing for the tag changed after
+// that revision, it waits up to SnapshotWait for a change and returns
+// repository.ErrNotModified if none happens. A server shutting down (see
+// reqctx.GetShutdown) ends the wait the same way, so the client polls again.
+func (s *Service) GetSnapshot(ctx context.Context, tagId uint64, since *uint64, vars models.TemplateVars) (models.TagSnapshot, error) {
+	if since != nil {
+		events, unsubscribe := s.Notifier.SubscribeTag(tagId)
+		defer unsubscribe()
+
+		eventId, err := s.BannerRepo.GetLatestTagEventId(ctx, tagId, *since)
+		if err != nil {
+			return models.TagSnapshot{}, err
+		}
+
+		if eventId == 0 {
+			timer := time.NewTimer(s.SnapshotWait)
+			defer timer.Stop()
+
+			select {
+			case <-events:
+			case <-timer.C:
+				return models.TagSnapshot{}, repository.ErrNotModified
+			case <-reqctx.GetShutdown(ctx):
+				return models.TagSnapshot{}, repository.ErrNotModified
+			case <-ctx.Done():
+				return models.TagSnapshot{}, ctx.Err()
+			}
+		}
+	}
+
+	revision, err := s.BannerRepo.GetRevision(ctx)
+	if err != nil {
+		return models.TagSnapshot{}, err
+	}
+
+	contents, err := s.BannerRepo.GetTagContents(ctx, tagId)
+	if err != nil {
+		return models.TagSnapshot{}, err
+	}
+
+	snapshot := models.TagSnapshot{
+		TagId:    tagId,
+		Revision: revision,
+		Banners:  make(map[uint64]json.RawMessage, len(contents)),
+	}
+	for _, content := range contents {
+		rendered, err := s.render(string(content.Content), vars)
+		if err != nil {
+			return models.TagSnapshot{}, err
+		}
+		snapshot.Banners[content.FeatureId] = json.RawMessage(rendered)
+	}
+
+	return snapshot, nil
+}
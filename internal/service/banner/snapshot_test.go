@@ -0,0 +1,134 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/notifier"
+	"banner-service/internal/repository"
+	"banner-service/internal/reqctx"
+	"banner-service/internal/template"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// snapshotRepository holds the contents of a single tag and the newest event
+// that changed them.
+type snapshotRepository struct {
+	Repository
+	mu       sync.Mutex
+	contents []models.FeatureContent
+	revision uint64
+}
+
+func (r *snapshotRepository) GetLatestTagEventId(_ context.Context, _, afterEventId uint64) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.revision <= afterEventId {
+		return 0, nil
+	}
+	return r.revision, nil
+}
+
+func (r *snapshotRepository) GetRevision(_ context.Context) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.revision, nil
+}
+
+func (r *snapshotRepository) GetTagContents(_ context.Context, _ uint64) ([]models.FeatureContent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.contents, nil
+}
+
+func (r *snapshotRepository) set(contents []models.FeatureContent, revision uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contents = contents
+	r.revision = revision
+}
+
+func TestGetSnapshot(t *testing.T) {
+	repo := &snapshotRepository{}
+	repo.set([]models.FeatureContent{{FeatureId: 1, Content: json.RawMessage(`{"title": "a"}`)}}, 3)
+	s := NewService(Deps{BannerRepo: repo, Notifier: notifier.NewHub(), SnapshotWait: time.Hour})
+
+	snapshot, err := s.GetSnapshot(context.Background(), 1, nil, models.TemplateVars{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), snapshot.Revision)
+	assert.JSONEq(t, `{"title": "a"}`, string(snapshot.Banners[1]))
+
+	since := uint64(2)
+	snapshot, err = s.GetSnapshot(context.Background(), 1, &since, models.TemplateVars{})
+	require.NoError(t, err, "a change after since is returned at once")
+	assert.Equal(t, uint64(3), snapshot.Revision)
+}
+
+func TestGetSnapshotRendersTemplates(t *testing.T) {
+	repo := &snapshotRepository{}
+	repo.set([]models.FeatureContent{{FeatureId: 1, Content: json.RawMessage(`{"city": "{{param.city}}"}`)}}, 3)
+	s := NewService(Deps{BannerRepo: repo, Notifier: notifier.NewHub(), SnapshotWait: time.Hour})
+
+	snapshot, err := s.GetSnapshot(context.Background(), 1, nil, models.TemplateVars{Param: map[string]string{"city": "Omsk"}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city": "Omsk"}`, string(snapshot.Banners[1]))
+
+	_, err = s.GetSnapshot(context.Background(), 1, nil, models.TemplateVars{Strict: true})
+	assert.ErrorIs(t, err, template.ErrMissingVariable)
+}
+
+func TestGetSnapshotNotModified(t *testing.T) {
+	repo := &snapshotRepository{revision: 3}
+	s := NewService(Deps{BannerRepo: repo, Notifier: notifier.NewHub(), SnapshotWait: 20 * time.Millisecond})
+
+	since := uint64(3)
+	_, err := s.GetSnapshot(context.Background(), 1, &since, models.TemplateVars{})
+	assert.ErrorIs(t, err, repository.ErrNotModified)
+}
+
+func TestGetSnapshotEndsWaitOnShutdown(t *testing.T) {
+	repo := &snapshotRepository{revision: 3}
+	s := NewService(Deps{BannerRepo: repo, Notifier: notifier.NewHub(), SnapshotWait: time.Hour})
+	shutdown := make(chan struct{})
+	close(shutdown)
+	ctx := reqctx.SetShutdown(context.Background(), shutdown)
+
+	since := uint64(3)
+	_, err := s.GetSnapshot(ctx, 1, &since, models.TemplateVars{})
+	assert.ErrorIs(t, err, repository.ErrNotModified, "the client polls again instead of holding the drain")
+}
+
+// signalNotifier hands out a single tag subscription and reports when it
+// is taken.
+type signalNotifier struct {
+	Notifier
+	events     chan uint64
+	subscribed chan struct{}
+}
+
+func (n *signalNotifier) SubscribeTag(_ uint64) (<-chan uint64, func()) {
+	close(n.subscribed)
+	return n.events, func() {}
+}
+
+func TestGetSnapshotWaitsForChange(t *testing.T) {
+	repo := &snapshotRepository{revision: 3}
+	tags := &signalNotifier{events: make(chan uint64, 1), subscribed: make(chan struct{})}
+	s := NewService(Deps{BannerRepo: repo, Notifier: tags, SnapshotWait: time.Hour})
+
+	go func() {
+		<-tags.subscribed
+		repo.set([]models.FeatureContent{{FeatureId: 2, Content: json.RawMessage(`{}`)}}, 4)
+		tags.events <- 4
+	}()
+
+	since := uint64(3)
+	snapshot, err := s.GetSnapshot(context.Background(), 1, &since, models.TemplateVars{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), snapshot.Revision)
+	assert.Contains(t, snapshot.Banners, uint64(2))
+}
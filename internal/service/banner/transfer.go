@@ -0,0 +1,248 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"banner-service/internal/schema"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+const (
+	importChunkSize = 500
+	// MaxImportLineSize bounds a single NDJSON line of the import.
+	MaxImportLineSize = 16 << 20
+)
+
+func (s *Service) ExportBanners(ctx context.Context, fn func(models.BannerExport) error) error {
+	return s.BannerRepo.ExportBanners(ctx, fn)
+}
+
+// ImportBanners reads NDJSON banners in the export format and applies them in
+// chunks, each chunk in its own transaction. Lines that fail validation are
+// reported and skipped; they do not affect the other lines.
+func (s *Service) ImportBanners(ctx context.Context, r io.Reader, opts models.ImportOptions) (models.ImportReport, error) {
+	report := models.ImportReport{Mode: opts.Mode, DryRun: opts.DryRun, Lines: make([]models.ImportLineResult, 0)}
+	if opts.Mode != models.ImportCreate && opts.Mode != models.ImportUpsert {
+		return report, fmt.Errorf("%w: unknown import mode %q", repository.ErrInvalidImport, opts.Mode)
+	}
+
+	var (
+		chunk      []models.BannerExport
+		chunkLines []uint64
+		pairLines  = make(map[models.FeatureTag]uint64)
+		idLines    = make(map[uint64]uint64)
+		// The schema versions the lines of the chunk are checked against.
+		versions = make(models.SchemaVersions)
+	)
+
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+
+		results, affected, err := s.BannerRepo.ImportBanners(ctx, chunk, opts, versions)
+		if errors.Is(err, repository.ErrAlreadyExists) {
+			results = make([]models.ImportLineResult, len(chunk))
+			for i := range results {
+				results[i] = models.ImportLineResult{Status: models.ImportFailed, Error: "chunk rolled back: " + err.Error()}
+			}
+		} else if err != nil {
+			return err
+		}
+
+		for i, result := range results {
+			result.Line = chunkLines[i]
+			report.Add(result)
+		}
+		s.evict(affected)
+
+		chunk, chunkLines = chunk[:0], chunkLines[:0]
+		clear(versions)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), MaxImportLineSize)
+	for line := uint64(1); scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		banner, err := parseImportLine(text, opts.Mode)
+		if err == nil {
+			if err = s.checkImportLine(ctx, &banner, versions); err != nil && !isImportLineError(err) {
+				return report, err
+			}
+		}
+		if err == nil {
+			err = claimImportKeys(banner, line, opts.Mode, pairLines, idLines)
+		}
+		if err != nil {
+			report.Add(models.ImportLineResult{Line: line, Status: models.ImportInvalid, Error: err.Error()})
+			continue
+		}
+
+		chunk = append(chunk, banner)
+		chunkLines = append(chunkLines, line)
+		if len(chunk) == importChunkSize {
+			if err = flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("%w: line is longer than %d bytes", repository.ErrInvalidImport, MaxImportLineSize)
+		}
+		return report, err
+	}
+	if err := flush(); err != nil {
+		return report, err
+	}
+
+	sort.Slice(report.Lines, func(i, j int) bool { return report.Lines[i].Line < report.Lines[j].Line })
+	return report, nil
+}
+
+// parseImportLine decodes and validates a line. Missing timestamps default to
+// the current time and a missing active_version to the latest version.
+func parseImportLine(text []byte, mode models.ImportMode) (models.BannerExport, error) {
+	var banner models.BannerExport
+	if err := json.Unmarshal(text, &banner); err != nil {
+		return banner, fmt.Errorf("invalid json: %v", err)
+	}
+
+	if mode == models.ImportCreate {
+		banner.BannerId = 0
+	}
+	if banner.FeatureId == 0 {
+		return banner, errors.New("feature_id is required")
+	}
+	if len(banner.TagIds) == 0 {
+		return banner, errors.New("tag_ids must not be empty")
+	}
+	tags := make(map[uint64]struct{}, len(banner.TagIds))
+	for _, tagId := range banner.TagIds {
+		if tagId == 0 {
+			return banner, errors.New("tag_ids must be positive")
+		}
+		if _, ok := tags[tagId]; ok {
+			return banner, fmt.Errorf("tag %d is repeated", tagId)
+		}
+		tags[tagId] = struct{}{}
+	}
+
+	if banner.FrequencyCap != nil && banner.FrequencyCap.Limit == 0 {
+		banner.FrequencyCap = nil
+	} else if banner.FrequencyCap != nil && banner.FrequencyCap.WindowSeconds == 0 {
+		return banner, errors.New("frequency_cap.window_seconds must be positive")
+	}
+
+	if len(banner.Versions) == 0 {
+		return banner, errors.New("versions must not be empty")
+	}
+	now := time.Now().UTC()
+	versions := make(map[uint64]struct{}, len(banner.Versions))
+	var latest uint64
+	for i, version := range banner.Versions {
+		if version.Version == 0 {
+			return banner, errors.New("versions must be positive")
+		}
+		if _, ok := versions[version.Version]; ok {
+			return banner, fmt.Errorf("version %d is repeated", version.Version)
+		}
+		versions[version.Version] = struct{}{}
+		latest = max(latest, version.Version)
+
+		content := bytes.TrimSpace(version.Content)
+		if len(content) == 0 || content[0] != '{' {
+			return banner, fmt.Errorf("content of version %d must be an object", version.Version)
+		}
+
+		if version.UpdatedAt.IsZero() {
+			banner.Versions[i].UpdatedAt = now
+		} else {
+			banner.Versions[i].UpdatedAt = version.UpdatedAt.UTC()
+		}
+	}
+
+	if banner.ActiveVersion == 0 {
+		banner.ActiveVersion = latest
+	} else if _, ok := versions[banner.ActiveVersion]; !ok {
+		return banner, fmt.Errorf("active_version %d is not among versions", banner.ActiveVersion)
+	}
+
+	if banner.CreatedAt.IsZero() {
+		banner.CreatedAt = now
+	} else {
+		banner.CreatedAt = banner.CreatedAt.UTC()
+	}
+
+	return banner, nil
+}
+
+// checkImportLine runs the checks a created banner goes through: the feature
+// and tags are checked against the catalog, every version against the
+// supported locales and the uploaded assets, and the active version against
+// the schema of the feature in force. Older versions are not checked against
+// the schema, they may predate it.
+func (s *Service) checkImportLine(ctx context.Context, banner *models.BannerExport, versions models.SchemaVersions) error {
+	if err := s.checkReferences(ctx, &banner.FeatureId, banner.TagIds); err != nil {
+		return err
+	}
+	for i := range banner.Versions {
+		version := &banner.Versions[i]
+		if err := s.checkLocales(version.Localized, &version.DefaultLocale); err != nil {
+			return fmt.Errorf("version %d: %w", version.Version, err)
+		}
+		if err := s.checkAssets(ctx, version.Content, version.Localized); err != nil {
+			return fmt.Errorf("version %d: %w", version.Version, err)
+		}
+	}
+
+	active := banner.Active()
+	if err := s.checkSchemaContent(ctx, banner.FeatureId, active.Content, active.Localized, versions); err != nil {
+		return fmt.Errorf("version %d: %w", active.Version, err)
+	}
+	return nil
+}
+
+// isImportLineError reports whether a check rejected the line rather than
+// failed.
+func isImportLineError(err error) bool {
+	return errors.As(err, new(*schema.ViolationError)) ||
+		errors.Is(err, repository.ErrInvalidReference) || errors.Is(err, repository.ErrInvalidLocale)
+}
+
+// claimImportKeys rejects a banner that repeats a feature and tag pair, or in
+// upsert mode a banner id, of an earlier line of the same import.
+func claimImportKeys(banner models.BannerExport, line uint64, mode models.ImportMode, pairLines map[models.FeatureTag]uint64, idLines map[uint64]uint64) error {
+	if mode == models.ImportUpsert && banner.BannerId != 0 {
+		if prev, ok := idLines[banner.BannerId]; ok {
+			return fmt.Errorf("banner %d is already imported on line %d", banner.BannerId, prev)
+		}
+	}
+	for _, tagId := range banner.TagIds {
+		pair := models.FeatureTag{FeatureId: banner.FeatureId, TagId: tagId}
+		if prev, ok := pairLines[pair]; ok {
+			return fmt.Errorf("feature %d and tag %d are already imported on line %d", pair.FeatureId, pair.TagId, prev)
+		}
+	}
+
+	if mode == models.ImportUpsert && banner.BannerId != 0 {
+		idLines[banner.BannerId] = line
+	}
+	for _, tagId := range banner.TagIds {
+		pairLines[models.FeatureTag{FeatureId: banner.FeatureId, TagId: tagId}] = line
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"errors"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+// importRepository creates every banner it is given, or fails the whole chunk
+// with err.
+type importRepository struct {
+	Repository
+	chunks [][]models.BannerExport
+	err    error
+}
+
+func (r *importRepository) ImportBanners(_ context.Context, banners []models.BannerExport, _ models.ImportOptions, _ models.SchemaVersions) ([]models.ImportLineResult, []models.FeatureTag, error) {
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	r.chunks = append(r.chunks, append([]models.BannerExport(nil), banners...))
+
+	results := make([]models.ImportLineResult, 0, len(banners))
+	var affected []models.FeatureTag
+	for i, banner := range banners {
+		results = append(results, models.ImportLineResult{Status: models.ImportCreated, BannerId: uint64(100 + i)})
+		affected = append(affected, featureTagsOf(banner)...)
+	}
+	return results, affected, nil
+}
+
+func featureTagsOf(banner models.BannerExport) []models.FeatureTag {
+	pairs := make([]models.FeatureTag, 0, len(banner.TagIds))
+	for _, tagId := range banner.TagIds {
+		pairs = append(pairs, models.FeatureTag{FeatureId: banner.FeatureId, TagId: tagId})
+	}
+	return pairs
+}
+
+func TestImportBanners(t *testing.T) {
+	input := strings.Join([]string{
+		`{"banner_id": 7, "feature_id": 1, "tag_ids": [1, 2], "versions": [{"version": 1, "content": {"title": "a"}}, {"version": 2, "content": {"title": "b"}}]}`,
+		`not json`,
+		``,
+		`{"feature_id": 1, "tag_ids": [2], "versions": [{"version": 1, "content": {}}]}`,
+		`{"feature_id": 2, "tag_ids": [1], "versions": [{"version": 1, "content": "text"}]}`,
+		`{"feature_id": 2, "tag_ids": [1], "active_version": 3, "versions": [{"version": 1, "content": {}}]}`,
+		`{"feature_id": 3, "tag_ids": [1], "versions": [{"version": 1, "content": {}}]}`,
+	}, "\n")
+
+	repo := &importRepository{}
+	cache := ttlcache.New[models.BannerCacheKey, models.BannerContent]()
+	cache.Set(models.BannerCacheKey{FeatureId: 1, TagId: 2}, models.BannerContent{Content: "{}"}, ttlcache.DefaultTTL)
+	s := NewService(Deps{BannerRepo: repo, Cache: cache})
+
+	report, err := s.ImportBanners(context.Background(), strings.NewReader(input), models.ImportOptions{Mode: models.ImportCreate})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(2), report.Created)
+	assert.Equal(t, uint64(4), report.Failed)
+
+	statuses := make(map[uint64]models.ImportStatus)
+	for _, line := range report.Lines {
+		statuses[line.Line] = line.Status
+	}
+	assert.Equal(t, map[uint64]models.ImportStatus{
+		1: models.ImportCreated,
+		2: models.ImportInvalid,
+		4: models.ImportInvalid, // feature 1 and tag 2 are taken by line 1
+		5: models.ImportInvalid,
+		6: models.ImportInvalid,
+		7: models.ImportCreated,
+	}, statuses)
+
+	require.Len(t, repo.chunks, 1)
+	first := repo.chunks[0][0]
+	assert.Zero(t, first.BannerId, "create mode ignores banner ids")
+	assert.Equal(t, uint64(2), first.ActiveVersion, "the latest version is active by default")
+	assert.False(t, first.CreatedAt.IsZero())
+
+	assert.False(t, cache.Has(models.BannerCacheKey{FeatureId: 1, TagId: 2}), "imported keys are evicted")
+}
+
+func TestImportBannersRolledBackChunk(t *testing.T) {
+	repo := &importRepository{err: repository.ErrAlreadyExists}
+	s := NewService(Deps{BannerRepo: repo, Cache: ttlcache.New[models.BannerCacheKey, models.BannerContent]()})
+
+	input := `{"feature_id": 1, "tag_ids": [1], "versions": [{"version": 1, "content": {}}]}`
+	report, err := s.ImportBanners(context.Background(), strings.NewReader(input), models.ImportOptions{Mode: models.ImportUpsert})
+	require.NoError(t, err)
+	require.Len(t, report.Lines, 1)
+	assert.Equal(t, models.ImportFailed, report.Lines[0].Status)
+
+	repo.err = errors.New("connection reset")
+	_, err = s.ImportBanners(context.Background(), strings.NewReader(input), models.ImportOptions{Mode: models.ImportUpsert})
+	assert.Error(t, err)
+}
+
+func TestImportBannersUnknownMode(t *testing.T) {
+	s := NewService(Deps{BannerRepo: &importRepository{}})
+
+	_, err := s.ImportBanners(context.Background(), strings.NewReader(""), models.ImportOptions{Mode: "merge"})
+	assert.ErrorIs(t, err, repository.ErrInvalidImport)
+}
+
+func TestImportBannersChecksLines(t *testing.T) {
+	missing := strings.Repeat("b", 64)
+	input := strings.Join([]string{
+		// An older version may predate the schema.
+		`{"feature_id": 1, "tag_ids": [1], "versions": [{"version": 1, "content": {}}, {"version": 2, "content": {"title": "a"}}]}`,
+		`{"feature_id": 2, "tag_ids": [3], "versions": [{"version": 1, "content": {"title": "a"}}]}`,
+		`{"feature_id": 1, "tag_ids": [4], "versions": [{"version": 1, "content": {"title": ""}}]}`,
+		`{"feature_id": 1, "tag_ids": [5], "versions": [{"version": 1, "content": {"title": "a"}, "localized": {"de": {"title": "a"}}}]}`,
+		`{"feature_id": 1, "tag_ids": [6], "active_version": 2, "versions": [{"version": 1, "content": {"image": "/assets/` + missing + `"}}, {"version": 2, "content": {"title": "a"}}]}`,
+		// The pair of a rejected line stays free.
+		`{"feature_id": 1, "tag_ids": [3], "versions": [{"version": 1, "content": {"title": "a"}, "default_locale": "EN"}]}`,
+	}, "\n")
+
+	repo := &importRepository{}
+	s := NewService(Deps{
+		BannerRepo: repo,
+		Cache:      ttlcache.New[models.BannerCacheKey, models.BannerContent](),
+		Catalog:    newCatalog(),
+		Schemas:    newSchemas(),
+		Assets:     memoryAssets{},
+		Locales:    []string{"en", "ru"},
+	})
+
+	report, err := s.ImportBanners(context.Background(), strings.NewReader(input), models.ImportOptions{Mode: models.ImportCreate})
+	require.NoError(t, err)
+
+	errs := make(map[uint64]string)
+	for _, line := range report.Lines {
+		errs[line.Line] = line.Error
+	}
+	assert.Empty(t, errs[1])
+	assert.Contains(t, errs[2], "feature 2 is archived")
+	assert.Contains(t, errs[3], "version 1")
+	assert.Contains(t, errs[4], `"de" is not one of the supported locales`)
+	assert.Contains(t, errs[5], missing)
+	assert.Empty(t, errs[6])
+	assert.Equal(t, uint64(2), report.Created)
+
+	require.Len(t, repo.chunks, 1)
+	require.Len(t, repo.chunks[0], 2)
+	assert.Equal(t, "en", repo.chunks[0][1].Versions[0].DefaultLocale, "the default locale is normalized")
+}
+
+type failingCatalog struct {
+	Catalog
+}
+
+func (failingCatalog) GetFeatures(_ context.Context, _ *models.CatalogFilter) ([]models.Feature, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestImportBannersCheckFailure(t *testing.T) {
+	s := NewService(Deps{BannerRepo: &importRepository{}, Catalog: failingCatalog{}})
+
+	input := `{"feature_id": 1, "tag_ids": [1], "versions": [{"version": 1, "content": {}}]}`
+	_, err := s.ImportBanners(context.Background(), strings.NewReader(input), models.ImportOptions{Mode: models.ImportCreate})
+	assert.Error(t, err, "a failing catalog fails the import rather than the line")
+}
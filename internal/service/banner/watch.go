@@ -0,0 +1,91 @@
+package banner
+
+import (
+	"banner-service/internal/locale"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"errors"
+	"log"
+)
+
+// WatchBanner streams the banner of the given feature and tag, rendered with
+// vars in the locale negotiated from languages like GetBanner, every time it
+// changes. The tag resolves through its ancestors and the default banner of
+// the feature, so every change to a banner of the feature is re-resolved and
+// sent only if the result differs from the last one sent. When lastEventId
+// is set, the current state is sent first only if a banner of the feature
+// changed after that event.
+func (s *Service) WatchBanner(ctx context.Context, tagId uint64, featureId uint64, role models.UserRole, lastEventId *uint64, vars models.TemplateVars, languages string) (<-chan models.BannerUpdate, error) {
+	locales := locale.Rank(languages, s.Locales)
+	events, unsubscribe := s.Notifier.SubscribeFeature(featureId)
+
+	var after uint64
+	if lastEventId != nil {
+		after = *lastEventId
+	}
+	eventId, err := s.BannerRepo.GetLatestFeatureEventId(ctx, featureId, after)
+	if err != nil {
+		unsubscribe()
+		return nil, err
+	}
+	sendInitial := lastEventId == nil || eventId != 0
+
+	updates := make(chan models.BannerUpdate, 1)
+	go func() {
+		defer close(updates)
+		defer unsubscribe()
+
+		var last *models.BannerUpdate
+		if sendInitial && !s.pushUpdate(ctx, updates, &last, eventId, tagId, featureId, role, vars, locales) {
+			return
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case eventId := <-events:
+				if !s.pushUpdate(ctx, updates, &last, eventId, tagId, featureId, role, vars, locales) {
+					return
+				}
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+// pushUpdate sends the current state of the banner unless it is the state last
+// sent. Content that cannot be rendered, such as a template missing a
+// variable in strict mode, is skipped like any other failed lookup; the next
+// change is sent again.
+func (s *Service) pushUpdate(ctx context.Context, updates chan<- models.BannerUpdate, last **models.BannerUpdate, eventId, tagId, featureId uint64, role models.UserRole, vars models.TemplateVars, locales []string) bool {
+	update := models.BannerUpdate{EventId: eventId, Status: models.BannerAvailable}
+
+	content, err := s.resolveBanner(ctx, []uint64{tagId}, featureId, locales, role, true)
+	if err == nil {
+		content.Content, err = s.render(content.Content, vars)
+	}
+	if errors.Is(err, repository.ErrBannerInactive) {
+		update.Status = models.BannerInactive
+	} else if errors.Is(err, repository.ErrNotFound) {
+		update.Status = models.BannerNotFound
+	} else if err != nil {
+		log.Printf("watch banner: %v", err)
+		return ctx.Err() == nil
+	} else {
+		update.Content = content.Content
+	}
+	if *last != nil && (*last).Status == update.Status && (*last).Content == update.Content {
+		return true
+	}
+
+	select {
+	case updates <- update:
+		*last = &update
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,174 @@
+package banner
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/notifier"
+	"banner-service/internal/repository"
+	"banner-service/internal/template"
+	"context"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchRepository serves a single banner that tests change under a lock.
+type watchRepository struct {
+	Repository
+	mu            sync.Mutex
+	content       *models.BannerContent
+	latestEventId uint64
+}
+
+func (r *watchRepository) GetBanner(_ context.Context, _, _ uint64, _ []string, isAdmin bool, _ []uint64) (models.BannerContent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.content == nil {
+		return models.BannerContent{}, repository.ErrNotFound
+	} else if !r.content.IsActive && !isAdmin {
+		return models.BannerContent{}, repository.ErrBannerInactive
+	}
+	return *r.content, nil
+}
+
+func (r *watchRepository) GetLatestFeatureEventId(_ context.Context, _, afterEventId uint64) (uint64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.latestEventId <= afterEventId {
+		return 0, nil
+	}
+	return r.latestEventId, nil
+}
+
+func (r *watchRepository) set(content *models.BannerContent, eventId uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.content = content
+	r.latestEventId = eventId
+}
+
+func nextUpdate(t *testing.T, updates <-chan models.BannerUpdate) models.BannerUpdate {
+	t.Helper()
+
+	select {
+	case update, ok := <-updates:
+		require.True(t, ok, "the stream is closed")
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("no update")
+		return models.BannerUpdate{}
+	}
+}
+
+func newWatchService(repo *watchRepository, hub *notifier.Hub) *Service {
+	return NewService(Deps{BannerRepo: repo, Notifier: hub, Cache: ttlcache.New[models.BannerCacheKey, models.BannerContent]()})
+}
+
+func TestWatchBanner(t *testing.T) {
+	repo := &watchRepository{}
+	repo.set(&models.BannerContent{Content: `{"title": "first"}`, IsActive: true, TagId: 1, BannerId: 1}, 5)
+	hub := notifier.NewHub()
+	s := newWatchService(repo, hub)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := s.WatchBanner(ctx, 1, 1, models.Client, nil, models.TemplateVars{}, "")
+	require.NoError(t, err)
+
+	update := nextUpdate(t, updates)
+	assert.Equal(t, models.BannerAvailable, update.Status)
+	assert.Equal(t, uint64(5), update.EventId)
+	assert.JSONEq(t, `{"title": "first"}`, update.Content)
+
+	repo.set(&models.BannerContent{Content: `{"title": "second"}`, IsActive: false, TagId: 1, BannerId: 1}, 6)
+	hub.Publish(models.BannerNotification{EventId: 6, FeatureIds: []uint64{1}, TagIds: []uint64{1}})
+	update = nextUpdate(t, updates)
+	assert.Equal(t, models.BannerUpdate{EventId: 6, Status: models.BannerInactive}, update)
+
+	repo.set(nil, 7)
+	hub.Publish(models.BannerNotification{EventId: 7, FeatureIds: []uint64{1}, TagIds: []uint64{1}})
+	update = nextUpdate(t, updates)
+	assert.Equal(t, models.BannerUpdate{EventId: 7, Status: models.BannerNotFound}, update)
+
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-updates
+		return !ok
+	}, time.Second, 10*time.Millisecond, "the stream closes with the context")
+}
+
+func TestWatchBannerFollowsFallbacks(t *testing.T) {
+	repo := &watchRepository{}
+	repo.set(&models.BannerContent{Content: `{"title": "parent"}`, IsActive: true, TagId: 2, BannerId: 2, Depth: 1}, 5)
+	hub := notifier.NewHub()
+	s := newWatchService(repo, hub)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := s.WatchBanner(ctx, 1, 1, models.Client, nil, models.TemplateVars{}, "")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title": "parent"}`, nextUpdate(t, updates).Content)
+
+	repo.set(&models.BannerContent{Content: `{"title": "default"}`, IsActive: true, BannerId: 3}, 6)
+	hub.Publish(models.BannerNotification{EventId: 6, FeatureIds: []uint64{1}, TagIds: []uint64{2}})
+	update := nextUpdate(t, updates)
+	assert.Equal(t, uint64(6), update.EventId, "a change to the banner of the parent tag is sent")
+	assert.JSONEq(t, `{"title": "default"}`, update.Content)
+
+	hub.Publish(models.BannerNotification{EventId: 7, FeatureIds: []uint64{1}, TagIds: []uint64{5}})
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected update %+v: the result did not change", update)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchBannerResumes(t *testing.T) {
+	repo := &watchRepository{}
+	repo.set(&models.BannerContent{Content: `{"title": "first"}`, IsActive: true, TagId: 1, BannerId: 1}, 5)
+	hub := notifier.NewHub()
+	s := newWatchService(repo, hub)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lastEventId := uint64(5)
+	updates, err := s.WatchBanner(ctx, 1, 1, models.Client, &lastEventId, models.TemplateVars{}, "")
+	require.NoError(t, err)
+
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected update %+v: nothing changed after the last event", update)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	repo.set(&models.BannerContent{Content: `{"title": "second"}`, IsActive: true, TagId: 1, BannerId: 1}, 6)
+	hub.Publish(models.BannerNotification{EventId: 6, FeatureIds: []uint64{1}, TagIds: []uint64{1}})
+	update := nextUpdate(t, updates)
+	assert.Equal(t, uint64(6), update.EventId)
+	assert.JSONEq(t, `{"title": "second"}`, update.Content)
+
+	lastEventId = 4
+	missed, err := s.WatchBanner(ctx, 1, 1, models.Client, &lastEventId, models.TemplateVars{}, "")
+	require.NoError(t, err)
+	update = nextUpdate(t, missed)
+	assert.Equal(t, uint64(6), update.EventId, "a change missed while disconnected is sent first")
+}
+
+func TestWatchBannerRendersTemplates(t *testing.T) {
+	repo := &watchRepository{}
+	repo.set(&models.BannerContent{Content: `{"title": "Hi, {{user.name}}"}`, IsActive: true, TagId: 1, BannerId: 1}, 5)
+	s := newWatchService(repo, notifier.NewHub())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := models.TemplateVars{User: map[string]string{"name": `"Ann"`}}
+	updates, err := s.WatchBanner(ctx, 1, 1, models.Client, nil, vars, "")
+	require.NoError(t, err)
+	update := nextUpdate(t, updates)
+	assert.JSONEq(t, `{"title": "Hi, \"Ann\""}`, update.Content)
+
+	_, err = s.GetBanner(ctx, []uint64{1}, 1, models.Client, false, models.TemplateVars{Strict: true}, "")
+	assert.ErrorIs(t, err, template.ErrMissingVariable, "the cache keeps the template, not the rendered content")
+}
@@ -0,0 +1,177 @@
+package catalog
+
+import (
+	"banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"banner-service/internal/schema"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type Repository interface {
+	CreateFeature(ctx context.Context, feature *models.Feature) (uint64, error)
+	GetFeatures(ctx context.Context, filter *models.CatalogFilter) ([]models.Feature, error)
+	GetFeature(ctx context.Context, featureId uint64) (models.Feature, error)
+	UpdateFeature(ctx context.Context, featureId uint64, patch *models.PatchFeature) error
+	DeleteFeature(ctx context.Context, featureId uint64) error
+	CreateTag(ctx context.Context, tag *models.Tag) (uint64, error)
+	GetTags(ctx context.Context, filter *models.CatalogFilter) ([]models.Tag, error)
+	GetTag(ctx context.Context, tagId uint64) (models.Tag, error)
+	UpdateTag(ctx context.Context, tagId uint64, patch *models.PatchTag) error
+	DeleteTag(ctx context.Context, tagId uint64) error
+	CreateFeatureSchema(ctx context.Context, featureId uint64, schema json.RawMessage) (models.FeatureSchema, error)
+	GetFeatureSchema(ctx context.Context, featureId, version uint64) (models.FeatureSchema, error)
+	GetFeatureSchemas(ctx context.Context, featureId uint64) ([]models.FeatureSchema, error)
+}
+
+// BannerCache holds the banners resolved for features and tags, which a
+// catalog change can make stale.
+type BannerCache interface {
+	EvictFeature(featureId uint64)
+	EvictAll()
+}
+
+type Deps struct {
+	CatalogRepo Repository
+	BannerCache BannerCache
+}
+
+type Service struct {
+	Deps
+}
+
+func NewService(d Deps) *Service {
+	return &Service{
+		Deps: d,
+	}
+}
+
+var _ http.CatalogManagement = (*Service)(nil)
+
+func (s *Service) CreateFeature(ctx context.Context, feature *models.Feature) (models.Feature, error) {
+	featureId, err := s.CatalogRepo.CreateFeature(ctx, feature)
+	if err != nil {
+		return models.Feature{}, err
+	}
+	return s.CatalogRepo.GetFeature(ctx, featureId)
+}
+
+func (s *Service) GetFeatures(ctx context.Context, filter *models.CatalogFilter) ([]models.Feature, error) {
+	if features, err := s.CatalogRepo.GetFeatures(ctx, filter); err != nil {
+		return nil, err
+	} else {
+		return features, nil
+	}
+}
+
+func (s *Service) GetFeature(ctx context.Context, featureId uint64) (models.Feature, error) {
+	if feature, err := s.CatalogRepo.GetFeature(ctx, featureId); err != nil {
+		return models.Feature{}, err
+	} else {
+		return feature, nil
+	}
+}
+
+// UpdateFeature changes the feature; a change of its default banner evicts
+// its resolved banners.
+func (s *Service) UpdateFeature(ctx context.Context, featureId uint64, patch *models.PatchFeature) error {
+	if err := s.CatalogRepo.UpdateFeature(ctx, featureId, patch); err != nil {
+		return err
+	}
+	if patch.DefaultBannerId != nil {
+		s.BannerCache.EvictFeature(featureId)
+	}
+	return nil
+}
+
+func (s *Service) DeleteFeature(ctx context.Context, featureId uint64) error {
+	if err := s.CatalogRepo.DeleteFeature(ctx, featureId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) CreateTag(ctx context.Context, tag *models.Tag) (models.Tag, error) {
+	tagId, err := s.CatalogRepo.CreateTag(ctx, tag)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	return s.CatalogRepo.GetTag(ctx, tagId)
+}
+
+func (s *Service) GetTags(ctx context.Context, filter *models.CatalogFilter) ([]models.Tag, error) {
+	if tags, err := s.CatalogRepo.GetTags(ctx, filter); err != nil {
+		return nil, err
+	} else {
+		return tags, nil
+	}
+}
+
+func (s *Service) GetTag(ctx context.Context, tagId uint64) (models.Tag, error) {
+	if tag, err := s.CatalogRepo.GetTag(ctx, tagId); err != nil {
+		return models.Tag{}, err
+	} else {
+		return tag, nil
+	}
+}
+
+// UpdateTag changes the tag; a change of its parent evicts every resolved
+// banner, as the tag and its descendants fall back to other banners.
+func (s *Service) UpdateTag(ctx context.Context, tagId uint64, patch *models.PatchTag) error {
+	if err := s.CatalogRepo.UpdateTag(ctx, tagId, patch); err != nil {
+		return err
+	}
+	if patch.ParentTagId != nil {
+		s.BannerCache.EvictAll()
+	}
+	return nil
+}
+
+func (s *Service) DeleteTag(ctx context.Context, tagId uint64) error {
+	if err := s.CatalogRepo.DeleteTag(ctx, tagId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SetFeatureSchema adds a schema version to the feature. Banners that do not
+// conform to it are kept; see the schema report.
+func (s *Service) SetFeatureSchema(ctx context.Context, featureId uint64, raw json.RawMessage) (models.FeatureSchema, error) {
+	if _, err := schema.Compile(raw); err != nil {
+		return models.FeatureSchema{}, err
+	}
+	return s.CatalogRepo.CreateFeatureSchema(ctx, featureId, raw)
+}
+
+// DeleteFeatureSchema adds a version without a schema, which turns validation
+// off while keeping the history.
+func (s *Service) DeleteFeatureSchema(ctx context.Context, featureId uint64) (models.FeatureSchema, error) {
+	if _, err := s.GetFeatureSchema(ctx, featureId, 0); err != nil {
+		return models.FeatureSchema{}, err
+	}
+	return s.CatalogRepo.CreateFeatureSchema(ctx, featureId, nil)
+}
+
+// GetFeatureSchema returns a schema version, or the one in force if version is
+// zero.
+func (s *Service) GetFeatureSchema(ctx context.Context, featureId, version uint64) (models.FeatureSchema, error) {
+	featureSchema, err := s.CatalogRepo.GetFeatureSchema(ctx, featureId, version)
+	if err != nil {
+		return models.FeatureSchema{}, err
+	} else if version == 0 && featureSchema.Schema == nil {
+		return models.FeatureSchema{}, fmt.Errorf("%w: schema of feature %d was removed", repository.ErrNotFound, featureId)
+	}
+	return featureSchema, nil
+}
+
+func (s *Service) GetFeatureSchemas(ctx context.Context, featureId uint64) ([]models.FeatureSchema, error) {
+	schemas, err := s.CatalogRepo.GetFeatureSchemas(ctx, featureId)
+	if err != nil {
+		return nil, err
+	} else if len(schemas) == 0 {
+		return nil, fmt.Errorf("%w: feature %d has no schema", repository.ErrNotFound, featureId)
+	}
+	return schemas, nil
+}
@@ -0,0 +1,58 @@
+package catalog
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type updateRepository struct {
+	Repository
+}
+
+func (r *updateRepository) UpdateFeature(_ context.Context, _ uint64, _ *models.PatchFeature) error {
+	return nil
+}
+
+func (r *updateRepository) UpdateTag(_ context.Context, _ uint64, _ *models.PatchTag) error {
+	return nil
+}
+
+// recordingCache records the evictions.
+type recordingCache struct {
+	features []uint64
+	all      int
+}
+
+func (c *recordingCache) EvictFeature(featureId uint64) {
+	c.features = append(c.features, featureId)
+}
+
+func (c *recordingCache) EvictAll() {
+	c.all++
+}
+
+func TestUpdateFeatureEvictsOnDefaultBanner(t *testing.T) {
+	cache := &recordingCache{}
+	s := NewService(Deps{CatalogRepo: &updateRepository{}, BannerCache: cache})
+
+	require.NoError(t, s.UpdateFeature(context.Background(), 1, &models.PatchFeature{}))
+	assert.Empty(t, cache.features, "a feature keeps its banners without a new default")
+
+	require.NoError(t, s.UpdateFeature(context.Background(), 1, &models.PatchFeature{DefaultBannerId: lo.ToPtr(uint64(2))}))
+	assert.Equal(t, []uint64{1}, cache.features)
+}
+
+func TestUpdateTagEvictsOnParent(t *testing.T) {
+	cache := &recordingCache{}
+	s := NewService(Deps{CatalogRepo: &updateRepository{}, BannerCache: cache})
+
+	require.NoError(t, s.UpdateTag(context.Background(), 1, &models.PatchTag{}))
+	assert.Zero(t, cache.all, "a tag keeps the banners without a new parent")
+
+	require.NoError(t, s.UpdateTag(context.Background(), 1, &models.PatchTag{ParentTagId: lo.ToPtr(uint64(0))}))
+	assert.Equal(t, 1, cache.all)
+}
@@ -0,0 +1,93 @@
+package event
+
+import (
+	"banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaxEvents bounds the number of events of a single report.
+const MaxEvents = 100
+
+// Buffer queues events to be written later.
+type Buffer interface {
+	Add(events []models.BannerEvent) int
+}
+
+type Repository interface {
+	GetBannerStats(ctx context.Context, bannerId uint64, filter *models.StatsFilter) (models.BannerStats, error)
+}
+
+type Deps struct {
+	EventRepo Repository
+	Buffer    Buffer
+}
+
+type Service struct {
+	Deps
+}
+
+func NewService(d Deps) *Service {
+	return &Service{
+		Deps: d,
+	}
+}
+
+var _ http.EventManagement = (*Service)(nil)
+
+// TrackEvents stamps the events with the time of receipt and queues them.
+// Events of a banner version that does not exist are dropped when the buffer
+// writes them, so a report does not wait for the database.
+func (s *Service) TrackEvents(ctx context.Context, events []models.BannerEvent) (models.EventReceipt, error) {
+	if len(events) == 0 || len(events) > MaxEvents {
+		return models.EventReceipt{}, fmt.Errorf("%w: a report holds from 1 to %d events", repository.ErrInvalidEvent, MaxEvents)
+	}
+
+	now := time.Now().UTC()
+	for i := range events {
+		event := &events[i]
+		if event.Kind != models.EventImpression && event.Kind != models.EventClick {
+			return models.EventReceipt{}, fmt.Errorf("%w: event %d has unknown kind %q", repository.ErrInvalidEvent, i, event.Kind)
+		}
+		if event.BannerId == 0 || event.Version == 0 {
+			return models.EventReceipt{}, fmt.Errorf("%w: event %d has no banner id or version", repository.ErrInvalidEvent, i)
+		}
+		event.OccurredAt = now
+	}
+
+	accepted := s.Buffer.Add(events)
+	return models.EventReceipt{Accepted: accepted, Dropped: len(events) - accepted}, nil
+}
+
+// GetBannerStats counts the impressions and clicks of the banner in total,
+// per version and per day, with their CTR.
+func (s *Service) GetBannerStats(ctx context.Context, bannerId uint64, filter *models.StatsFilter) (models.BannerStats, error) {
+	stats, err := s.EventRepo.GetBannerStats(ctx, bannerId, filter)
+	if err != nil {
+		return models.BannerStats{}, err
+	}
+
+	for i := range stats.Versions {
+		counts := &stats.Versions[i].EventCounts
+		counts.CTR = ctr(counts.Impressions, counts.Clicks)
+		stats.Total.Impressions += counts.Impressions
+		stats.Total.Clicks += counts.Clicks
+	}
+	for i := range stats.Days {
+		counts := &stats.Days[i].EventCounts
+		counts.CTR = ctr(counts.Impressions, counts.Clicks)
+	}
+	stats.Total.CTR = ctr(stats.Total.Impressions, stats.Total.Clicks)
+
+	return stats, nil
+}
+
+func ctr(impressions, clicks uint64) float64 {
+	if impressions == 0 {
+		return 0
+	}
+	return float64(clicks) / float64(impressions)
+}
@@ -0,0 +1,67 @@
+package event
+
+import (
+	"banner-service/internal/models"
+	"banner-service/internal/repository"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type countingBuffer struct {
+	capacity int
+	events   []models.BannerEvent
+}
+
+func (b *countingBuffer) Add(events []models.BannerEvent) int {
+	accepted := min(len(events), b.capacity-len(b.events))
+	b.events = append(b.events, events[:accepted]...)
+	return accepted
+}
+
+type statsRepository struct {
+	stats models.BannerStats
+}
+
+func (r statsRepository) GetBannerStats(_ context.Context, _ uint64, _ *models.StatsFilter) (models.BannerStats, error) {
+	return r.stats, nil
+}
+
+func TestTrackEvents(t *testing.T) {
+	buffer := &countingBuffer{capacity: 2}
+	s := NewService(Deps{EventRepo: statsRepository{}, Buffer: buffer})
+
+	_, err := s.TrackEvents(context.Background(), []models.BannerEvent{{Kind: "hover", BannerId: 1, Version: 1}})
+	assert.ErrorIs(t, err, repository.ErrInvalidEvent)
+	_, err = s.TrackEvents(context.Background(), []models.BannerEvent{{Kind: models.EventClick, BannerId: 1}})
+	assert.ErrorIs(t, err, repository.ErrInvalidEvent)
+	assert.Empty(t, buffer.events)
+
+	receipt, err := s.TrackEvents(context.Background(), []models.BannerEvent{
+		{Kind: models.EventImpression, BannerId: 1, Version: 1},
+		{Kind: models.EventImpression, BannerId: 1, Version: 1},
+		{Kind: models.EventClick, BannerId: 1, Version: 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.EventReceipt{Accepted: 2, Dropped: 1}, receipt)
+	assert.False(t, buffer.events[0].OccurredAt.IsZero())
+}
+
+func TestGetBannerStats(t *testing.T) {
+	s := NewService(Deps{EventRepo: statsRepository{stats: models.BannerStats{
+		BannerId: 1,
+		Versions: []models.VersionStats{
+			{Version: 1, EventCounts: models.EventCounts{Impressions: 100, Clicks: 5}},
+			{Version: 2, EventCounts: models.EventCounts{Impressions: 0, Clicks: 1}},
+		},
+		Days: []models.DayStats{{Day: "2024-05-15", EventCounts: models.EventCounts{Impressions: 100, Clicks: 6}}},
+	}}})
+
+	stats, err := s.GetBannerStats(context.Background(), 1, &models.StatsFilter{})
+	require.NoError(t, err)
+	assert.InDelta(t, 0.05, stats.Versions[0].CTR, 1e-9)
+	assert.Zero(t, stats.Versions[1].CTR, "no impressions, no CTR")
+	assert.InDelta(t, 0.06, stats.Days[0].CTR, 1e-9)
+	assert.Equal(t, models.EventCounts{Impressions: 100, Clicks: 6, CTR: 0.06}, stats.Total)
+}
@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"banner-service/internal/controller/http"
+	"banner-service/internal/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type Repository interface {
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (uint64, error)
+	GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, subscriptionId uint64) (models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, subscriptionId uint64, patch *models.PatchWebhookSubscription) error
+	DeleteSubscription(ctx context.Context, subscriptionId uint64) error
+	GetDeliveries(ctx context.Context, filter *models.DeliveryFilter) ([]models.WebhookDelivery, error)
+}
+
+type Deps struct {
+	WebhookRepo Repository
+}
+
+type Service struct {
+	Deps
+}
+
+func NewService(d Deps) *Service {
+	return &Service{
+		Deps: d,
+	}
+}
+
+var _ http.WebhookManagement = (*Service)(nil)
+
+func (s *Service) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (models.WebhookSubscription, error) {
+	if sub.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return models.WebhookSubscription{}, err
+		}
+		sub.Secret = secret
+	}
+
+	subscriptionId, err := s.WebhookRepo.CreateSubscription(ctx, sub)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+
+	created, err := s.WebhookRepo.GetSubscription(ctx, subscriptionId)
+	if err != nil {
+		return models.WebhookSubscription{}, err
+	}
+	created.Secret = sub.Secret
+	return created, nil
+}
+
+func (s *Service) GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	if subs, err := s.WebhookRepo.GetSubscriptions(ctx); err != nil {
+		return nil, err
+	} else {
+		return subs, nil
+	}
+}
+
+func (s *Service) GetSubscription(ctx context.Context, subscriptionId uint64) (models.WebhookSubscription, error) {
+	if sub, err := s.WebhookRepo.GetSubscription(ctx, subscriptionId); err != nil {
+		return models.WebhookSubscription{}, err
+	} else {
+		return sub, nil
+	}
+}
+
+func (s *Service) UpdateSubscription(ctx context.Context, subscriptionId uint64, patch *models.PatchWebhookSubscription) error {
+	if err := s.WebhookRepo.UpdateSubscription(ctx, subscriptionId, patch); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) DeleteSubscription(ctx context.Context, subscriptionId uint64) error {
+	if err := s.WebhookRepo.DeleteSubscription(ctx, subscriptionId); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *Service) GetDeliveries(ctx context.Context, filter *models.DeliveryFilter) ([]models.WebhookDelivery, error) {
+	if _, err := s.WebhookRepo.GetSubscription(ctx, filter.SubscriptionId); err != nil {
+		return nil, err
+	}
+	if deliveries, err := s.WebhookRepo.GetDeliveries(ctx, filter); err != nil {
+		return nil, err
+	} else {
+		return deliveries, nil
+	}
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
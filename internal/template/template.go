@@ -0,0 +1,155 @@
+package template
+
+import (
+	"banner-service/internal/models"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Placeholders are written in the string values of banner content:
+//
+//	{{user.name}}           claim "name" of the token
+//	{{param.city|Moscow}}   query parameter "city", "Moscow" if it is missing
+//
+// Values become part of the string value they are written in, which is
+// encoded as a JSON string again: parameters come from whoever makes the
+// request, so a quote or a backslash in a value cannot end the string. Object
+// keys are never rendered.
+const (
+	openDelim  = "{{"
+	closeDelim = "}}"
+
+	userPrefix  = "user."
+	paramPrefix = "param."
+)
+
+var ErrMissingVariable = errors.New("missing template variable")
+
+// Render substitutes the placeholders of content. A missing variable without
+// a default renders as an empty string, or fails with ErrMissingVariable in
+// strict mode. Content without placeholders is returned as is.
+func Render(content json.RawMessage, vars models.TemplateVars, strict bool) (json.RawMessage, error) {
+	if !bytes.Contains(content, []byte(openDelim)) {
+		return content, nil
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	for i := 0; i < len(content); {
+		if content[i] != '"' {
+			out.WriteByte(content[i])
+			i++
+			continue
+		}
+
+		end := stringEnd(content, i)
+		literal := content[i:end]
+		i = end
+		if isKey(content, end) || !bytes.Contains(literal, []byte(openDelim)) {
+			out.Write(literal)
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(literal, &value); err != nil {
+			return nil, err
+		}
+		rendered, err := renderString(value, vars, strict)
+		if err != nil {
+			return nil, err
+		}
+		if err = writeString(&out, rendered); err != nil {
+			return nil, err
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// renderString substitutes the placeholders of a single string value. An
+// unterminated placeholder is left as is.
+func renderString(s string, vars models.TemplateVars, strict bool) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(s, openDelim)
+		if start < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		length := strings.Index(s[start+len(openDelim):], closeDelim)
+		if length < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+
+		b.WriteString(s[:start])
+		value, err := lookup(s[start+len(openDelim):start+len(openDelim)+length], vars, strict)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		s = s[start+len(openDelim)+length+len(closeDelim):]
+	}
+}
+
+// lookup resolves "name" or "name|default".
+func lookup(expr string, vars models.TemplateVars, strict bool) (string, error) {
+	name, fallback, hasDefault := strings.Cut(expr, "|")
+	name = strings.TrimSpace(name)
+
+	var (
+		value string
+		ok    bool
+	)
+	switch {
+	case strings.HasPrefix(name, userPrefix):
+		value, ok = vars.User[strings.TrimPrefix(name, userPrefix)]
+	case strings.HasPrefix(name, paramPrefix):
+		value, ok = vars.Param[strings.TrimPrefix(name, paramPrefix)]
+	}
+
+	switch {
+	case ok:
+		return value, nil
+	case hasDefault:
+		return strings.TrimSpace(fallback), nil
+	case strict:
+		return "", fmt.Errorf("%w: %s", ErrMissingVariable, name)
+	default:
+		return "", nil
+	}
+}
+
+// stringEnd returns the index right after the string literal that starts at
+// start.
+func stringEnd(content []byte, start int) int {
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(content)
+}
+
+// isKey reports whether the string literal that ends at end is an object key.
+func isKey(content []byte, end int) bool {
+	rest := bytes.TrimLeft(content[end:], " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
+
+func writeString(out *bytes.Buffer, s string) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return err
+	}
+	out.Truncate(out.Len() - 1) // Encode appends a newline
+	return nil
+}
@@ -0,0 +1,79 @@
+package template
+
+import (
+	"banner-service/internal/models"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	vars := models.TemplateVars{
+		User:  map[string]string{"name": `"Ann" \ <b>`, "sub": "ann"},
+		Param: map[string]string{"city": "Kazan", "url": "https://example.com/?a=1&b=2"},
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		strict   bool
+		expected string
+		err      error
+	}{
+		{
+			name:     "without placeholders",
+			content:  `{"title": "sale"}`,
+			expected: `{"title": "sale"}`,
+		},
+		{
+			name:     "user claim and parameter",
+			content:  `{"title": "Hi, {{user.sub}} from {{ param.city }}!", "n": [1, "{{user.sub}}"]}`,
+			expected: `{"title": "Hi, ann from Kazan!", "n": [1, "ann"]}`,
+		},
+		{
+			name:     "URL is kept as is",
+			content:  `{"url": "{{param.url}}"}`,
+			expected: `{"url": "https://example.com/?a=1&b=2"}`,
+		},
+		{
+			name:     "default",
+			content:  `{"title": "{{param.country|Russia}}"}`,
+			expected: `{"title": "Russia"}`,
+		},
+		{
+			name:     "missing variable",
+			content:  `{"title": "[{{param.country}}]"}`,
+			expected: `{"title": "[]"}`,
+		},
+		{
+			name:    "missing variable in strict mode",
+			content: `{"title": "{{param.country}}"}`,
+			strict:  true,
+			err:     ErrMissingVariable,
+		},
+		{
+			name:     "keys and unterminated placeholders are kept",
+			content:  `{"{{param.city}}": "{{param.city"}`,
+			expected: `{"{{param.city}}": "{{param.city"}`,
+		},
+		{
+			name:     "value that needs JSON escaping",
+			content:  `{"title": "\"{{user.name}}\"\n"}`,
+			expected: `{"title": "\"\"Ann\" \\ <b>\"\n"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := Render(json.RawMessage(tt.content), vars, tt.strict)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(rendered))
+			assert.True(t, json.Valid(rendered))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"banner-service/internal/models"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type Client struct {
+	http *http.Client
+}
+
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{
+		http: httpClient,
+	}
+}
+
+// Deliver posts the delivery payload to the subscriber and returns the
+// response status code, if any was received.
+func (c *Client) Deliver(ctx context.Context, delivery models.PendingDelivery) (int, error) {
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(delivery.DeliveryId, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook: unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
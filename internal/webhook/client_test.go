@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestClientDeliverSignsPayload(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := json.RawMessage(`{"event_id":1,"type":"banner.created"}`)
+
+	var received bool
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.True(t, Verify(secret, timestamp, body, r.Header.Get(SignatureHeader)))
+		assert.False(t, Verify("other", timestamp, body, r.Header.Get(SignatureHeader)))
+		assert.Equal(t, "banner.created", r.Header.Get(EventHeader))
+		assert.Equal(t, "7", r.Header.Get(DeliveryHeader))
+		assert.JSONEq(t, string(payload), string(body))
+		received = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	client := NewClient(receiver.Client())
+	status, err := client.Deliver(context.Background(), models.PendingDelivery{
+		WebhookDelivery: models.WebhookDelivery{
+			DeliveryId: 7,
+			EventType:  "banner.created",
+			Payload:    payload,
+		},
+		URL:    receiver.URL,
+		Secret: secret,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.True(t, received)
+}
+
+func TestClientDeliverFailsOnErrorStatus(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	client := NewClient(receiver.Client())
+	status, err := client.Deliver(context.Background(), models.PendingDelivery{
+		WebhookDelivery: models.WebhookDelivery{Payload: json.RawMessage(`{}`)},
+		URL:             receiver.URL,
+		Secret:          "secret",
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+}
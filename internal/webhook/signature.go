@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+const (
+	SignatureHeader = "X-Banner-Signature"
+	TimestampHeader = "X-Banner-Timestamp"
+	EventHeader     = "X-Banner-Event"
+	DeliveryHeader  = "X-Banner-Delivery"
+
+	signaturePrefix = "sha256="
+)
+
+// Sign computes the HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// subscription secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
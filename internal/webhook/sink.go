@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"banner-service/internal/models"
+	"context"
+)
+
+type subscriptionRepository interface {
+	EnqueueDeliveries(ctx context.Context, event models.OutboxEvent) error
+}
+
+// SubscriptionSink is an outbox sink that turns banner events into
+// deliveries for the matching webhook subscriptions.
+type SubscriptionSink struct {
+	repository subscriptionRepository
+}
+
+func NewSubscriptionSink(repo subscriptionRepository) *SubscriptionSink {
+	return &SubscriptionSink{
+		repository: repo,
+	}
+}
+
+func (s *SubscriptionSink) Name() string {
+	return "webhook_subscriptions"
+}
+
+func (s *SubscriptionSink) Send(ctx context.Context, event models.OutboxEvent) error {
+	return s.repository.EnqueueDeliveries(ctx, event)
+}
@@ -0,0 +1,21 @@
+package worker
+
+import (
+	"math/rand"
+	"time"
+)
+
+const maxBackoff = 5 * time.Minute
+
+// backoff doubles base for every failed attempt up to maxBackoff and applies
+// equal jitter so that retries of many failed items do not line up.
+func backoff(base time.Duration, attempts uint64) time.Duration {
+	d := base
+	for i := uint64(1); i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"banner-service/internal/metrics"
+	"banner-service/internal/models"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// eventFlushTimeout bounds the final flush, which runs after the context of
+// the buffer is done.
+const eventFlushTimeout = 10 * time.Second
+
+type eventRepository interface {
+	InsertBannerEvents(ctx context.Context, events []models.BannerEvent) (int, error)
+}
+
+// EventBuffer collects banner events in memory and writes them in batches,
+// every interval or as soon as a batch is full, so reporting an event never
+// waits for the database. Events of unknown banner versions are dropped by the
+// write.
+type EventBuffer struct {
+	repository eventRepository
+	interval   time.Duration
+	batchSize  int
+	capacity   int
+
+	mu     sync.Mutex
+	events []models.BannerEvent
+	full   chan struct{}
+}
+
+func NewEventBuffer(eventRepo eventRepository, interval time.Duration, batchSize, capacity int) *EventBuffer {
+	return &EventBuffer{
+		repository: eventRepo,
+		interval:   interval,
+		batchSize:  batchSize,
+		capacity:   capacity,
+		full:       make(chan struct{}, 1),
+	}
+}
+
+// Add queues the events and returns how many fit; the rest are dropped once
+// the buffer holds capacity events, when the database falls behind.
+func (b *EventBuffer) Add(events []models.BannerEvent) int {
+	b.mu.Lock()
+	accepted := min(len(events), b.capacity-len(b.events))
+	b.events = append(b.events, events[:accepted]...)
+	batchReady := len(b.events) >= b.batchSize
+	b.mu.Unlock()
+
+	if dropped := len(events) - accepted; dropped > 0 {
+		metrics.BannerEventsDropped.Add(float64(dropped))
+	}
+	if batchReady {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+	return accepted
+}
+
+// Start flushes the buffer until ctx is done, and then flushes what is left.
+func (b *EventBuffer) Start(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.flush(ctx)
+		case <-b.full:
+			b.flush(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), eventFlushTimeout)
+			b.flush(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+func (b *EventBuffer) flush(ctx context.Context) {
+	b.mu.Lock()
+	events := b.events
+	b.events = nil
+	b.mu.Unlock()
+
+	for len(events) > 0 {
+		batch := events[:min(len(events), b.batchSize)]
+		events = events[len(batch):]
+		inserted, err := b.repository.InsertBannerEvents(ctx, batch)
+		if err != nil {
+			metrics.BannerEventsDropped.Add(float64(len(batch)))
+			log.Printf("event buffer: %d events lost: %v", len(batch), err)
+		} else if unknown := len(batch) - inserted; unknown > 0 {
+			metrics.BannerEventsDropped.Add(float64(unknown))
+			log.Printf("event buffer: %d events of unknown banner versions dropped", unknown)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryEvents writes the events of the banner versions it knows, or every
+// event without versions, the way the repository does.
+type memoryEvents struct {
+	mu       sync.Mutex
+	batches  [][]models.BannerEvent
+	versions map[uint64][]uint64
+}
+
+func (m *memoryEvents) InsertBannerEvents(_ context.Context, events []models.BannerEvent) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.versions != nil {
+		events = slices.DeleteFunc(slices.Clone(events), func(event models.BannerEvent) bool {
+			return !slices.Contains(m.versions[event.BannerId], event.Version)
+		})
+	}
+	m.batches = append(m.batches, events)
+	return len(events), nil
+}
+
+func (m *memoryEvents) sizes() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sizes := make([]int, 0, len(m.batches))
+	for _, batch := range m.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func impressions(n int) []models.BannerEvent {
+	events := make([]models.BannerEvent, n)
+	for i := range events {
+		events[i] = models.BannerEvent{Kind: models.EventImpression, BannerId: 1, Version: 1}
+	}
+	return events
+}
+
+func TestEventBufferFlushesFullBatches(t *testing.T) {
+	repo := &memoryEvents{}
+	buffer := NewEventBuffer(repo, time.Hour, 2, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go buffer.Start(ctx)
+
+	assert.Equal(t, 3, buffer.Add(impressions(3)))
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]int{2, 1}, repo.sizes())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEventBufferFlushesOnStop(t *testing.T) {
+	repo := &memoryEvents{}
+	buffer := NewEventBuffer(repo, time.Hour, 100, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		buffer.Start(ctx)
+		close(done)
+	}()
+
+	assert.Equal(t, 3, buffer.Add(impressions(5)), "events over the capacity are dropped")
+	cancel()
+	<-done
+
+	assert.Equal(t, []int{3}, repo.sizes())
+}
+
+func TestEventBufferDropsUnknownVersions(t *testing.T) {
+	repo := &memoryEvents{versions: map[uint64][]uint64{1: {1}}}
+	buffer := NewEventBuffer(repo, time.Hour, 100, 10)
+
+	assert.Equal(t, 3, buffer.Add([]models.BannerEvent{
+		{Kind: models.EventImpression, BannerId: 1, Version: 1},
+		{Kind: models.EventImpression, BannerId: 1, Version: 2},
+		{Kind: models.EventClick, BannerId: 2, Version: 1},
+	}), "a report is accepted without a lookup")
+	buffer.flush(context.Background())
+
+	assert.Equal(t, []int{1}, repo.sizes(), "only the event of a known version is written")
+}
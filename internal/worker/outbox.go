@@ -0,0 +1,117 @@
+package worker
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	outboxBatchSize     = 100
+	outboxLease         = 30 * time.Second
+	outboxPruneInterval = 10 * time.Minute
+)
+
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event models.OutboxEvent) error
+}
+
+type outboxRepository interface {
+	ClaimOutboxEvents(ctx context.Context, limit uint64, lease time.Duration) ([]models.OutboxEvent, error)
+	MarkOutboxEventDelivered(ctx context.Context, eventId uint64) error
+	MarkOutboxEventFailed(ctx context.Context, eventId uint64, lastError string, backoff time.Duration, dead bool) error
+	DeleteFinishedOutboxEvents(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+type OutboxRelay struct {
+	repository   outboxRepository
+	sinks        []Sink
+	interval     time.Duration
+	maxAttempts  uint64
+	retryBackoff time.Duration
+	retention    time.Duration
+}
+
+// NewOutboxRelay creates a relay that also deletes the events delivered or
+// dead for longer than retention.
+func NewOutboxRelay(repo outboxRepository, sinks []Sink, interval time.Duration, maxAttempts uint64, retryBackoff, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		repository:   repo,
+		sinks:        sinks,
+		interval:     interval,
+		maxAttempts:  maxAttempts,
+		retryBackoff: retryBackoff,
+		retention:    retention,
+	}
+}
+
+func (w *OutboxRelay) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(outboxPruneInterval)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.relay(ctx); err != nil {
+				log.Printf("outbox relay: %v", err)
+			}
+		case <-pruneTicker.C:
+			if err := w.prune(ctx); err != nil {
+				log.Printf("outbox relay: prune: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *OutboxRelay) relay(ctx context.Context) error {
+	events, err := w.repository.ClaimOutboxEvents(ctx, outboxBatchSize, outboxLease)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		if err = w.deliver(ctx, event); err != nil {
+			log.Printf("outbox relay: event %d: %v", event.EventId, err)
+		}
+	}
+	return nil
+}
+
+func (w *OutboxRelay) deliver(ctx context.Context, event models.OutboxEvent) error {
+	var sendErr error
+	for _, sink := range w.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			sendErr = fmt.Errorf("%s: %w", sink.Name(), err)
+			break
+		}
+	}
+
+	if sendErr == nil {
+		return w.repository.MarkOutboxEventDelivered(ctx, event.EventId)
+	}
+
+	attempts := event.Attempts + 1
+	dead := attempts >= w.maxAttempts
+	if err := w.repository.MarkOutboxEventFailed(ctx, event.EventId, sendErr.Error(), backoff(w.retryBackoff, attempts), dead); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func (w *OutboxRelay) prune(ctx context.Context) error {
+	deleted, err := w.repository.DeleteFinishedOutboxEvents(ctx, w.retention)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("outbox relay: pruned %d events", deleted)
+	}
+	return nil
+}
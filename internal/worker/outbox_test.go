@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type outboxResult struct {
+	eventId   uint64
+	delivered bool
+	lastError string
+	backoff   time.Duration
+	dead      bool
+}
+
+type memoryOutbox struct {
+	events    []models.OutboxEvent
+	results   []outboxResult
+	retention time.Duration
+}
+
+func (m *memoryOutbox) ClaimOutboxEvents(_ context.Context, _ uint64, _ time.Duration) ([]models.OutboxEvent, error) {
+	events := m.events
+	m.events = nil
+	return events, nil
+}
+
+func (m *memoryOutbox) MarkOutboxEventDelivered(_ context.Context, eventId uint64) error {
+	m.results = append(m.results, outboxResult{eventId: eventId, delivered: true})
+	return nil
+}
+
+func (m *memoryOutbox) MarkOutboxEventFailed(_ context.Context, eventId uint64, lastError string, backoff time.Duration, dead bool) error {
+	m.results = append(m.results, outboxResult{eventId: eventId, lastError: lastError, backoff: backoff, dead: dead})
+	return nil
+}
+
+func (m *memoryOutbox) DeleteFinishedOutboxEvents(_ context.Context, retention time.Duration) (int64, error) {
+	m.retention = retention
+	return 0, nil
+}
+
+// memorySink records the events it got and fails the ones in fail.
+type memorySink struct {
+	name string
+	fail map[uint64]bool
+	sent []uint64
+}
+
+func (s *memorySink) Name() string {
+	return s.name
+}
+
+func (s *memorySink) Send(_ context.Context, event models.OutboxEvent) error {
+	if s.fail[event.EventId] {
+		return errors.New("unavailable")
+	}
+	s.sent = append(s.sent, event.EventId)
+	return nil
+}
+
+func TestOutboxRelayDelivers(t *testing.T) {
+	repo := &memoryOutbox{events: []models.OutboxEvent{{EventId: 1}, {EventId: 2}}}
+	first, second := &memorySink{name: "first"}, &memorySink{name: "second"}
+	relay := NewOutboxRelay(repo, []Sink{first, second}, time.Second, 3, time.Second, time.Hour)
+
+	require.NoError(t, relay.relay(context.Background()))
+
+	assert.Equal(t, []uint64{1, 2}, first.sent)
+	assert.Equal(t, []uint64{1, 2}, second.sent)
+	assert.Equal(t, []outboxResult{{eventId: 1, delivered: true}, {eventId: 2, delivered: true}}, repo.results)
+}
+
+func TestOutboxRelayRetriesFailedEvents(t *testing.T) {
+	repo := &memoryOutbox{events: []models.OutboxEvent{{EventId: 1}, {EventId: 2, Attempts: 2}}}
+	first := &memorySink{name: "first", fail: map[uint64]bool{1: true, 2: true}}
+	second := &memorySink{name: "second"}
+	relay := NewOutboxRelay(repo, []Sink{first, second}, time.Second, 3, time.Second, time.Hour)
+
+	require.NoError(t, relay.relay(context.Background()), "failed deliveries are scheduled, not returned")
+
+	assert.Empty(t, second.sent, "the sinks after a failed one are not tried")
+	require.Len(t, repo.results, 2)
+	assert.Equal(t, "first: unavailable", repo.results[0].lastError)
+	assert.False(t, repo.results[0].dead)
+	assert.InDelta(t, time.Second, repo.results[0].backoff, float64(time.Second/2))
+	assert.True(t, repo.results[1].dead, "the last attempt moves the event to dead")
+}
+
+func TestOutboxRelayPrunes(t *testing.T) {
+	repo := &memoryOutbox{}
+	relay := NewOutboxRelay(repo, nil, time.Second, 3, time.Second, 24*time.Hour)
+
+	require.NoError(t, relay.prune(context.Background()))
+	assert.Equal(t, 24*time.Hour, repo.retention)
+}
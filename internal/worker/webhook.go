@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	webhookBatchSize = 50
+	webhookLease     = time.Minute
+	// webhookConcurrency bounds the deliveries of a batch sent at once.
+	webhookConcurrency = 10
+)
+
+type deliveryClient interface {
+	Deliver(ctx context.Context, delivery models.PendingDelivery) (int, error)
+}
+
+type deliveryRepository interface {
+	ClaimDeliveries(ctx context.Context, limit uint64, lease time.Duration) ([]models.PendingDelivery, error)
+	MarkDeliveryDelivered(ctx context.Context, deliveryId uint64, responseStatus int) error
+	MarkDeliveryFailed(ctx context.Context, deliveryId uint64, responseStatus *int, lastError string, backoff time.Duration, dead bool) error
+}
+
+// WebhookDispatcher sends the pending deliveries in batches it claims for a
+// lease. A batch is sized so that its deliveries, webhookConcurrency at a
+// time and each bounded by the client timeout, end within the lease, and a
+// delivery that could not end within it is left to the next claim instead of
+// being sent twice.
+type WebhookDispatcher struct {
+	repository   deliveryRepository
+	client       deliveryClient
+	interval     time.Duration
+	maxAttempts  uint64
+	retryBackoff time.Duration
+	lease        time.Duration
+	timeout      time.Duration
+	batchSize    uint64
+}
+
+// NewWebhookDispatcher creates a dispatcher for a client whose deliveries
+// take up to timeout.
+func NewWebhookDispatcher(repo deliveryRepository, client deliveryClient, interval time.Duration, maxAttempts uint64, retryBackoff time.Duration, timeout time.Duration) *WebhookDispatcher {
+	// The lease holds at least one round of deliveries besides the margin
+	// of one timeout left for recording the results.
+	lease := max(webhookLease, 2*timeout)
+	rounds := uint64(1)
+	if timeout > 0 {
+		rounds = max(uint64(lease/timeout)-1, 1)
+	}
+	return &WebhookDispatcher{
+		repository:   repo,
+		client:       client,
+		interval:     interval,
+		maxAttempts:  maxAttempts,
+		retryBackoff: retryBackoff,
+		lease:        lease,
+		timeout:      timeout,
+		batchSize:    min(webhookBatchSize, rounds*webhookConcurrency),
+	}
+}
+
+func (w *WebhookDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.dispatch(ctx); err != nil {
+				log.Printf("webhook dispatcher: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *WebhookDispatcher) dispatch(ctx context.Context) error {
+	leaseEnd := time.Now().Add(w.lease)
+	deliveries, err := w.repository.ClaimDeliveries(ctx, w.batchSize, w.lease)
+	if err != nil {
+		return err
+	}
+
+	queue := make(chan models.PendingDelivery)
+	var senders sync.WaitGroup
+	for i := 0; i < min(webhookConcurrency, len(deliveries)); i++ {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for delivery := range queue {
+				if time.Until(leaseEnd) < 2*w.timeout {
+					// Another claim takes it once the lease ends.
+					continue
+				}
+				if err := w.deliver(ctx, delivery); err != nil {
+					log.Printf("webhook dispatcher: delivery %d: %v", delivery.DeliveryId, err)
+				}
+			}
+		}()
+	}
+	for _, delivery := range deliveries {
+		queue <- delivery
+	}
+	close(queue)
+	senders.Wait()
+	return nil
+}
+
+func (w *WebhookDispatcher) deliver(ctx context.Context, delivery models.PendingDelivery) error {
+	status, sendErr := w.client.Deliver(ctx, delivery)
+	if sendErr == nil {
+		return w.repository.MarkDeliveryDelivered(ctx, delivery.DeliveryId, status)
+	}
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+
+	attempts := delivery.Attempts + 1
+	dead := attempts >= w.maxAttempts
+	if err := w.repository.MarkDeliveryFailed(ctx, delivery.DeliveryId, responseStatus, sendErr.Error(), backoff(w.retryBackoff, attempts), dead); err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"banner-service/internal/models"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryDeliveries struct {
+	mu        sync.Mutex
+	pending   []models.PendingDelivery
+	limit     uint64
+	delivered []uint64
+}
+
+func (m *memoryDeliveries) ClaimDeliveries(_ context.Context, limit uint64, _ time.Duration) ([]models.PendingDelivery, error) {
+	m.limit = limit
+	claimed := m.pending[:min(uint64(len(m.pending)), limit)]
+	m.pending = m.pending[len(claimed):]
+	return claimed, nil
+}
+
+func (m *memoryDeliveries) MarkDeliveryDelivered(_ context.Context, deliveryId uint64, _ int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.delivered = append(m.delivered, deliveryId)
+	return nil
+}
+
+func (m *memoryDeliveries) MarkDeliveryFailed(_ context.Context, _ uint64, _ *int, _ string, _ time.Duration, _ bool) error {
+	return nil
+}
+
+// slowClient takes delay for every delivery and tracks how many run at once.
+type slowClient struct {
+	delay    time.Duration
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (c *slowClient) Deliver(_ context.Context, _ models.PendingDelivery) (int, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return 200, nil
+}
+
+func pendingDeliveries(n int) []models.PendingDelivery {
+	deliveries := make([]models.PendingDelivery, n)
+	for i := range deliveries {
+		deliveries[i].DeliveryId = uint64(i + 1)
+	}
+	return deliveries
+}
+
+func TestWebhookDispatcherFitsBatchInLease(t *testing.T) {
+	for _, tt := range []struct {
+		timeout   time.Duration
+		batchSize uint64
+	}{
+		{10 * time.Second, 50},
+		{20 * time.Second, 20},
+		{45 * time.Second, webhookConcurrency},
+	} {
+		w := NewWebhookDispatcher(&memoryDeliveries{}, &slowClient{}, time.Second, 3, time.Second, tt.timeout)
+
+		assert.Equal(t, tt.batchSize, w.batchSize, "timeout %s", tt.timeout)
+		rounds := (tt.batchSize + webhookConcurrency - 1) / webhookConcurrency
+		assert.Less(t, time.Duration(rounds+1)*tt.timeout, w.lease+time.Nanosecond, "timeout %s", tt.timeout)
+	}
+}
+
+func TestWebhookDispatcherLeavesDeliveriesPastLease(t *testing.T) {
+	repo := &memoryDeliveries{pending: pendingDeliveries(30)}
+	client := &slowClient{delay: 20 * time.Millisecond}
+	w := NewWebhookDispatcher(repo, client, time.Second, 3, time.Second, 20*time.Millisecond)
+	w.lease = 50 * time.Millisecond
+
+	assert.NoError(t, w.dispatch(context.Background()))
+
+	assert.Equal(t, webhookConcurrency, client.peak, "deliveries are sent concurrently up to the bound")
+	assert.Len(t, repo.delivered, webhookConcurrency, "a delivery that could outlive the lease is not sent")
+}
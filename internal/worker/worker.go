@@ -7,16 +7,18 @@ import (
 )
 
 type repository interface {
-	DeleteMarkedBanners(ctx context.Context) error
+	DeleteMarkedBanners(ctx context.Context, gracePeriod time.Duration) error
 }
 
 type BannerCollector struct {
-	repository repository
+	repository  repository
+	gracePeriod time.Duration
 }
 
-func NewBannerCollector(bannerRepo repository) *BannerCollector {
+func NewBannerCollector(bannerRepo repository, gracePeriod time.Duration) *BannerCollector {
 	return &BannerCollector{
-		repository: bannerRepo,
+		repository:  bannerRepo,
+		gracePeriod: gracePeriod,
 	}
 }
 
@@ -27,7 +29,7 @@ func (w *BannerCollector) Start(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
-			err := w.repository.DeleteMarkedBanners(ctx)
+			err := w.repository.DeleteMarkedBanners(ctx, w.gracePeriod)
 			if err != nil {
 				log.Printf("worker: %v", err)
 			}
@@ -37,3 +39,53 @@ func (w *BannerCollector) Start(ctx context.Context) {
 		}
 	}
 }
+
+type assetRepository interface {
+	DeleteUnreferencedAssets(ctx context.Context, gracePeriod time.Duration, remove func(hash string) error) ([]string, error)
+}
+
+type assetStorage interface {
+	Delete(ctx context.Context, hash string) error
+}
+
+// AssetCollector removes the assets no banner version refers to any more,
+// each record under the lock of the asset and then its file.
+type AssetCollector struct {
+	repository  assetRepository
+	storage     assetStorage
+	gracePeriod time.Duration
+}
+
+func NewAssetCollector(assetRepo assetRepository, storage assetStorage, gracePeriod time.Duration) *AssetCollector {
+	return &AssetCollector{
+		repository:  assetRepo,
+		storage:     storage,
+		gracePeriod: gracePeriod,
+	}
+}
+
+func (w *AssetCollector) Start(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.collect(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *AssetCollector) collect(ctx context.Context) {
+	hashes, err := w.repository.DeleteUnreferencedAssets(ctx, w.gracePeriod, func(hash string) error {
+		return w.storage.Delete(ctx, hash)
+	})
+	if err != nil {
+		log.Printf("asset collector: %v", err)
+	}
+	if len(hashes) > 0 {
+		log.Printf("asset collector: removed %d assets", len(hashes))
+	}
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// memoryAssets removes the unreferenced assets it holds the way the
+// repository does: the record goes first, and a failed remove stops the run.
+type memoryAssets struct {
+	unreferenced []string
+}
+
+func (m *memoryAssets) DeleteUnreferencedAssets(_ context.Context, _ time.Duration, remove func(hash string) error) ([]string, error) {
+	var deleted []string
+	for i, hash := range m.unreferenced {
+		deleted = append(deleted, hash)
+		if err := remove(hash); err != nil {
+			m.unreferenced = m.unreferenced[i+1:]
+			return deleted, err
+		}
+	}
+	m.unreferenced = nil
+	return deleted, nil
+}
+
+type memoryStorage struct {
+	files map[string]bool
+	fail  map[string]bool
+}
+
+func (m *memoryStorage) Delete(_ context.Context, hash string) error {
+	if m.fail[hash] {
+		return errors.New("disk failure")
+	}
+	delete(m.files, hash)
+	return nil
+}
+
+func TestAssetCollectorRemovesFilesAfterRecords(t *testing.T) {
+	repo := &memoryAssets{unreferenced: []string{"a", "b", "c"}}
+	storage := &memoryStorage{files: map[string]bool{"a": true, "b": true, "c": true, "d": true}, fail: map[string]bool{"b": true}}
+	collector := NewAssetCollector(repo, storage, time.Hour)
+
+	collector.collect(context.Background())
+
+	assert.Equal(t, map[string]bool{"b": true, "c": true, "d": true}, storage.files, "only unreferenced files go")
+	assert.Equal(t, []string{"c"}, repo.unreferenced, "a file that could not be removed stops the run")
+
+	storage.fail = nil
+	collector.collect(context.Background())
+	assert.Equal(t, map[string]bool{"b": true, "d": true}, storage.files, "the file of a deleted record is left behind")
+	assert.Empty(t, repo.unreferenced)
+}
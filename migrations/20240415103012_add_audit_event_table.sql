@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+create table audit_event
+(
+    event_id   bigserial primary key,
+    actor      text      not null,
+    action     text      not null,
+    banner_id  bigint    not null,
+    before     jsonb,
+    after      jsonb,
+    request_id text      not null default '',
+    created_at timestamp not null default current_timestamp
+);
+
+create index audit_event_actor_idx on audit_event (actor, event_id);
+create index audit_event_banner_id_idx on audit_event (banner_id, event_id);
+create index audit_event_created_at_idx on audit_event (created_at);
+
+create rule audit_event_no_update as on update to audit_event do instead nothing;
+create rule audit_event_no_delete as on delete to audit_event do instead nothing;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table audit_event;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table banner add column deleted_at timestamp;
+
+update banner set deleted_at = current_timestamp where must_be_deleted;
+
+alter table banner drop column must_be_deleted;
+
+create index banner_deleted_at_idx on banner (deleted_at) where deleted_at is not null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table banner add column must_be_deleted boolean not null default false;
+
+update banner set must_be_deleted = true where deleted_at is not null;
+
+alter table banner drop column deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+create table outbox_event
+(
+    event_id        bigserial primary key,
+    banner_id       bigint    not null,
+    event_type      text      not null,
+    payload         jsonb     not null,
+    status          text      not null default 'pending',
+    attempts        integer   not null default 0,
+    next_attempt_at timestamp not null default current_timestamp,
+    last_error      text      not null default '',
+    created_at      timestamp not null default current_timestamp,
+    delivered_at    timestamp
+);
+
+create index outbox_event_pending_idx on outbox_event (banner_id, event_id) where status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table outbox_event;
+-- +goose StatementEnd
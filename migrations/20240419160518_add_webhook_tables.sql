@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+create table webhook_subscription
+(
+    subscription_id bigserial primary key,
+    url             text      not null,
+    secret          text      not null,
+    feature_id      integer,
+    tag_id          integer,
+    event_types     text[]    not null default '{}',
+    is_active       boolean   not null default true,
+    created_at      timestamp not null default current_timestamp
+);
+
+create table webhook_delivery
+(
+    delivery_id     bigserial primary key,
+    subscription_id bigint    not null references webhook_subscription on delete cascade,
+    event_id        bigint    not null,
+    event_type      text      not null,
+    payload         jsonb     not null,
+    status          text      not null default 'pending',
+    attempts        integer   not null default 0,
+    response_status integer,
+    last_error      text      not null default '',
+    next_attempt_at timestamp not null default current_timestamp,
+    created_at      timestamp not null default current_timestamp,
+    delivered_at    timestamp,
+    unique (subscription_id, event_id)
+);
+
+create index webhook_delivery_pending_idx on webhook_delivery (next_attempt_at) where status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table webhook_delivery;
+drop table webhook_subscription;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table role_endpoints drop constraint role_endpoints_pkey;
+
+alter table role_endpoints add primary key (role, resource);
+
+insert into role_endpoints (role, resource)
+values ('user', 'GET /user_banner/stream');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete from role_endpoints where role = 'user' and resource = 'GET /user_banner/stream';
+
+alter table role_endpoints drop constraint role_endpoints_pkey;
+
+alter table role_endpoints add primary key (role);
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+insert into role_endpoints (role, resource)
+values ('user', 'GET /user_banner/snapshot');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete from role_endpoints where role = 'user' and resource = 'GET /user_banner/snapshot';
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table banner add column updated_at timestamp not null default current_timestamp;
+
+update banner b
+set updated_at = bv.updated_at
+from banner_version bv
+where bv.banner_id = b.banner_id and bv.version = b.active_version;
+
+create index banner_created_at_idx on banner (created_at, banner_id) where deleted_at is null;
+create index banner_updated_at_idx on banner (updated_at, banner_id) where deleted_at is null;
+create index banner_feature_tag_banner_id_idx on banner_feature_tag (banner_id);
+create index banner_feature_tag_feature_id_idx on banner_feature_tag (feature_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index banner_feature_tag_feature_id_idx;
+drop index banner_feature_tag_banner_id_idx;
+drop index banner_updated_at_idx;
+drop index banner_created_at_idx;
+
+alter table banner drop column updated_at;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+create index banner_version_content_idx on banner_version using gin (content jsonb_path_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index banner_version_content_idx;
+-- +goose StatementEnd
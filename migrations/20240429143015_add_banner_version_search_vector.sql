@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table banner_version
+    add column search_vector tsvector
+        generated always as (jsonb_to_tsvector('simple', coalesce(content, '{}'), '["string"]')) stored;
+
+create index banner_version_search_vector_idx on banner_version using gin (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index banner_version_search_vector_idx;
+
+alter table banner_version drop column search_vector;
+-- +goose StatementEnd
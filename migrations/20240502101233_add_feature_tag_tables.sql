@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+create table feature
+(
+    feature_id  integer   primary key,
+    name        text      not null unique,
+    description text      not null default '',
+    owner       text      not null default '',
+    archived    boolean   not null default false,
+    created_at  timestamp not null default current_timestamp,
+    updated_at  timestamp not null default current_timestamp
+);
+
+create table tag
+(
+    tag_id      integer   primary key,
+    name        text      not null unique,
+    description text      not null default '',
+    owner       text      not null default '',
+    archived    boolean   not null default false,
+    created_at  timestamp not null default current_timestamp,
+    updated_at  timestamp not null default current_timestamp
+);
+
+-- Register the ids banners already use, so that strict reference checks can be
+-- turned on right away.
+insert into feature (feature_id, name)
+select distinct feature_id, 'feature ' || feature_id
+from banner_feature_tag;
+
+insert into tag (tag_id, name)
+select distinct tag_id, 'tag ' || tag_id
+from banner_feature_tag;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table tag;
+drop table feature;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table tag
+    add column parent_tag_id integer references tag on delete restrict,
+    add constraint tag_parent_check check (parent_tag_id <> tag_id);
+
+alter table feature
+    add column default_banner_id bigint references banner on delete set null;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table feature drop column default_banner_id;
+
+alter table tag drop column parent_tag_id;
+-- +goose StatementEnd
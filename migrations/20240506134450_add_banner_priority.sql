@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table banner add column priority integer not null default 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table banner drop column priority;
+-- +goose StatementEnd
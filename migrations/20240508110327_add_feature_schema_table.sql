@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+create table feature_schema
+(
+    feature_id integer   not null,
+    version    integer   not null,
+    schema     jsonb,
+    created_by text      not null default '',
+    created_at timestamp not null default current_timestamp,
+    primary key (feature_id, version)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table feature_schema;
+-- +goose StatementEnd
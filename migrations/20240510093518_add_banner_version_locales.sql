@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- localized maps a locale to the content in that language; content stays the
+-- default served when the requested locale is missing, and default_locale
+-- names its language, if known.
+alter table banner_version add column localized jsonb not null default '{}';
+alter table banner_version add column default_locale text not null default '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table banner_version drop column default_locale;
+alter table banner_version drop column localized;
+-- +goose StatementEnd
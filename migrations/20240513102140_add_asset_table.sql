@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+create table asset
+(
+    hash       text      not null primary key,
+    mime_type  text      not null,
+    size       bigint    not null,
+    created_by text      not null default '',
+    created_at timestamp not null default current_timestamp
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table asset;
+-- +goose StatementEnd
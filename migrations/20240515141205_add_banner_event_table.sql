@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+create table banner_event
+(
+    kind        text      not null check (kind in ('impression', 'click')),
+    banner_id   integer   not null,
+    version     integer   not null,
+    tag_id      integer   not null,
+    feature_id  integer   not null,
+    occurred_at timestamp not null
+);
+
+create index banner_event_banner_id_occurred_at_idx on banner_event (banner_id, occurred_at);
+
+insert into role_endpoints (role, resource)
+values ('user', 'POST /events');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+delete from role_endpoints where role = 'user' and resource = 'POST /events';
+
+drop table banner_event;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table banner add column frequency_cap jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table banner drop column frequency_cap;
+-- +goose StatementEnd
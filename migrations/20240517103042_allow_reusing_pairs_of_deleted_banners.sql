@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+alter table banner_feature_tag add column deleted boolean not null default false;
+
+update banner_feature_tag bft
+set deleted = true
+from banner b
+where b.banner_id = bft.banner_id and b.deleted_at is not null;
+
+alter table banner_feature_tag drop constraint banner_feature_tag_pkey;
+
+create unique index banner_feature_tag_pair_idx on banner_feature_tag (tag_id, feature_id) where not deleted;
+create index banner_feature_tag_tag_id_feature_id_idx on banner_feature_tag (tag_id, feature_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index banner_feature_tag_tag_id_feature_id_idx;
+drop index banner_feature_tag_pair_idx;
+
+alter table banner_feature_tag add primary key (tag_id, feature_id);
+
+alter table banner_feature_tag drop column deleted;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+create index outbox_event_finished_idx on outbox_event (coalesce(delivered_at, next_attempt_at)) where status <> 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index outbox_event_finished_idx;
+-- +goose StatementEnd
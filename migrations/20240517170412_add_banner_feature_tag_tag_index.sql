@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+create index banner_feature_tag_tag_id_banner_id_idx on banner_feature_tag (tag_id, banner_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop index banner_feature_tag_tag_id_banner_id_idx;
+-- +goose StatementEnd
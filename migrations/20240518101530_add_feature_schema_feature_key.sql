@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Register the features that have schemas but are not in the catalog yet.
+insert into feature (feature_id, name)
+select distinct s.feature_id, 'feature ' || s.feature_id
+from feature_schema s
+where not exists (select 1 from feature f where f.feature_id = s.feature_id);
+
+alter table feature_schema
+    add constraint feature_schema_feature_id_fkey foreign key (feature_id) references feature on delete cascade;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+alter table feature_schema drop constraint feature_schema_feature_id_fkey;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- uploaded_at is the last upload of the content; the grace period of the
+-- collector counts from it, so uploading an asset again keeps it.
+alter table asset add column uploaded_at timestamp not null default current_timestamp;
+update asset set uploaded_at = created_at;
+
+-- The assets each banner version refers to. The collector looks references
+-- up by hash here instead of scanning the content, and the key on asset keeps
+-- a referenced asset from being removed.
+create table banner_version_asset
+(
+    banner_id bigint  not null,
+    version   integer not null,
+    hash      text    not null references asset,
+    primary key (banner_id, version, hash),
+    foreign key (banner_id, version) references banner_version on delete cascade
+);
+
+create index banner_version_asset_hash_idx on banner_version_asset (hash);
+
+-- The same paths asset.References matches: /assets/{hash} not after a host.
+insert into banner_version_asset (banner_id, version, hash)
+select distinct bv.banner_id, bv.version, m[1]
+from banner_version bv
+cross join lateral regexp_matches(bv.content::text || ' ' || bv.localized::text,
+                                  '(?:^|[^0-9A-Za-z._~%:@/-])/assets/([0-9a-f]{64})(?![0-9A-Za-z])', 'g') m
+where exists (select 1 from asset a where a.hash = m[1]);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+drop table banner_version_asset;
+alter table asset drop column uploaded_at;
+-- +goose StatementEnd